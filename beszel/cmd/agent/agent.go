@@ -3,12 +3,12 @@ package main
 import (
 	"beszel"
 	"beszel/internal/agent"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-
-	"golang.org/x/crypto/ssh"
 )
 
 // cli options
@@ -26,7 +26,8 @@ func (opts *cmdOptions) parse() bool {
 	flag.Usage = func() {
 		fmt.Printf("Usage: %s [command] [flags]\n", os.Args[0])
 		fmt.Println("\nCommands:")
-		fmt.Println("  health    Check if the agent is running")
+		fmt.Println("  diagnose  Run one collection and print detected devices (-json for JSON output)")
+		fmt.Println("  health    Check if the agent is running (set HEALTH_KEY_FILE to request a payload)")
 		fmt.Println("  help      Display this help message")
 		fmt.Println("  update    Update to the latest version")
 		fmt.Println("  version   Display the version")
@@ -49,14 +50,43 @@ func (opts *cmdOptions) parse() bool {
 	case "update":
 		agent.Update()
 		return true
+	case "diagnose":
+		diagnoseFlags := flag.NewFlagSet(subcommand, flag.ExitOnError)
+		asJSON := diagnoseFlags.Bool("json", false, "Output as JSON")
+		diagnoseFlags.Parse(os.Args[2:])
+		report := agent.Diagnose()
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			report.Print(os.Stdout)
+		}
+		return true
 	case "health":
 		// for health, we need to parse flags first to get the listen address
 		args := append(os.Args[2:], subcommand)
 		flag.CommandLine.Parse(args)
 		addr := opts.getAddress()
 		network := agent.GetNetwork(addr)
-		err := agent.Health(addr, network)
+		signer, err := agent.LoadHealthSigner()
+		if err != nil {
+			log.Fatal(err)
+		}
+		// request a payload if a key is configured, otherwise only check the connection
+		if signer != nil {
+			err = agent.DeepHealth(addr, network, signer)
+		} else {
+			err = agent.Health(addr, network)
+		}
 		if err != nil {
+			var healthErr *agent.HealthError
+			if errors.As(err, &healthErr) {
+				log.Print(err)
+				os.Exit(healthErr.Code)
+			}
 			log.Fatal(err)
 		}
 		fmt.Print("ok")
@@ -68,7 +98,7 @@ func (opts *cmdOptions) parse() bool {
 }
 
 // loadPublicKeys loads the public keys from the command line flag, environment variable, or key file.
-func (opts *cmdOptions) loadPublicKeys() ([]ssh.PublicKey, error) {
+func (opts *cmdOptions) loadPublicKeys() ([]agent.AuthorizedKey, error) {
 	// Try command line flag first
 	if opts.key != "" {
 		return agent.ParseKeys(opts.key)
@@ -9,28 +9,67 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
 )
 
 type Agent struct {
-	sync.Mutex                               // Used to lock agent while collecting data
-	debug         bool                       // true if LOG_LEVEL is set to debug
-	zfs           bool                       // true if system has arcstats
-	memCalc       string                     // Memory calculation formula
-	fsNames       []string                   // List of filesystem device names being monitored
-	fsStats       map[string]*system.FsStats // Keeps track of disk stats for each filesystem
-	netInterfaces map[string]struct{}        // Stores all valid network interfaces
-	netIoStats    system.NetIoStats          // Keeps track of bandwidth usage
-	dockerManager *dockerManager             // Manages Docker API requests
-	sensorConfig  *SensorConfig              // Sensors config
-	systemInfo    system.Info                // Host system info
-	gpuManager    *GPUManager                // Manages GPU data
-	cache         *SessionCache              // Cache for system stats based on primary session ID
+	sync.Mutex                                // Protects the session cache
+	debug          bool                       // true if LOG_LEVEL is set to debug
+	zfs            bool                       // true if system has arcstats
+	memCalc        string                     // Memory calculation formula
+	fsNames        []string                   // List of filesystem device names being monitored
+	fsStats        map[string]*system.FsStats // Keeps track of disk stats for each filesystem
+	rootDevice     string                     // Detected root device name, empty if not detected
+	rootIoFallback bool                       // true if root I/O uses a fallback device
+	netLock        sync.Mutex                 // Protects netInterfaces, which is replaced rather than modified
+	netInterfaces  map[string]struct{}        // Stores all valid network interfaces
+	netIoStats     system.NetIoStats          // Keeps track of bandwidth usage
+	links          *linkReader                // Reads link state and speed of network interfaces (nil without sysfs)
+	dockerManager  *dockerManager             // Manages Docker API requests
+	sensorConfig   *SensorConfig              // Sensors config
+	hwmon          *hwmonReader               // Reads fan, voltage, current and power sensors (nil if no hwmon chips)
+	cpufreq        *cpufreqReader             // Reads cpu frequencies and throttle counters (nil if unavailable)
+	rapl           *raplReader                // Reads package and DRAM energy counters (nil if unavailable)
+	zfsStats       *zfsReader                 // Reads ARC hit ratios and pool health (nil without ZFS)
+	mdstat         string                     // Path of mdstat (empty without md RAID support)
+	smart          *smartChecker              // Checks SMART health of disks (nil if disabled or unsupported)
+	power          *powerReader               // Reads batteries, AC adapters and UPS devices (nil if none)
+	systemInfo     system.Info                // Host system info
+	gpuManager     *GPUManager                // Manages GPU data
+	execMetrics    *execMetrics               // Runs custom metrics commands (nil if none configured)
+	textfiles      *textfileMetrics           // Reads custom metrics from .prom files (nil if not configured)
+	scrapeTargets  *scrapeTargets             // Scrapes local Prometheus endpoints (nil if none configured)
+	probes         []*probe                   // HTTP, TCP and DNS probes from PROBES_FILE
+	certChecker    *certChecker               // Checks TLS certificate expiry (nil if none configured)
+	inventory      *inventoryReader           // Reads hardware and OS inventory (nil if disabled)
+	updates        *updateChecker             // Checks for pending package updates (nil if disabled or unsupported)
+	sockets        *socketReader              // Reads listening sockets and TCP states (nil if procfs is unavailable)
+	sessions       *sessionReader             // Reads logged in users and recent logins (nil if utmp is unavailable)
+	collectors     []*registeredCollector     // Enabled collectors in the order their data is applied
+	process        *process.Process           // Agent process, used for self-metrics
+	cache          *SessionCache              // Per hub state used to calculate rates between requests
+	sampler        *statsSampler              // High resolution sampler for max / p95 values (nil if disabled)
 }
 
+// NewAgent creates an agent and starts its background samplers and checks
 func NewAgent() *Agent {
+	agent := newAgent()
+	agent.startBackground()
+
+	// if debugging, print stats
+	if agent.debug {
+		slog.Debug("Stats", "data", agent.gatherStats(""))
+	}
+
+	return agent
+}
+
+// newAgent creates an agent and its collectors without starting anything in the background
+func newAgent() *Agent {
 	agent := &Agent{
 		fsStats: make(map[string]*system.FsStats),
-		cache:   NewSessionCache(69 * time.Second),
+		cache:   NewSessionCache(10 * time.Minute),
 	}
 	agent.memCalc, _ = GetEnv("MEM_CALC")
 	agent.sensorConfig = agent.newSensorConfig()
@@ -49,6 +88,9 @@ func NewAgent() *Agent {
 
 	slog.Debug(beszel.Version)
 
+	// create sampler before initializing disks / network so it receives devices
+	agent.sampler = newStatsSampler()
+
 	// initialize system info / docker manager
 	agent.initializeSystemInfo()
 	agent.initializeDiskInfo()
@@ -62,14 +104,55 @@ func NewAgent() *Agent {
 		agent.gpuManager = gm
 	}
 
-	// if debugging, print stats
-	if agent.debug {
-		slog.Debug("Stats", "data", agent.gatherStats(""))
+	agent.execMetrics = newExecMetrics()
+	agent.textfiles = newTextfileMetrics()
+	agent.scrapeTargets = newScrapeTargets()
+	agent.probes = loadProbes()
+	agent.hwmon = newHwmonReader(agent.sensorConfig.sysPath)
+	agent.cpufreq = newCpufreqReader()
+	agent.rapl = newRaplReader()
+	agent.links = newLinkReader()
+	agent.zfsStats = newZfsReader()
+	if _, err := os.Stat(defaultMdstatPath); err == nil {
+		agent.mdstat = defaultMdstatPath
 	}
+	agent.smart = newSmartChecker()
+	agent.certChecker = newCertChecker()
+	agent.inventory = newInventoryReader()
+	agent.updates = newUpdateChecker()
+	agent.sockets = newSocketReader()
+	agent.sessions = newSessionReader()
+	agent.power = newPowerReader()
+
+	agent.initializeCollectors()
 
 	return agent
 }
 
+// startBackground starts the sampler and the checks which run on their own interval
+func (a *Agent) startBackground() {
+	// start high resolution sampling
+	if a.sampler != nil {
+		go a.sampler.start()
+	}
+
+	// start custom metrics commands
+	if a.execMetrics != nil {
+		go a.execMetrics.start()
+	}
+
+	startProbes(a.probes)
+	if a.certChecker != nil {
+		go a.certChecker.start()
+	}
+	if a.updates != nil {
+		go a.updates.start()
+	}
+	if a.smart != nil {
+		go a.smart.start()
+	}
+}
+
 // GetEnv retrieves an environment variable with a "BESZEL_AGENT_" prefix, or falls back to the unprefixed key.
 func GetEnv(key string) (value string, exists bool) {
 	if value, exists = os.LookupEnv("BESZEL_AGENT_" + key); exists {
@@ -79,39 +162,33 @@ func GetEnv(key string) (value string, exists bool) {
 	return os.LookupEnv(key)
 }
 
-func (a *Agent) gatherStats(sessionID string) *system.CombinedData {
-	a.Lock()
-	defer a.Unlock()
+// gatherStats runs all collectors, calculating rates against the previous
+// request from the same hub.
+func (a *Agent) gatherStats(hubID string) *system.CombinedData {
+	start := time.Now()
+	data := &system.CombinedData{Timestamp: start.UnixMilli(), Info: a.systemInfo}
 
-	cachedData, ok := a.cache.Get(sessionID)
-	if ok {
-		slog.Debug("Cached stats", "session", sessionID)
-		return cachedData
+	a.Lock()
+	state, isNew := a.cache.Get(hubID)
+	if isNew {
+		slog.Debug("New hub", "id", hubID, "hubs", a.cache.Len())
+		data.Baseline = true
 	}
+	since := state.lastRequest
+	state.lastRequest = start
+	a.Unlock()
 
-	*cachedData = system.CombinedData{
-		Stats: a.getSystemStats(),
-		Info:  a.systemInfo,
-	}
-	slog.Debug("System stats", "data", cachedData)
-
-	if a.dockerManager != nil {
-		if containerStats, err := a.dockerManager.getDockerStats(); err == nil {
-			cachedData.Containers = containerStats
-			slog.Debug("Docker stats", "data", cachedData.Containers)
-		} else {
-			slog.Debug("Docker stats", "err", err)
-		}
+	// apply data in registry order once all collectors have finished or timed out
+	results := a.runCollectors(state)
+	applyResults(data, results)
+	data.Agent = a.newAgentStats(results, time.Since(start))
+	// add max / p95 values from samples taken since the hub's last request
+	if !isNew {
+		a.sampler.applySummary(&data.Stats, since)
 	}
+	updateSystemInfo(data)
 
-	cachedData.Stats.ExtraFs = make(map[string]*system.FsStats)
-	for name, stats := range a.fsStats {
-		if !stats.Root && stats.DiskTotal > 0 {
-			cachedData.Stats.ExtraFs[name] = stats
-		}
-	}
-	slog.Debug("Extra filesystems", "data", cachedData.Stats.ExtraFs)
+	slog.Debug("Stats", "data", data)
 
-	a.cache.Set(sessionID, cachedData)
-	return cachedData
+	return data
 }
@@ -1,36 +1,130 @@
 package agent
 
 import (
+	"beszel/internal/entities/container"
 	"beszel/internal/entities/system"
+	"math"
 	"time"
 )
 
+// hubState holds the counters used to calculate rates between requests from a single hub.
+// Keeping these per hub lets multiple hubs poll the same agent at their own interval.
+type hubState struct {
+	lastRequest   time.Time                   // Time of previous request, start of sampler window
+	cpuBusy       float64                     // Busy cpu time at previous request
+	cpuTotal      float64                     // Total cpu time at previous request
+	netIoStats    system.NetIoStats           // Network counters at previous request
+	diskIo        map[string]diskIoState      // Disk I/O counters at previous request by device
+	containers    map[string]*container.Stats // Container stats at previous request by short id
+	throttleRates counterRates                // Cpu thermal throttle counter
+	raplRates     counterRates                // RAPL energy counters by domain
+	arcRates      counterRates                // ZFS ARC hit and miss counters
+	socketRates   counterRates                // TCP retransmit counter
+	textfileRates counterRates                // Textfile counters by series
+	scrapeRates   counterRates                // Scraped counters by series
+	linkRates     counterRates                // Network interface byte counters
+	inventorySent time.Time                   // Time the inventory was last sent
+}
+
+// diskIoState holds disk I/O counters for a device at a point in time
+type diskIoState struct {
+	time  time.Time
+	read  uint64
+	write uint64
+}
+
+// counterRates converts counter values into per second rates
+type counterRates struct {
+	prev map[string]counterValue
+}
+
+// counterValue is the last value of a counter and its rate
+type counterValue struct {
+	value float64
+	time  time.Time
+	rate  float64
+	seen  bool // true if seen in the latest update, used to prune removed series
+}
+
+// rate returns the per second rate of a counter since its last different
+// timestamp. ok is false until the counter has two values.
+func (cr *counterRates) rate(series string, value float64, t time.Time) (rate float64, ok bool) {
+	if cr.prev == nil {
+		cr.prev = make(map[string]counterValue)
+	}
+	prev, exists := cr.prev[series]
+	switch {
+	case !exists:
+		cr.prev[series] = counterValue{value: value, time: t, rate: math.NaN(), seen: true}
+		return 0, false
+	case !t.After(prev.time):
+		// same source data as the previous update, keep the previous rate
+		prev.seen = true
+		cr.prev[series] = prev
+		return prev.rate, !math.IsNaN(prev.rate)
+	}
+	rate = 0
+	// counter reset produces a zero rate
+	if value >= prev.value {
+		rate = (value - prev.value) / t.Sub(prev.time).Seconds()
+	}
+	cr.prev[series] = counterValue{value: value, time: t, rate: rate, seen: true}
+	return rate, true
+}
+
+// prune removes counters not seen since the last prune
+func (cr *counterRates) prune() {
+	for series, prev := range cr.prev {
+		if !prev.seen {
+			delete(cr.prev, series)
+			continue
+		}
+		prev.seen = false
+		cr.prev[series] = prev
+	}
+}
+
+// SessionCache keeps a hubState for each hub, keyed by the fingerprint of its authenticated key.
+// States which have not been used for longer than leaseTime are removed.
+//
 // Not thread safe since we only access from gatherStats which is already locked
 type SessionCache struct {
-	data           *system.CombinedData
-	lastUpdate     time.Time
-	primarySession string
-	leaseTime      time.Duration
+	states    map[string]*hubState
+	leaseTime time.Duration
 }
 
 func NewSessionCache(leaseTime time.Duration) *SessionCache {
 	return &SessionCache{
 		leaseTime: leaseTime,
-		data:      &system.CombinedData{},
+		states:    make(map[string]*hubState),
 	}
 }
 
-func (c *SessionCache) Get(sessionID string) (stats *system.CombinedData, isCached bool) {
-	if sessionID != c.primarySession && time.Since(c.lastUpdate) < c.leaseTime {
-		return c.data, true
+// Get returns the state for a hub, creating it if it doesn't exist.
+// isNew is true if the state was created by this call.
+func (c *SessionCache) Get(hubID string) (state *hubState, isNew bool) {
+	c.prune()
+	if state, ok := c.states[hubID]; ok {
+		return state, false
+	}
+	state = &hubState{
+		diskIo:     make(map[string]diskIoState),
+		containers: make(map[string]*container.Stats),
 	}
-	return c.data, false
+	c.states[hubID] = state
+	return state, true
+}
+
+// Len returns the number of hubs being tracked
+func (c *SessionCache) Len() int {
+	return len(c.states)
 }
 
-func (c *SessionCache) Set(sessionID string, data *system.CombinedData) {
-	if data != nil {
-		*c.data = *data
+// prune removes states of hubs that have not made a request within the lease time
+func (c *SessionCache) prune() {
+	for hubID, state := range c.states {
+		if !state.lastRequest.IsZero() && time.Since(state.lastRequest) > c.leaseTime {
+			delete(c.states, hubID)
+		}
 	}
-	c.primarySession = sessionID
-	c.lastUpdate = time.Now()
 }
@@ -1,85 +1,112 @@
+//go:build testing
+// +build testing
+
 package agent
 
 import (
-	"beszel/internal/entities/system"
 	"testing"
-	"testing/synctest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestSessionCache_GetSet(t *testing.T) {
-	synctest.Run(func() {
-		cache := NewSessionCache(69 * time.Second)
-
-		testData := &system.CombinedData{
-			Info: system.Info{
-				Hostname: "test-host",
-				Cores:    4,
-			},
-			Stats: system.Stats{
-				Cpu:     50.0,
-				MemPct:  30.0,
-				DiskPct: 40.0,
-			},
-		}
-
-		// Test initial state - should not be cached
-		data, isCached := cache.Get("session1")
-		assert.False(t, isCached, "Expected no cached data initially")
-		assert.NotNil(t, data, "Expected data to be initialized")
-		// Set data for session1
-		cache.Set("session1", testData)
-
-		time.Sleep(15 * time.Second)
-
-		// Get data for a different session - should be cached
-		data, isCached = cache.Get("session2")
-		assert.True(t, isCached, "Expected data to be cached for non-primary session")
-		require.NotNil(t, data, "Expected cached data to be returned")
-		assert.Equal(t, "test-host", data.Info.Hostname, "Hostname should match test data")
-		assert.Equal(t, 4, data.Info.Cores, "Cores should match test data")
-		assert.Equal(t, 50.0, data.Stats.Cpu, "CPU should match test data")
-		assert.Equal(t, 30.0, data.Stats.MemPct, "Memory percentage should match test data")
-		assert.Equal(t, 40.0, data.Stats.DiskPct, "Disk percentage should match test data")
-
-		time.Sleep(10 * time.Second)
-
-		// Get data for the primary session - should not be cached
-		data, isCached = cache.Get("session1")
-		assert.False(t, isCached, "Expected data not to be cached for primary session")
-		require.NotNil(t, data, "Expected data to be returned even if not cached")
-		assert.Equal(t, "test-host", data.Info.Hostname, "Hostname should match test data")
-		// if not cached, agent will update the data
-		cache.Set("session1", testData)
-
-		time.Sleep(45 * time.Second)
-
-		// Get data for a different session - should still be cached
-		_, isCached = cache.Get("session2")
-		assert.True(t, isCached, "Expected data to be cached for non-primary session")
-
-		// Wait for the lease to expire
-		time.Sleep(30 * time.Second)
-
-		// Get data for session2 - should not be cached
-		_, isCached = cache.Get("session2")
-		assert.False(t, isCached, "Expected data not to be cached after lease expiration")
-	})
+func TestSessionCache_Get(t *testing.T) {
+	cache := NewSessionCache(10 * time.Minute)
+
+	// Test initial state - should create new state
+	state, isNew := cache.Get("hub1")
+	assert.True(t, isNew, "Expected new state for unknown hub")
+	require.NotNil(t, state)
+	assert.NotNil(t, state.diskIo, "Expected disk I/O map to be initialized")
+	assert.NotNil(t, state.containers, "Expected containers map to be initialized")
+
+	// Same hub should return the same state
+	state.cpuTotal = 100
+	sameState, isNew := cache.Get("hub1")
+	assert.False(t, isNew, "Expected existing state for known hub")
+	assert.Same(t, state, sameState)
+
+	// Different hub should get its own state
+	otherState, isNew := cache.Get("hub2")
+	assert.True(t, isNew, "Expected new state for second hub")
+	assert.NotSame(t, state, otherState)
+	assert.Zero(t, otherState.cpuTotal, "Second hub should not share counters with first hub")
+	assert.Equal(t, 2, cache.Len())
 }
 
-func TestSessionCache_NilData(t *testing.T) {
-	// Create a new SessionCache
-	cache := NewSessionCache(30 * time.Second)
+func TestSessionCache_Prune(t *testing.T) {
+	cache := NewSessionCache(time.Minute)
+
+	stale, _ := cache.Get("stale")
+	stale.lastRequest = time.Now().Add(-2 * time.Minute)
+	active, _ := cache.Get("active")
+	active.lastRequest = time.Now().Add(-30 * time.Second)
+	// state without a completed request is never pruned
+	cache.Get("pending")
+
+	_, isNew := cache.Get("active")
+	assert.False(t, isNew)
+	assert.Equal(t, 2, cache.Len(), "Expected stale hub state to be removed")
 
-	// Test setting nil data (should not panic)
-	assert.NotPanics(t, func() {
-		cache.Set("session1", nil)
-	}, "Setting nil data should not panic")
+	_, isNew = cache.Get("stale")
+	assert.True(t, isNew, "Expected stale hub to get a new state")
+}
+
+func TestGatherStatsPerHub(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("SAMPLE_INTERVAL", "0")
+	agent := NewAgent()
+
+	data := agent.gatherStats("hub1")
+	require.NotNil(t, data.Agent, "Expected agent self-metrics in payload")
+	assert.True(t, data.Baseline, "Expected first request from a hub to only set baselines")
+	assert.Zero(t, data.Stats.Cpu, "Expected no cpu usage without a baseline")
+	assert.Len(t, data.Agent.Collectors, len(agent.collectors))
+	assert.Positive(t, data.Agent.Rss)
+	state1, _ := agent.cache.Get("hub1")
+	firstRequest := state1.lastRequest
+	assert.False(t, firstRequest.IsZero())
+	assert.NotZero(t, state1.cpuTotal)
+
+	// request from another hub does not change state of first hub
+	agent.gatherStats("hub2")
+	state1, _ = agent.cache.Get("hub1")
+	assert.Equal(t, firstRequest, state1.lastRequest)
+	state2, isNew := agent.cache.Get("hub2")
+	assert.False(t, isNew)
+	assert.True(t, state2.lastRequest.After(firstRequest))
+
+	// rates are reported from the second request
+	data = agent.gatherStats("hub1")
+	assert.False(t, data.Baseline)
+}
 
-	// Get data - should not be nil even though we set nil
-	data, _ := cache.Get("session2")
-	assert.NotNil(t, data, "Expected data to not be nil after setting nil data")
+func TestCounterRates(t *testing.T) {
+	var rates counterRates
+	start := time.Now()
+
+	_, ok := rates.rate("jobs", 100, start)
+	assert.False(t, ok, "First value has no rate")
+
+	rate, ok := rates.rate("jobs", 160, start.Add(time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, rate)
+
+	// same timestamp keeps the previous rate
+	rate, ok = rates.rate("jobs", 160, start.Add(time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, rate)
+
+	// counter reset
+	rate, ok = rates.rate("jobs", 10, start.Add(2*time.Minute))
+	assert.True(t, ok)
+	assert.Zero(t, rate)
+
+	// series not seen since the previous prune are removed
+	rates.prune()
+	rates.rate("other", 1, start)
+	rates.prune()
+	assert.NotContains(t, rates.prev, "jobs")
+	assert.Contains(t, rates.prev, "other")
 }
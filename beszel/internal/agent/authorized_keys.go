@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Payload sections which can be allowed per key with the sections="..." option.
+// Each entry clears its section from the payload.
+var payloadSections = map[string]func(*system.CombinedData){
+	"stats":        func(d *system.CombinedData) { d.Stats = system.Stats{} },
+	"info":         func(d *system.CombinedData) { d.Info = system.Info{} },
+	"containers":   func(d *system.CombinedData) { d.Containers = nil },
+	"agent":        func(d *system.CombinedData) { d.Agent = nil },
+	"certificates": func(d *system.CombinedData) { d.Certificates = nil },
+	"inventory":    func(d *system.CombinedData) { d.Inventory = nil },
+	"updates":      func(d *system.CombinedData) { d.Updates = nil },
+	"listeners":    func(d *system.CombinedData) { d.Listeners = nil },
+	"sessions":     func(d *system.CombinedData) { d.Sessions, d.Logins = nil, nil },
+	"power":        func(d *system.CombinedData) { d.Power = nil },
+	"zfs":          func(d *system.CombinedData) { d.ZfsPools = nil },
+	"storage":      func(d *system.CombinedData) { d.Raid, d.Smart = nil, nil },
+	"links":        func(d *system.CombinedData) { d.Links, d.Stats.NetUtil = nil, nil },
+}
+
+// AuthorizedKey is a public key along with the options from its authorized_keys line.
+type AuthorizedKey struct {
+	gossh.PublicKey
+	Comment  string
+	from     []string            // Source address patterns from the from="..." option
+	sections map[string]struct{} // Sections the key may request, nil allows all
+}
+
+// parseAuthorizedKey parses a single line in authorized_keys format, including
+// the from="pattern-list" and sections="section-list" options.
+func parseAuthorizedKey(line string) (AuthorizedKey, error) {
+	pubKey, comment, options, _, err := gossh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return AuthorizedKey{}, err
+	}
+	key := AuthorizedKey{PublicKey: pubKey, Comment: comment}
+	for _, option := range options {
+		name, value, _ := strings.Cut(option, "=")
+		value = strings.Trim(value, `"`)
+		switch strings.ToLower(name) {
+		case "from":
+			for pattern := range strings.SplitSeq(value, ",") {
+				if pattern = strings.TrimSpace(pattern); pattern != "" {
+					key.from = append(key.from, pattern)
+				}
+			}
+		case "sections":
+			key.sections = make(map[string]struct{})
+			for section := range strings.SplitSeq(value, ",") {
+				section = strings.TrimSpace(section)
+				if section == "" {
+					continue
+				}
+				if _, ok := payloadSections[section]; !ok {
+					return AuthorizedKey{}, fmt.Errorf("unknown section %q", section)
+				}
+				key.sections[section] = struct{}{}
+			}
+		default:
+			return AuthorizedKey{}, fmt.Errorf("unsupported option %q", name)
+		}
+	}
+	return key, nil
+}
+
+// Fingerprint returns the SHA256 fingerprint of the key
+func (k *AuthorizedKey) Fingerprint() string {
+	return gossh.FingerprintSHA256(k.PublicKey)
+}
+
+// allowsAddr reports whether a client at addr may authenticate with the key.
+// Uses OpenSSH from="..." semantics: a matching negated pattern denies access,
+// otherwise any matching pattern allows it. Non IP addresses (unix sockets)
+// are denied if the key has a source restriction.
+func (k *AuthorizedKey) allowsAddr(addr net.Addr) bool {
+	if len(k.from) == 0 {
+		return true
+	}
+	host := addr.String()
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	allowed := false
+	for _, pattern := range k.from {
+		negated := strings.HasPrefix(pattern, "!")
+		if !matchAddrPattern(strings.TrimPrefix(pattern, "!"), ip) {
+			continue
+		}
+		if negated {
+			return false
+		}
+		allowed = true
+	}
+	return allowed
+}
+
+// matchAddrPattern matches an ip against a CIDR or a wildcard pattern
+func matchAddrPattern(pattern string, ip net.IP) bool {
+	if strings.Contains(pattern, "/") {
+		_, ipNet, err := net.ParseCIDR(pattern)
+		return err == nil && ipNet.Contains(ip)
+	}
+	match, _ := path.Match(pattern, ip.String())
+	return match
+}
+
+// filterSections removes sections from data which the key is not allowed to request
+func (k *AuthorizedKey) filterSections(data *system.CombinedData) {
+	if k.sections == nil {
+		return
+	}
+	for section, clearSection := range payloadSections {
+		if _, allowed := k.sections[section]; !allowed {
+			clearSection(data)
+		}
+	}
+}
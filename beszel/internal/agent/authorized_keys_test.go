@@ -0,0 +1,135 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/container"
+	"beszel/internal/entities/system"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPubKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKCBM91kukN7hbvFKtbpEeo2JXjCcNxXcdBH7V7ADMBo"
+
+func TestParseAuthorizedKeyOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		line         string
+		wantErr      string
+		wantFrom     []string
+		wantSections []string
+		wantComment  string
+	}{
+		{
+			name: "no options",
+			line: testPubKey,
+		},
+		{
+			name:        "comment",
+			line:        testPubKey + " hub@staging",
+			wantComment: "hub@staging",
+		},
+		{
+			name:     "from option",
+			line:     `from="10.0.0.0/8,!10.0.0.1,192.168.1.*" ` + testPubKey,
+			wantFrom: []string{"10.0.0.0/8", "!10.0.0.1", "192.168.1.*"},
+		},
+		{
+			name:         "sections option",
+			line:         `sections="stats,info" ` + testPubKey,
+			wantSections: []string{"stats", "info"},
+		},
+		{
+			name:         "both options",
+			line:         `from="127.0.0.1",sections="stats" ` + testPubKey + " third-party",
+			wantFrom:     []string{"127.0.0.1"},
+			wantSections: []string{"stats"},
+			wantComment:  "third-party",
+		},
+		{
+			name:    "unknown section",
+			line:    `sections="stats,secrets" ` + testPubKey,
+			wantErr: "unknown section",
+		},
+		{
+			name:    "unsupported option",
+			line:    `command="ls" ` + testPubKey,
+			wantErr: "unsupported option",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := parseAuthorizedKey(tt.line)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "ssh-ed25519", key.Type())
+			assert.Equal(t, tt.wantFrom, key.from)
+			assert.Equal(t, tt.wantComment, key.Comment)
+			if tt.wantSections == nil {
+				assert.Nil(t, key.sections)
+			} else {
+				assert.Len(t, key.sections, len(tt.wantSections))
+				for _, section := range tt.wantSections {
+					assert.Contains(t, key.sections, section)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthorizedKeyAllowsAddr(t *testing.T) {
+	key := AuthorizedKey{from: []string{"10.0.0.0/8", "!10.0.0.1", "192.168.1.*", "::1"}}
+
+	tests := []struct {
+		addr    net.Addr
+		allowed bool
+	}{
+		{&net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 5000}, true},
+		{&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}, false},
+		{&net.TCPAddr{IP: net.ParseIP("192.168.1.20"), Port: 5000}, true},
+		{&net.TCPAddr{IP: net.ParseIP("192.168.2.20"), Port: 5000}, false},
+		{&net.TCPAddr{IP: net.ParseIP("::1"), Port: 5000}, true},
+		{&net.UnixAddr{Name: "@", Net: "unix"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr.String(), func(t *testing.T) {
+			assert.Equal(t, tt.allowed, key.allowsAddr(tt.addr))
+		})
+	}
+
+	// keys without restrictions allow everything
+	unrestricted := AuthorizedKey{}
+	assert.True(t, unrestricted.allowsAddr(&net.UnixAddr{Name: "@", Net: "unix"}))
+}
+
+func TestAuthorizedKeyFilterSections(t *testing.T) {
+	newData := func() *system.CombinedData {
+		return &system.CombinedData{
+			Stats:      system.Stats{Cpu: 10},
+			Info:       system.Info{Hostname: "host"},
+			Containers: []*container.Stats{{Name: "secret-app"}},
+		}
+	}
+
+	// no sections option allows everything
+	data := newData()
+	(&AuthorizedKey{}).filterSections(data)
+	assert.Equal(t, newData(), data)
+
+	key, err := parseAuthorizedKey(`sections="stats,info" ` + testPubKey)
+	require.NoError(t, err)
+	data = newData()
+	key.filterSections(data)
+	assert.Equal(t, 10.0, data.Stats.Cpu)
+	assert.Equal(t, "host", data.Info.Hostname)
+	assert.Nil(t, data.Containers)
+}
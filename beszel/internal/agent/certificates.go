@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Default time between certificate checks
+	defaultCertInterval = time.Hour
+	// Time to connect and complete the TLS handshake
+	certDialTimeout = 10 * time.Second
+)
+
+// certChecker checks the certificates in the CERTIFICATES env var on an interval.
+//
+// CERTIFICATES is a comma separated list of host:port addresses (port defaults
+// to 443) or paths to PEM encoded certificate files.
+type certChecker struct {
+	sync.Mutex
+	interval time.Duration
+	targets  []string
+	results  []system.CertStatus // Results of the last check, in target order
+}
+
+// newCertChecker creates a certChecker from the CERTIFICATES env var.
+// Returns nil if no targets are configured.
+func newCertChecker() *certChecker {
+	value, _ := GetEnv("CERTIFICATES")
+	cc := &certChecker{interval: getEnvDuration("CERT_INTERVAL", defaultCertInterval)}
+	for target := range strings.SplitSeq(value, ",") {
+		if target = strings.TrimSpace(target); target != "" {
+			cc.targets = append(cc.targets, target)
+		}
+	}
+	if len(cc.targets) == 0 {
+		return nil
+	}
+	if cc.interval <= 0 {
+		cc.interval = defaultCertInterval
+	}
+	slog.Info("CERTIFICATES", "targets", cc.targets, "interval", cc.interval)
+	return cc
+}
+
+// start checks certificates on the interval. Blocks forever.
+func (cc *certChecker) start() {
+	for {
+		cc.checkAll()
+		time.Sleep(cc.interval)
+	}
+}
+
+// checkAll checks all targets and stores the results
+func (cc *certChecker) checkAll() {
+	now := time.Now()
+	results := make([]system.CertStatus, 0, len(cc.targets))
+	for _, target := range cc.targets {
+		status := system.CertStatus{Target: target}
+		cert, hostname, err := loadCertificate(target)
+		if err != nil {
+			slog.Debug("Certificate", "target", target, "err", err)
+			status.Error = err.Error()
+		} else {
+			setCertStatus(&status, cert, hostname, now)
+		}
+		results = append(results, status)
+	}
+
+	cc.Lock()
+	defer cc.Unlock()
+	cc.results = results
+}
+
+// isCertFile reports whether the target is a file path rather than an address.
+// Paths without a directory, e.g. server.pem, are files if they exist.
+func isCertFile(target string) bool {
+	if strings.ContainsAny(target, `/\`) {
+		return true
+	}
+	_, err := os.Stat(target)
+	return err == nil
+}
+
+// loadCertificate returns the leaf certificate of a file or server and the
+// hostname it should be valid for (empty for files)
+func loadCertificate(target string) (cert *x509.Certificate, hostname string, err error) {
+	if isCertFile(target) {
+		cert, err = readCertFile(target)
+		return cert, "", err
+	}
+
+	address := target
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		address = net.JoinHostPort(target, "443")
+	}
+	hostname, _, _ = net.SplitHostPort(address)
+
+	ctx, cancel := context.WithTimeout(context.Background(), certDialTimeout)
+	defer cancel()
+	dialer := &tls.Dialer{Config: &tls.Config{
+		ServerName: hostname,
+		// the certificate is inspected rather than trusted, so expired or
+		// mismatched certificates can still be reported
+		InsecureSkipVerify: true,
+	}}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, "", err
+	}
+	defer conn.Close()
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, "", errors.New("no certificate")
+	}
+	return certs[0], hostname, nil
+}
+
+// readCertFile reads the first certificate in a PEM file
+func readCertFile(path string) (*x509.Certificate, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			return nil, errors.New("no certificate in file")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+// setCertStatus sets the expiry, issuer and hostname mismatch of a certificate
+func setCertStatus(status *system.CertStatus, cert *x509.Certificate, hostname string, now time.Time) {
+	status.Subject = cert.Subject.CommonName
+	status.Issuer = cert.Issuer.CommonName
+	if status.Issuer == "" && len(cert.Issuer.Organization) > 0 {
+		status.Issuer = cert.Issuer.Organization[0]
+	}
+	status.Expires = cert.NotAfter.Unix()
+	status.Days = daysUntil(status.Expires, now)
+	if hostname != "" {
+		if err := cert.VerifyHostname(hostname); err != nil {
+			status.Mismatch = true
+		}
+	}
+}
+
+// daysUntil returns the days from now until a unix time, rounded down to two decimals
+func daysUntil(unix int64, now time.Time) float64 {
+	return math.Floor(time.Unix(unix, 0).Sub(now).Hours()/24*100) / 100
+}
+
+// collectCertificates gets the results of the last certificate check, with
+// days remaining updated to the current time
+func (a *Agent) collectCertificates(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	a.certChecker.Lock()
+	results := slices.Clone(a.certChecker.results)
+	a.certChecker.Unlock()
+	if results == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	var errs []error
+	for i := range results {
+		if results[i].Error != "" {
+			errs = append(errs, fmt.Errorf("%s: %s", results[i].Target, results[i].Error))
+			continue
+		}
+		results[i].Days = daysUntil(results[i].Expires, now)
+	}
+	return func(data *system.CombinedData) {
+		data.Certificates = results
+	}, errors.Join(errs...)
+}
@@ -0,0 +1,121 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCert writes a self-signed certificate expiring at notAfter to a PEM file
+func writeTestCert(t *testing.T, notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "internal.example"},
+		Issuer:       pkix.Name{CommonName: "internal.example"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		DNSNames:     []string{"internal.example"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	keyBlock := &pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("not used")}
+	content := append(pem.EncodeToMemory(keyBlock), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	return path
+}
+
+func TestNewCertChecker(t *testing.T) {
+	t.Setenv("BESZEL_AGENT_CERTIFICATES", "")
+	assert.Nil(t, newCertChecker())
+
+	t.Setenv("BESZEL_AGENT_CERTIFICATES", "example.com, /etc/ssl/site.pem ,db.internal:8443")
+	cc := newCertChecker()
+	require.NotNil(t, cc)
+	assert.Equal(t, []string{"example.com", "/etc/ssl/site.pem", "db.internal:8443"}, cc.targets)
+	assert.Equal(t, defaultCertInterval, cc.interval)
+	assert.True(t, isCertFile("/etc/ssl/site.pem"))
+	assert.False(t, isCertFile("db.internal:8443"))
+
+	// files in the working directory are not dialed
+	path := writeTestCert(t, time.Now().Add(time.Hour))
+	t.Chdir(filepath.Dir(path))
+	assert.True(t, isCertFile(filepath.Base(path)))
+	assert.False(t, isCertFile("example.com"))
+}
+
+func TestCertCheckerFile(t *testing.T) {
+	notAfter := time.Now().Add(10*24*time.Hour + time.Hour)
+	path := writeTestCert(t, notAfter)
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+
+	cc := &certChecker{targets: []string{path, missing}}
+	cc.checkAll()
+	require.Len(t, cc.results, 2)
+
+	status := cc.results[0]
+	assert.Equal(t, path, status.Target)
+	assert.Equal(t, "internal.example", status.Subject)
+	assert.Equal(t, "internal.example", status.Issuer)
+	assert.Equal(t, notAfter.Unix(), status.Expires)
+	assert.InDelta(t, 10.04, status.Days, 0.01)
+	assert.False(t, status.Mismatch, "Files have no hostname to check")
+	assert.Empty(t, status.Error)
+
+	assert.NotEmpty(t, cc.results[1].Error)
+
+	a := &Agent{certChecker: cc}
+	apply, err := a.collectCertificates(context.Background(), &hubState{})
+	assert.ErrorContains(t, err, "missing.pem")
+	require.NotNil(t, apply)
+	data := &system.CombinedData{}
+	apply(data)
+	assert.Len(t, data.Certificates, 2)
+}
+
+func TestCertCheckerServer(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "https://")
+	port := address[strings.LastIndex(address, ":")+1:]
+
+	// test certificate is valid for 127.0.0.1 but not localhost
+	cc := &certChecker{targets: []string{address, "localhost:" + port}}
+	cc.checkAll()
+	require.Len(t, cc.results, 2)
+	assert.Empty(t, cc.results[0].Error)
+	assert.Equal(t, server.Certificate().NotAfter.Unix(), cc.results[0].Expires)
+	assert.Greater(t, cc.results[0].Days, 0.0)
+	assert.False(t, cc.results[0].Mismatch)
+	assert.Empty(t, cc.results[1].Error)
+	assert.True(t, cc.results[1].Mismatch)
+}
+
+func TestCollectCertificatesBeforeCheck(t *testing.T) {
+	a := &Agent{certChecker: &certChecker{targets: []string{"example.com"}}}
+	apply, err := a.collectCertificates(context.Background(), &hubState{})
+	assert.NoError(t, err)
+	assert.Nil(t, apply)
+}
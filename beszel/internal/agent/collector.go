@@ -0,0 +1,289 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+const (
+	// Default time a collector may run before its data is left out of the payload
+	defaultCollectorTimeout = 2 * time.Second
+	// Docker makes several API requests per collection so it gets longer
+	dockerCollectorTimeout = 5 * time.Second
+	// Scraping waits on other processes to respond
+	scrapeCollectorTimeout = 5 * time.Second
+)
+
+var errCollectorBusy = errors.New("previous collection still running")
+
+// collector gathers one part of the payload.
+//
+// collect returns a function which writes the collected data into the payload.
+// The function is only called if collect returns before the deadline, so a
+// collector that hangs can never modify a payload that has already been sent.
+// A collector may return both apply and an error if it collected partial data.
+type collector interface {
+	collect(ctx context.Context, hs *hubState) (apply func(*system.CombinedData), err error)
+}
+
+// collectorFunc adapts a function to the collector interface
+type collectorFunc func(ctx context.Context, hs *hubState) (func(*system.CombinedData), error)
+
+func (f collectorFunc) collect(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	return f(ctx, hs)
+}
+
+// registeredCollector is a collector along with its name and deadline
+type registeredCollector struct {
+	name      string
+	timeout   time.Duration
+	collector collector
+	running   chan struct{} // Held while collecting so a timed out run never overlaps the next
+}
+
+// collectorResult is the outcome of a single collector run
+type collectorResult struct {
+	name     string
+	duration time.Duration
+	err      error
+	timedOut bool // true if the run or a previous run did not finish before the deadline
+	apply    func(*system.CombinedData)
+}
+
+// initializeCollectors builds the collector registry, keeping only collectors
+// enabled by the COLLECTORS env var. Must be called after managers are created.
+//
+// COLLECTORS is a comma separated whitelist, or a blacklist if prefixed with "-".
+// Order matters: data is applied in registry order (sensors before gpu for dashboard temp).
+func (a *Agent) initializeCollectors() {
+	available := []*registeredCollector{
+		{name: "cpu", collector: collectorFunc(a.collectCpu)},
+		{name: "memory", collector: collectorFunc(a.collectMemory)},
+		{name: "disk", collector: collectorFunc(a.collectDisk)},
+		{name: "network", collector: collectorFunc(a.collectNetwork)},
+		{name: "sensors", collector: collectorFunc(a.collectTemperatures)},
+	}
+	if a.hwmon != nil {
+		available = append(available, &registeredCollector{name: "hwmon", collector: collectorFunc(a.collectHwmon)})
+	}
+	if a.cpufreq != nil {
+		available = append(available, &registeredCollector{name: "cpufreq", collector: collectorFunc(a.collectCpufreq)})
+	}
+	if a.rapl != nil {
+		available = append(available, &registeredCollector{name: "rapl", collector: collectorFunc(a.collectRapl)})
+	}
+	if a.links != nil {
+		available = append(available, &registeredCollector{name: "links", collector: collectorFunc(a.collectLinks)})
+	}
+	if a.zfsStats != nil {
+		available = append(available, &registeredCollector{name: "zfs", collector: collectorFunc(a.collectZfs), timeout: scrapeCollectorTimeout})
+	}
+	if a.mdstat != "" {
+		available = append(available, &registeredCollector{name: "raid", collector: collectorFunc(a.collectRaid)})
+	}
+	if a.smart != nil {
+		available = append(available, &registeredCollector{name: "smart", collector: collectorFunc(a.collectSmart)})
+	}
+	if a.gpuManager != nil {
+		available = append(available, &registeredCollector{name: "gpu", collector: collectorFunc(a.collectGpu)})
+	}
+	if a.dockerManager != nil {
+		available = append(available, &registeredCollector{name: "docker", collector: collectorFunc(a.collectContainers), timeout: dockerCollectorTimeout})
+	}
+	if a.execMetrics != nil {
+		available = append(available, &registeredCollector{name: "exec", collector: collectorFunc(a.collectExecMetrics)})
+	}
+	if a.textfiles != nil {
+		available = append(available, &registeredCollector{name: "textfile", collector: collectorFunc(a.collectTextfileMetrics)})
+	}
+	if a.scrapeTargets != nil {
+		available = append(available, &registeredCollector{name: "scrape", collector: collectorFunc(a.collectScrapedMetrics), timeout: scrapeCollectorTimeout})
+	}
+	if len(a.probes) > 0 {
+		available = append(available, &registeredCollector{name: "probes", collector: collectorFunc(a.collectProbes)})
+	}
+	if a.certChecker != nil {
+		available = append(available, &registeredCollector{name: "certificates", collector: collectorFunc(a.collectCertificates)})
+	}
+	if a.inventory != nil {
+		available = append(available, &registeredCollector{name: "inventory", collector: collectorFunc(a.collectInventory)})
+	}
+	if a.updates != nil {
+		available = append(available, &registeredCollector{name: "updates", collector: collectorFunc(a.collectUpdates)})
+	}
+	if a.sockets != nil {
+		available = append(available, &registeredCollector{name: "sockets", collector: collectorFunc(a.collectSockets)})
+	}
+	if a.sessions != nil {
+		available = append(available, &registeredCollector{name: "sessions", collector: collectorFunc(a.collectSessions)})
+	}
+	if a.power != nil {
+		available = append(available, &registeredCollector{name: "power", collector: collectorFunc(a.collectPower), timeout: scrapeCollectorTimeout})
+	}
+
+	timeoutOverride := getEnvDuration("COLLECTOR_TIMEOUT", 0)
+	filter, _ := GetEnv("COLLECTORS")
+	isBlacklist := strings.HasPrefix(filter, "-")
+	names := parseCollectorNames(strings.TrimPrefix(filter, "-"))
+
+	a.collectors = a.collectors[:0]
+	for _, c := range available {
+		if len(names) > 0 && slices.Contains(names, c.name) == isBlacklist {
+			slog.Info("Collector disabled", "name", c.name)
+			continue
+		}
+		if timeoutOverride > 0 {
+			c.timeout = timeoutOverride
+		} else if c.timeout == 0 {
+			c.timeout = defaultCollectorTimeout
+		}
+		c.running = make(chan struct{}, 1)
+		a.collectors = append(a.collectors, c)
+	}
+
+	// used to report the agent's own memory usage
+	if p, err := process.NewProcess(int32(os.Getpid())); err == nil {
+		a.process = p
+	}
+}
+
+// parseCollectorNames splits a comma separated list of collector names
+func parseCollectorNames(value string) []string {
+	var names []string
+	for name := range strings.SplitSeq(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// runCollectors runs all collectors concurrently for the hub and returns their
+// results in registry order.
+func (a *Agent) runCollectors(hs *hubState) []collectorResult {
+	results := make([]collectorResult, len(a.collectors))
+	var wg sync.WaitGroup
+	for i, c := range a.collectors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(hs)
+			if err := results[i].err; err != nil {
+				slog.Debug("Collector failed", "name", c.name, "err", err, "duration", results[i].duration)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// applyResults writes the data of each result into the payload in registry order.
+// A custom metric set by more than one collector keeps the later value, and the
+// collision is added to the later collector's error so it shows in its status.
+func applyResults(data *system.CombinedData, results []collectorResult) {
+	sources := make(map[string]string) // collector that set each custom metric
+	for i := range results {
+		result := &results[i]
+		if result.apply == nil {
+			continue
+		}
+		customMetrics := data.Stats.CustomMetrics
+		data.Stats.CustomMetrics = nil
+		result.apply(data)
+		added := data.Stats.CustomMetrics
+		data.Stats.CustomMetrics = customMetrics
+		if len(added) == 0 {
+			continue
+		}
+		if data.Stats.CustomMetrics == nil {
+			data.Stats.CustomMetrics = make(map[string]float64, len(added))
+		}
+		var collisions []string
+		for key, value := range added {
+			if source, exists := sources[key]; exists {
+				collisions = append(collisions, fmt.Sprintf("%s (%s)", key, source))
+			}
+			sources[key] = result.name
+			data.Stats.CustomMetrics[key] = value
+		}
+		if len(collisions) > 0 {
+			slices.Sort(collisions)
+			slog.Warn("Custom metrics overwritten", "name", result.name, "metrics", collisions)
+			result.err = errors.Join(result.err, fmt.Errorf("custom metrics already set by another collector: %s", strings.Join(collisions, ", ")))
+		}
+	}
+}
+
+// run collects data within the collector's deadline. If the deadline passes, the
+// collector is left running in the background and its data is discarded.
+func (c *registeredCollector) run(hs *hubState) collectorResult {
+	result := collectorResult{name: c.name}
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	// wait for a previous run (from this or another hub) to finish
+	select {
+	case c.running <- struct{}{}:
+	case <-ctx.Done():
+		result.err = errCollectorBusy
+		result.timedOut = true
+		result.duration = time.Since(start)
+		return result
+	}
+
+	done := make(chan collectorResult, 1)
+	go func() {
+		defer func() { <-c.running }()
+		apply, err := c.collector.collect(ctx, hs)
+		done <- collectorResult{apply: apply, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		result.apply, result.err = r.apply, r.err
+	case <-ctx.Done():
+		slog.Warn("Collector timed out", "name", c.name, "timeout", c.timeout)
+		result.err = fmt.Errorf("timed out after %s", c.timeout)
+		result.timedOut = true
+	}
+	result.duration = time.Since(start)
+	return result
+}
+
+// newAgentStats returns the agent's self-metrics and the status of each collector
+func (a *Agent) newAgentStats(results []collectorResult, collectionTime time.Duration) *system.AgentStats {
+	agentStats := &system.AgentStats{
+		Goroutines:     runtime.NumGoroutine(),
+		CollectionTime: twoDecimals(float64(collectionTime.Microseconds()) / 1000),
+		Collectors:     make([]system.CollectorStatus, 0, len(results)),
+	}
+	if a.process != nil {
+		if memInfo, err := a.process.MemoryInfo(); err == nil {
+			agentStats.Rss = bytesToMegabytes(float64(memInfo.RSS))
+		}
+	}
+	for _, result := range results {
+		status := system.CollectorStatus{
+			Name:     result.name,
+			Duration: twoDecimals(float64(result.duration.Microseconds()) / 1000),
+			TimedOut: result.timedOut,
+		}
+		if result.err != nil {
+			status.Error = result.err.Error()
+		}
+		agentStats.Collectors = append(agentStats.Collectors, status)
+	}
+	return agentStats
+}
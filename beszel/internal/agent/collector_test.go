@@ -0,0 +1,159 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCollector(name string, timeout time.Duration, fn collectorFunc) *registeredCollector {
+	return &registeredCollector{name: name, timeout: timeout, collector: fn, running: make(chan struct{}, 1)}
+}
+
+func TestCollectorRun(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		c := newTestCollector("test", time.Second, func(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+			return func(data *system.CombinedData) { data.Stats.Cpu = 50 }, nil
+		})
+		result := c.run(&hubState{})
+		require.NoError(t, result.err)
+		require.NotNil(t, result.apply)
+		data := &system.CombinedData{}
+		result.apply(data)
+		assert.Equal(t, 50.0, data.Stats.Cpu)
+	})
+
+	t.Run("error with partial data", func(t *testing.T) {
+		c := newTestCollector("test", time.Second, func(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+			return func(data *system.CombinedData) {}, errors.New("partial")
+		})
+		result := c.run(&hubState{})
+		assert.EqualError(t, result.err, "partial")
+		assert.NotNil(t, result.apply)
+	})
+
+	t.Run("timeout discards data and blocks next run", func(t *testing.T) {
+		release := make(chan struct{})
+		c := newTestCollector("test", 50*time.Millisecond, func(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+			<-release
+			return func(data *system.CombinedData) { data.Stats.Cpu = 50 }, nil
+		})
+		result := c.run(&hubState{})
+		assert.ErrorContains(t, result.err, "timed out")
+		assert.Nil(t, result.apply, "Data from a timed out collector should not be applied")
+		assert.GreaterOrEqual(t, result.duration, 50*time.Millisecond)
+
+		// previous run still holds the collector
+		result = c.run(&hubState{})
+		assert.ErrorIs(t, result.err, errCollectorBusy)
+
+		// collector can run again once the hung run finishes
+		close(release)
+		assert.Eventually(t, func() bool {
+			return c.run(&hubState{}).err == nil
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestRunCollectorsConcurrently(t *testing.T) {
+	slow := func(value float64) collectorFunc {
+		return func(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+			time.Sleep(100 * time.Millisecond)
+			return func(data *system.CombinedData) { data.Stats.Cpu = value }, nil
+		}
+	}
+	a := &Agent{collectors: []*registeredCollector{
+		newTestCollector("first", time.Second, slow(1)),
+		newTestCollector("second", time.Second, slow(2)),
+		newTestCollector("third", time.Second, slow(3)),
+	}}
+
+	start := time.Now()
+	results := a.runCollectors(&hubState{})
+	assert.Less(t, time.Since(start), 250*time.Millisecond, "Collectors should run concurrently")
+
+	// results are returned in registry order so data is applied in a fixed order
+	require.Len(t, results, 3)
+	data := &system.CombinedData{}
+	for i, name := range []string{"first", "second", "third"} {
+		assert.Equal(t, name, results[i].name)
+		results[i].apply(data)
+	}
+	assert.Equal(t, 3.0, data.Stats.Cpu)
+}
+
+func TestInitializeCollectors(t *testing.T) {
+	names := func(a *Agent) []string {
+		var names []string
+		for _, c := range a.collectors {
+			names = append(names, c.name)
+		}
+		return names
+	}
+	a := &Agent{}
+
+	t.Setenv("COLLECTORS", "")
+	a.initializeCollectors()
+	assert.Equal(t, []string{"cpu", "memory", "disk", "network", "sensors"}, names(a))
+	assert.Equal(t, defaultCollectorTimeout, a.collectors[0].timeout)
+
+	t.Setenv("COLLECTORS", "cpu, memory")
+	a.initializeCollectors()
+	assert.Equal(t, []string{"cpu", "memory"}, names(a))
+
+	t.Setenv("COLLECTORS", "-sensors,disk")
+	a.initializeCollectors()
+	assert.Equal(t, []string{"cpu", "memory", "network"}, names(a))
+
+	t.Setenv("COLLECTORS", "")
+	t.Setenv("COLLECTOR_TIMEOUT", "750ms")
+	a.dockerManager = &dockerManager{}
+	a.initializeCollectors()
+	assert.Contains(t, names(a), "docker")
+	for _, c := range a.collectors {
+		assert.Equal(t, 750*time.Millisecond, c.timeout)
+	}
+}
+
+func TestNewAgentStats(t *testing.T) {
+	a := &Agent{}
+	results := []collectorResult{
+		{name: "cpu", duration: 1500 * time.Microsecond},
+		{name: "docker", duration: 5 * time.Second, err: errors.New("timed out after 5s"), timedOut: true},
+	}
+	agentStats := a.newAgentStats(results, 5*time.Second)
+
+	assert.Equal(t, 5000.0, agentStats.CollectionTime)
+	assert.Positive(t, agentStats.Goroutines)
+	require.Len(t, agentStats.Collectors, 2)
+	assert.Equal(t, system.CollectorStatus{Name: "cpu", Duration: 1.5}, agentStats.Collectors[0])
+	assert.Equal(t, system.CollectorStatus{Name: "docker", Duration: 5000, Error: "timed out after 5s", TimedOut: true}, agentStats.Collectors[1])
+}
+
+func TestApplyResultsCustomMetricCollisions(t *testing.T) {
+	setMetrics := func(values map[string]float64) func(*system.CombinedData) {
+		return func(data *system.CombinedData) { addCustomMetrics(data, values) }
+	}
+	results := []collectorResult{
+		{name: "cpu", apply: func(data *system.CombinedData) { data.Stats.Cpu = 5 }},
+		{name: "exec", apply: setMetrics(map[string]float64{"queue": 1, "jobs": 2})},
+		{name: "textfile", apply: setMetrics(map[string]float64{"queue": 3, "temp": 4})},
+		{name: "scrape", err: errors.New("connection refused")},
+	}
+	data := &system.CombinedData{}
+	applyResults(data, results)
+
+	assert.Equal(t, 5.0, data.Stats.Cpu)
+	assert.Equal(t, map[string]float64{"queue": 3, "jobs": 2, "temp": 4}, data.Stats.CustomMetrics)
+	assert.NoError(t, results[1].err)
+	assert.EqualError(t, results[2].err, "custom metrics already set by another collector: queue (exec)")
+	assert.EqualError(t, results[3].err, "connection refused")
+}
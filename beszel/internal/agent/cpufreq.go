@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cpufreqReader reads cpu frequencies from cpufreq and thermal throttle
+// counters from sysfs. Only available on Linux.
+type cpufreqReader struct {
+	path string // Path of the cpu devices directory
+}
+
+// newCpufreqReader creates a cpufreqReader if cpu0 has cpufreq or thermal throttle counters
+func newCpufreqReader() *cpufreqReader {
+	cr := &cpufreqReader{path: "/sys/devices/system/cpu"}
+	for _, dir := range []string{"cpu0/cpufreq", "cpu0/thermal_throttle"} {
+		if _, err := os.Stat(filepath.Join(cr.path, dir)); err == nil {
+			return cr
+		}
+	}
+	return nil
+}
+
+// collectCpufreq gets the frequency of each cpu and the thermal throttle rate
+func (a *Agent) collectCpufreq(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	cr := a.cpufreq
+	cpus, err := filepath.Glob(filepath.Join(cr.path, "cpu[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+
+	freqs := make(map[string]system.CpuFreq, len(cpus))
+	var throttles float64
+	var hasThrottles bool
+	// package counters are repeated for each cpu in the package so are only counted once
+	packages := make(map[string]struct{})
+	for _, cpu := range cpus {
+		if freq, ok := readCpuFreq(filepath.Join(cpu, "cpufreq")); ok {
+			freqs[filepath.Base(cpu)] = freq
+		}
+		throttleDir := filepath.Join(cpu, "thermal_throttle")
+		if count, ok := readSysfsFloat(filepath.Join(throttleDir, "core_throttle_count")); ok {
+			throttles += count
+			hasThrottles = true
+		}
+		pkg := readSysfsString(cpu, "topology/physical_package_id")
+		if _, seen := packages[pkg]; seen {
+			continue
+		}
+		if count, ok := readSysfsFloat(filepath.Join(throttleDir, "package_throttle_count")); ok {
+			packages[pkg] = struct{}{}
+			throttles += count
+			hasThrottles = true
+		}
+	}
+
+	var throttleRate float64
+	var throttleOk bool
+	if hasThrottles {
+		throttleRate, throttleOk = hs.throttleRates.rate("throttles", throttles, time.Now())
+	}
+
+	return func(data *system.CombinedData) {
+		if len(freqs) > 0 {
+			data.Stats.CpuFreqs = freqs
+		}
+		if throttleOk {
+			// reported per minute since throttling events are infrequent
+			data.Stats.Throttles = twoDecimals(throttleRate * 60)
+		}
+	}, nil
+}
+
+// readCpuFreq reads the current, minimum and maximum frequency of a cpu from its
+// cpufreq directory. The maximum is the lowest of the policy and firmware limits,
+// so it shows when the cpu is held below its hardware maximum.
+func readCpuFreq(dir string) (system.CpuFreq, bool) {
+	cur, ok := readSysfsFloat(filepath.Join(dir, "scaling_cur_freq"))
+	if !ok {
+		return system.CpuFreq{}, false
+	}
+	freq := system.CpuFreq{Cur: cur / 1000}
+	if hwMin, ok := readSysfsFloat(filepath.Join(dir, "cpuinfo_min_freq")); ok {
+		freq.Min = hwMin / 1000
+	}
+	if hwMax, ok := readSysfsFloat(filepath.Join(dir, "cpuinfo_max_freq")); ok {
+		freq.HwMax = hwMax / 1000
+		freq.Max = freq.HwMax
+	}
+	for _, limit := range []string{"scaling_max_freq", "bios_limit"} {
+		if value, ok := readSysfsFloat(filepath.Join(dir, limit)); ok && value > 0 {
+			if freq.Max == 0 || value/1000 < freq.Max {
+				freq.Max = value / 1000
+			}
+		}
+	}
+	return freq, true
+}
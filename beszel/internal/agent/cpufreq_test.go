@@ -0,0 +1,59 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectCpufreq(t *testing.T) {
+	path := t.TempDir()
+	writeSysfs(t, path, map[string]string{
+		"cpu0/cpufreq/scaling_cur_freq":                "1400000",
+		"cpu0/cpufreq/cpuinfo_min_freq":                "800000",
+		"cpu0/cpufreq/cpuinfo_max_freq":                "3600000",
+		"cpu0/cpufreq/scaling_max_freq":                "3600000",
+		"cpu0/cpufreq/bios_limit":                      "1800000",
+		"cpu0/thermal_throttle/core_throttle_count":    "5",
+		"cpu0/thermal_throttle/package_throttle_count": "10",
+		"cpu0/topology/physical_package_id":            "0",
+		"cpu1/cpufreq/scaling_cur_freq":                "3500000",
+		"cpu1/cpufreq/cpuinfo_min_freq":                "800000",
+		"cpu1/cpufreq/cpuinfo_max_freq":                "3600000",
+		"cpu1/thermal_throttle/core_throttle_count":    "2",
+		"cpu1/thermal_throttle/package_throttle_count": "10",
+		"cpu1/topology/physical_package_id":            "0",
+	})
+	a := &Agent{cpufreq: &cpufreqReader{path: path}}
+
+	hs := &hubState{}
+	apply, err := a.collectCpufreq(context.Background(), hs)
+	require.NoError(t, err)
+	data := &system.CombinedData{}
+	apply(data)
+	assert.Equal(t, map[string]system.CpuFreq{
+		"cpu0": {Cur: 1400, Min: 800, Max: 1800, HwMax: 3600},
+		"cpu1": {Cur: 3500, Min: 800, Max: 3600, HwMax: 3600},
+	}, data.Stats.CpuFreqs, "Expected the firmware limit to lower the maximum")
+	assert.Zero(t, data.Stats.Throttles, "Expected no rate from the first sample")
+	assert.Equal(t, 17.0, hs.throttleRates.prev["throttles"].value, "Expected package counts once per package")
+
+	writeSysfs(t, path, map[string]string{"cpu1/thermal_throttle/core_throttle_count": "8"})
+	apply, err = a.collectCpufreq(context.Background(), hs)
+	require.NoError(t, err)
+	apply(data)
+	assert.Positive(t, data.Stats.Throttles)
+
+	// another hub takes its own baseline
+	apply, err = a.collectCpufreq(context.Background(), &hubState{})
+	require.NoError(t, err)
+	data = &system.CombinedData{}
+	apply(data)
+	assert.Zero(t, data.Stats.Throttles)
+}
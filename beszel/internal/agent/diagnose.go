@@ -0,0 +1,379 @@
+package agent
+
+import (
+	"beszel"
+	"beszel/internal/entities/system"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	psutilNet "github.com/shirou/gopsutil/v4/net"
+)
+
+// DiagnosticReport describes what the agent detected at startup and the
+// result of one full collection.
+type DiagnosticReport struct {
+	Version    string                 `json:"version"`
+	Info       system.Info            `json:"info"`
+	Disk       DiskDiagnostics        `json:"disk"`
+	Network    []NetworkDiagnostics   `json:"network"`
+	Sensors    SensorDiagnostics      `json:"sensors"`
+	GPU        GPUDiagnostics         `json:"gpu"`
+	Docker     DockerDiagnostics      `json:"docker"`
+	Collectors []CollectorDiagnostics `json:"collectors"`
+}
+
+type DiskDiagnostics struct {
+	RootDevice   string                  `json:"root_device"`    // Detected root device, empty if not detected
+	RootIoDevice string                  `json:"root_io_device"` // Device used for root I/O
+	IoFallback   bool                    `json:"io_fallback"`    // true if root I/O uses a fallback device
+	Filesystems  []FilesystemDiagnostics `json:"filesystems"`
+}
+
+type FilesystemDiagnostics struct {
+	Device     string `json:"device"`
+	Mountpoint string `json:"mountpoint"`
+	Root       bool   `json:"root"`
+	Io         bool   `json:"io"` // true if device was found in diskstats
+}
+
+type NetworkDiagnostics struct {
+	Name      string `json:"name"`
+	BytesSent uint64 `json:"bytes_sent"`
+	BytesRecv uint64 `json:"bytes_recv"`
+	Included  bool   `json:"included"`
+	Reason    string `json:"reason,omitempty"` // Why the interface was excluded
+}
+
+type SensorDiagnostics struct {
+	Skipped bool               `json:"skipped"` // true if SENSORS is set to an empty string
+	Primary string             `json:"primary,omitempty"`
+	Sensors []SensorDiagnostic `json:"sensors"`
+}
+
+type SensorDiagnostic struct {
+	Name        string  `json:"name"`
+	Key         string  `json:"key,omitempty"` // Key matched by SENSORS, if renamed by an alias
+	Group       string  `json:"group,omitempty"`
+	Temperature float64 `json:"temperature"`
+	Included    bool    `json:"included"`
+	Reason      string  `json:"reason,omitempty"` // Why the sensor was excluded
+}
+
+type GPUDiagnostics struct {
+	NvidiaSmi  bool `json:"nvidia_smi"`
+	RocmSmi    bool `json:"rocm_smi"`
+	Tegrastats bool `json:"tegrastats"`
+}
+
+type DockerDiagnostics struct {
+	Enabled bool   `json:"enabled"`
+	Host    string `json:"host,omitempty"`
+	Version string `json:"version,omitempty"`
+	Podman  bool   `json:"podman"`
+}
+
+type CollectorDiagnostics struct {
+	Name     string   `json:"name"`
+	Timeout  float64  `json:"timeout_ms,omitempty"`
+	Duration float64  `json:"duration_ms"`
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty"` // Warning and error logs emitted while collecting
+}
+
+// Diagnose creates an agent, runs each collector once and returns a report
+// of the agent's decisions and the result of each collector. Background checks
+// such as probes, exec metrics and update checks are not started, so their
+// collectors have no data.
+func Diagnose() *DiagnosticReport {
+	report := &DiagnosticReport{Version: beszel.Version}
+
+	// capture warnings logged by each collector
+	capture := &logCapture{}
+	prevLogger := slog.Default()
+	slog.SetDefault(slog.New(capture))
+	defer slog.SetDefault(prevLogger)
+
+	capture.start()
+	start := time.Now()
+	a := newAgent()
+	report.Collectors = append(report.Collectors, CollectorDiagnostics{
+		Name:     "init",
+		Duration: durationMs(time.Since(start)),
+		Warnings: capture.stop(),
+	})
+
+	// run collectors one at a time so logged warnings can be attributed
+	state, _ := a.cache.Get("")
+	for _, c := range a.collectors {
+		capture.start()
+		result := c.run(state)
+		collector := CollectorDiagnostics{
+			Name:     c.name,
+			Timeout:  durationMs(c.timeout),
+			Duration: durationMs(result.duration),
+			Warnings: capture.stop(),
+		}
+		if result.err != nil {
+			collector.Error = result.err.Error()
+		}
+		report.Collectors = append(report.Collectors, collector)
+	}
+
+	report.Info = a.systemInfo
+	report.Disk = a.diskDiagnostics()
+	report.Network = a.networkDiagnostics()
+	report.Sensors = a.sensorDiagnostics()
+	if a.gpuManager != nil {
+		report.GPU = GPUDiagnostics{
+			NvidiaSmi:  a.gpuManager.nvidiaSmi,
+			RocmSmi:    a.gpuManager.rocmSmi,
+			Tegrastats: a.gpuManager.tegrastats,
+		}
+	}
+	if a.dockerManager != nil {
+		report.Docker = DockerDiagnostics{
+			Enabled: true,
+			Host:    a.dockerManager.host,
+			Version: a.dockerManager.version,
+			Podman:  a.systemInfo.Podman,
+		}
+	}
+
+	return report
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// diskDiagnostics returns the root device and monitored filesystems
+func (a *Agent) diskDiagnostics() DiskDiagnostics {
+	diag := DiskDiagnostics{
+		RootDevice: a.rootDevice,
+		IoFallback: a.rootIoFallback,
+	}
+	for device, stats := range a.fsStats {
+		if stats.Root {
+			diag.RootIoDevice = device
+		}
+		diag.Filesystems = append(diag.Filesystems, FilesystemDiagnostics{
+			Device:     device,
+			Mountpoint: stats.Mountpoint,
+			Root:       stats.Root,
+			Io:         slices.Contains(a.fsNames, device),
+		})
+	}
+	slices.SortFunc(diag.Filesystems, func(a, b FilesystemDiagnostics) int {
+		return strings.Compare(a.Mountpoint, b.Mountpoint)
+	})
+	return diag
+}
+
+// networkDiagnostics returns all network interfaces and whether they are monitored
+func (a *Agent) networkDiagnostics() []NetworkDiagnostics {
+	netIO, err := psutilNet.IOCounters(true)
+	if err != nil {
+		return nil
+	}
+	_, nicsEnvExists := GetEnv("NICS")
+	netInterfaces := a.getNetInterfaces()
+	interfaces := make([]NetworkDiagnostics, 0, len(netIO))
+	for _, v := range netIO {
+		nic := NetworkDiagnostics{Name: v.Name, BytesSent: v.BytesSent, BytesRecv: v.BytesRecv}
+		_, nic.Included = netInterfaces[v.Name]
+		switch {
+		case nic.Included:
+		case nicsEnvExists:
+			nic.Reason = "not in NICS"
+		case v.BytesRecv == 0 || v.BytesSent == 0:
+			nic.Reason = "no traffic"
+		default:
+			nic.Reason = "virtual or loopback interface"
+		}
+		interfaces = append(interfaces, nic)
+	}
+	return interfaces
+}
+
+// sensorDiagnostics returns all temperature sensors and the SENSORS filter decision
+// for each, using the same naming and aliases as collectTemperatures.
+func (a *Agent) sensorDiagnostics() SensorDiagnostics {
+	diag := SensorDiagnostics{
+		Skipped: a.sensorConfig.skipCollection,
+		Primary: a.sensorConfig.primarySensor,
+	}
+	if diag.Skipped {
+		return diag
+	}
+	temps, devices, _ := a.readTemperatures()
+	namer := a.sensorConfig.newSensorNamer()
+	for i, sensor := range temps {
+		var device string
+		if devices != nil {
+			device = devices[i]
+		}
+		reading := SensorDiagnostic{Name: sensor.SensorKey, Temperature: twoDecimals(sensor.Temperature)}
+		if sensor.Temperature <= 0 || sensor.Temperature >= 200 {
+			reading.Reason = "temperature out of range"
+			diag.Sensors = append(diag.Sensors, reading)
+			continue
+		}
+		key, name, group, ok := namer.name(sensor.SensorKey, device, i)
+		reading.Name = key
+		if !ok {
+			reading.Reason = "excluded by SENSORS"
+		} else {
+			reading.Included = true
+			reading.Group = group
+			if name != key {
+				reading.Name, reading.Key = name, key
+			}
+		}
+		diag.Sensors = append(diag.Sensors, reading)
+	}
+	return diag
+}
+
+// Print writes the report in human readable form
+func (r *DiagnosticReport) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+
+	fmt.Fprintf(tw, "%s-agent %s\n", beszel.AppName, r.Version)
+	fmt.Fprintf(tw, "Host:\t%s (%s)\n", r.Info.Hostname, r.Info.KernelVersion)
+
+	fmt.Fprintln(tw, "\nDisk")
+	fmt.Fprintf(tw, "  Root device:\t%s\n", r.Disk.RootDevice)
+	fmt.Fprintf(tw, "  Root I/O device:\t%s\n", r.Disk.RootIoDevice)
+	fmt.Fprintf(tw, "  I/O fallback:\t%s\n", yesNo(r.Disk.IoFallback))
+	fmt.Fprintln(tw, "  DEVICE\tMOUNTPOINT\tROOT\tI/O")
+	for _, fs := range r.Disk.Filesystems {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", fs.Device, fs.Mountpoint, yesNo(fs.Root), yesNo(fs.Io))
+	}
+
+	fmt.Fprintln(tw, "\nNetwork interfaces")
+	fmt.Fprintln(tw, "  NAME\tINCLUDED\tREASON")
+	for _, nic := range r.Network {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", nic.Name, yesNo(nic.Included), nic.Reason)
+	}
+
+	fmt.Fprintln(tw, "\nSensors")
+	if r.Sensors.Skipped {
+		fmt.Fprintln(tw, "  Collection disabled (SENSORS is empty)")
+	} else {
+		if r.Sensors.Primary != "" {
+			fmt.Fprintf(tw, "  Primary sensor:\t%s\n", r.Sensors.Primary)
+		}
+		fmt.Fprintln(tw, "  NAME\tTEMP\tINCLUDED\tREASON")
+		for _, sensor := range r.Sensors.Sensors {
+			name := sensor.Name
+			if sensor.Key != "" {
+				name += " (" + sensor.Key + ")"
+			}
+			fmt.Fprintf(tw, "  %s\t%.2f\t%s\t%s\n", name, sensor.Temperature, yesNo(sensor.Included), sensor.Reason)
+		}
+	}
+
+	fmt.Fprintln(tw, "\nGPU tools")
+	fmt.Fprintf(tw, "  nvidia-smi:\t%s\n", yesNo(r.GPU.NvidiaSmi))
+	fmt.Fprintf(tw, "  rocm-smi:\t%s\n", yesNo(r.GPU.RocmSmi))
+	fmt.Fprintf(tw, "  tegrastats:\t%s\n", yesNo(r.GPU.Tegrastats))
+
+	fmt.Fprintln(tw, "\nDocker")
+	if !r.Docker.Enabled {
+		fmt.Fprintln(tw, "  Disabled")
+	} else {
+		fmt.Fprintf(tw, "  Host:\t%s\n", r.Docker.Host)
+		version := r.Docker.Version
+		if r.Docker.Podman {
+			version = "podman"
+		} else if version == "" {
+			version = "unknown"
+		}
+		fmt.Fprintf(tw, "  Version:\t%s\n", version)
+	}
+
+	fmt.Fprintln(tw, "\nCollectors")
+	fmt.Fprintln(tw, "  NAME\tDURATION\tTIMEOUT\tERROR")
+	for _, c := range r.Collectors {
+		timeout := "-"
+		if c.Timeout > 0 {
+			timeout = fmt.Sprintf("%.0fms", c.Timeout)
+		}
+		fmt.Fprintf(tw, "  %s\t%.1fms\t%s\t%s\n", c.Name, c.Duration, timeout, c.Error)
+	}
+	if slices.ContainsFunc(r.Collectors, func(c CollectorDiagnostics) bool { return len(c.Warnings) > 0 }) {
+		fmt.Fprintln(tw, "\nWarnings")
+	}
+	for _, c := range r.Collectors {
+		for _, warning := range c.Warnings {
+			fmt.Fprintf(tw, "  %s: %s\n", c.Name, warning)
+		}
+	}
+}
+
+// logCapture is a slog.Handler which records warnings and errors logged while
+// a collector runs. Other records are discarded to keep the report readable.
+type logCapture struct {
+	sync.Mutex
+	recording bool
+	records   []string
+}
+
+// start begins recording warnings
+func (h *logCapture) start() {
+	h.Lock()
+	defer h.Unlock()
+	h.recording = true
+	h.records = nil
+}
+
+// stop ends recording and returns the recorded warnings
+func (h *logCapture) stop() []string {
+	h.Lock()
+	defer h.Unlock()
+	h.recording = false
+	return h.records
+}
+
+func (h *logCapture) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelWarn
+}
+
+func (h *logCapture) Handle(ctx context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Message)
+	r.Attrs(func(attr slog.Attr) bool {
+		fmt.Fprintf(&b, " %s=%v", attr.Key, attr.Value)
+		return true
+	})
+	h.Lock()
+	defer h.Unlock()
+	if h.recording {
+		h.records = append(h.records, b.String())
+	}
+	return nil
+}
+
+func (h *logCapture) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *logCapture) WithGroup(name string) slog.Handler {
+	return h
+}
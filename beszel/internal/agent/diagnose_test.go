@@ -0,0 +1,77 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogCapture(t *testing.T) {
+	capture := &logCapture{}
+	logger := slog.New(capture)
+
+	logger.Warn("not recording")
+	capture.start()
+	logger.Info("info is ignored")
+	logger.Warn("Device not found", "name", "sda")
+	logger.With("attr", 1).Error("Failed")
+	records := capture.stop()
+	logger.Error("after stop")
+
+	assert.Equal(t, []string{"Device not found name=sda", "Failed"}, records)
+}
+
+func TestDiagnose(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("SAMPLE_INTERVAL", "0")
+	t.Setenv("EXTRA_FILESYSTEMS", "/nonexistent-beszel-fs")
+	prevLogger := slog.Default()
+
+	report := Diagnose()
+	assert.Same(t, prevLogger, slog.Default(), "Expected default logger to be restored")
+
+	require.GreaterOrEqual(t, len(report.Collectors), 2)
+	assert.Equal(t, "init", report.Collectors[0].Name)
+	assert.Equal(t, "cpu", report.Collectors[1].Name)
+	assert.NotEmpty(t, report.Collectors[0].Warnings, "Expected invalid filesystem warning")
+	assert.False(t, report.Docker.Enabled)
+
+	rootCount := 0
+	for _, fs := range report.Disk.Filesystems {
+		if fs.Root {
+			rootCount++
+			assert.Equal(t, report.Disk.RootIoDevice, fs.Device)
+		}
+	}
+	assert.Equal(t, 1, rootCount, "Expected exactly one root filesystem")
+
+	var out bytes.Buffer
+	report.Print(&out)
+	assert.Contains(t, out.String(), "Root I/O device:")
+	assert.Contains(t, out.String(), "Invalid filesystem")
+}
+
+func TestSensorDiagnosticsHwmon(t *testing.T) {
+	a := newTestHwmonAgent(t, "-amdgpu")
+	aliases, err := parseSensorAliases([]byte(`
+- match: nct6798_*
+  device: nct6775.656
+  name: "Board *"
+  group: Board
+`))
+	require.NoError(t, err)
+	a.sensorConfig.aliases = aliases
+
+	diag := a.sensorDiagnostics()
+	assert.Equal(t, []SensorDiagnostic{
+		{Name: "Board systin", Key: "nct6798_systin", Group: "Board", Temperature: 45, Included: true},
+		{Name: "amdgpu", Temperature: 52, Reason: "excluded by SENSORS"},
+		{Name: "nct6798_systin_2", Temperature: 38.5, Included: true},
+	}, diag.Sensors, "Expected the same names as collectTemperatures")
+}
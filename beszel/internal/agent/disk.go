@@ -47,11 +47,13 @@ func (a *Agent) initializeDiskInfo() {
 		if _, exists := a.fsStats[key]; !exists {
 			if root {
 				slog.Info("Detected root device", "name", key)
+				a.rootDevice = key
 				// Check if root device is in /proc/diskstats, use fallback if not
 				if _, ioMatch = diskIoCounters[key]; !ioMatch {
 					key, ioMatch = findIoDevice(filesystem, diskIoCounters, a.fsStats)
 					if !ioMatch {
 						slog.Info("Using I/O fallback", "device", device, "mountpoint", mountpoint, "fallback", key)
+						a.rootIoFallback = true
 					}
 				}
 			} else {
@@ -142,8 +144,9 @@ func (a *Agent) initializeDiskInfo() {
 
 	// If no root filesystem set, use fallback
 	if !hasRoot {
-		rootDevice, _ := findIoDevice(filepath.Base(filesystem), diskIoCounters, a.fsStats)
+		rootDevice, match := findIoDevice(filepath.Base(filesystem), diskIoCounters, a.fsStats)
 		slog.Info("Root disk", "mountpoint", "/", "io", rootDevice)
+		a.rootIoFallback = !match
 		a.fsStats[rootDevice] = &system.FsStats{Root: true, Mountpoint: "/"}
 	}
 
@@ -186,5 +189,8 @@ func (a *Agent) initializeDiskIoStats(diskIoCounters map[string]disk.IOCountersS
 		stats.TotalWrite = d.WriteBytes
 		// add to list of valid io device names
 		a.fsNames = append(a.fsNames, device)
+		if stats.Root {
+			a.sampler.setDiskDevice(device)
+		}
 	}
 }
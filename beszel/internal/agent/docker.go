@@ -2,6 +2,7 @@ package agent
 
 import (
 	"beszel/internal/entities/container"
+	"beszel/internal/entities/system"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -18,15 +19,16 @@ import (
 )
 
 type dockerManager struct {
-	client              *http.Client                // Client to query Docker API
-	wg                  sync.WaitGroup              // WaitGroup to wait for all goroutines to finish
-	sem                 chan struct{}               // Semaphore to limit concurrent container requests
-	containerStatsMutex sync.RWMutex                // Mutex to prevent concurrent access to containerStatsMap
-	apiContainerList    []*container.ApiInfo        // List of containers from Docker API (no pointer)
-	containerStatsMap   map[string]*container.Stats // Keeps track of container stats
-	validIds            map[string]struct{}         // Map of valid container ids, used to prune invalid containers from containerStatsMap
-	goodDockerVersion   bool                        // Whether docker version is at least 25.0.0 (one-shot works correctly)
-	isWindows           bool                        // Whether the Docker Engine API is running on Windows
+	client              *http.Client         // Client to query Docker API
+	wg                  sync.WaitGroup       // WaitGroup to wait for all goroutines to finish
+	sem                 chan struct{}        // Semaphore to limit concurrent container requests
+	containerStatsMutex sync.RWMutex         // Mutex to prevent concurrent access to the hub's container stats map
+	apiContainerList    []*container.ApiInfo // List of containers from Docker API (no pointer)
+	validIds            map[string]struct{}  // Map of valid container ids, used to prune invalid containers from the hub's container stats map
+	goodDockerVersion   bool                 // Whether docker version is at least 25.0.0 (one-shot works correctly)
+	isWindows           bool                 // Whether the Docker Engine API is running on Windows
+	host                string               // Docker host address
+	version             string               // Docker version reported by the API, empty if unknown
 }
 
 // userAgentRoundTripper is a custom http.RoundTripper that adds a User-Agent header to all requests
@@ -57,8 +59,21 @@ func (d *dockerManager) dequeue() {
 	}
 }
 
-// Returns stats for all running containers
-func (dm *dockerManager) getDockerStats() ([]*container.Stats, error) {
+// collectContainers gets stats for all running containers
+func (a *Agent) collectContainers(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	containerStats, err := a.dockerManager.getDockerStats(hs.containers)
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("Docker stats", "data", containerStats)
+	return func(data *system.CombinedData) {
+		data.Containers = containerStats
+	}, nil
+}
+
+// Returns stats for all running containers.
+// containerStatsMap holds the previous stats of the requesting hub, keyed by short container id.
+func (dm *dockerManager) getDockerStats(containerStatsMap map[string]*container.Stats) ([]*container.Stats, error) {
 	resp, err := dm.client.Get("http://localhost/containers/json")
 	if err != nil {
 		return nil, err
@@ -90,16 +105,16 @@ func (dm *dockerManager) getDockerStats() ([]*container.Stats, error) {
 		// note: can't use Created field because it's not updated on restart
 		if strings.Contains(ctr.Status, "second") {
 			// if so, remove old container data
-			dm.deleteContainerStatsSync(ctr.IdShort)
+			dm.deleteContainerStatsSync(containerStatsMap, ctr.IdShort)
 		}
 		dm.queue()
 		go func() {
 			defer dm.dequeue()
-			err := dm.updateContainerStats(ctr)
+			err := dm.updateContainerStats(ctr, containerStatsMap)
 			// if error, delete from map and add to failed list to retry
 			if err != nil {
 				dm.containerStatsMutex.Lock()
-				delete(dm.containerStatsMap, ctr.IdShort)
+				delete(containerStatsMap, ctr.IdShort)
 				failedContainers = append(failedContainers, ctr)
 				dm.containerStatsMutex.Unlock()
 			}
@@ -115,7 +130,7 @@ func (dm *dockerManager) getDockerStats() ([]*container.Stats, error) {
 			dm.queue()
 			go func() {
 				defer dm.dequeue()
-				err = dm.updateContainerStats(ctr)
+				err = dm.updateContainerStats(ctr, containerStatsMap)
 				if err != nil {
 					slog.Error("Error getting container stats", "err", err)
 				}
@@ -125,20 +140,22 @@ func (dm *dockerManager) getDockerStats() ([]*container.Stats, error) {
 	}
 
 	// populate final stats and remove old / invalid container stats
+	// (copied so later requests from this hub can't modify a response being sent)
 	stats := make([]*container.Stats, 0, containersLength)
-	for id, v := range dm.containerStatsMap {
+	for id, v := range containerStatsMap {
 		if _, exists := dm.validIds[id]; !exists {
-			delete(dm.containerStatsMap, id)
+			delete(containerStatsMap, id)
 		} else {
-			stats = append(stats, v)
+			statsCopy := *v
+			stats = append(stats, &statsCopy)
 		}
 	}
 
 	return stats, nil
 }
 
-// Updates stats for individual container
-func (dm *dockerManager) updateContainerStats(ctr *container.ApiInfo) error {
+// Updates stats for individual container in the hub's container stats map
+func (dm *dockerManager) updateContainerStats(ctr *container.ApiInfo, containerStatsMap map[string]*container.Stats) error {
 	name := ctr.Names[0][1:]
 
 	resp, err := dm.client.Get("http://localhost/containers/" + ctr.IdShort + "/stats?stream=0&one-shot=1")
@@ -151,10 +168,10 @@ func (dm *dockerManager) updateContainerStats(ctr *container.ApiInfo) error {
 	defer dm.containerStatsMutex.Unlock()
 
 	// add empty values if they doesn't exist in map
-	stats, initialized := dm.containerStatsMap[ctr.IdShort]
+	stats, initialized := containerStatsMap[ctr.IdShort]
 	if !initialized {
 		stats = &container.Stats{Name: name}
-		dm.containerStatsMap[ctr.IdShort] = stats
+		containerStatsMap[ctr.IdShort] = stats
 	}
 
 	// reset current stats
@@ -221,10 +238,10 @@ func (dm *dockerManager) updateContainerStats(ctr *container.ApiInfo) error {
 }
 
 // Delete container stats from map using mutex
-func (dm *dockerManager) deleteContainerStatsSync(id string) {
+func (dm *dockerManager) deleteContainerStatsSync(containerStatsMap map[string]*container.Stats, id string) {
 	dm.containerStatsMutex.Lock()
 	defer dm.containerStatsMutex.Unlock()
-	delete(dm.containerStatsMap, id)
+	delete(containerStatsMap, id)
 }
 
 // Creates a new http client for Docker or Podman API
@@ -287,9 +304,9 @@ func newDockerManager(a *Agent) *dockerManager {
 			Timeout:   timeout,
 			Transport: userAgentTransport,
 		},
-		containerStatsMap: make(map[string]*container.Stats),
-		sem:               make(chan struct{}, 5),
-		apiContainerList:  []*container.ApiInfo{},
+		sem:              make(chan struct{}, 5),
+		apiContainerList: []*container.ApiInfo{},
+		host:             dockerHost,
 	}
 
 	// If using podman, return client
@@ -313,6 +330,7 @@ func newDockerManager(a *Agent) *dockerManager {
 	if err := json.NewDecoder(resp.Body).Decode(&versionInfo); err != nil {
 		return manager
 	}
+	manager.version = versionInfo.Version
 
 	// if version > 24, one-shot works correctly and we can limit concurrent operations
 	if dockerVersion, err := semver.Parse(versionInfo.Version); err == nil && dockerVersion.Major > 24 {
@@ -0,0 +1,225 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Default time between runs of each exec metrics command
+	defaultExecInterval = 30 * time.Second
+	// Default time a command may run before it is killed
+	defaultExecTimeout = 10 * time.Second
+)
+
+// execMetrics runs the commands in the EXEC_METRICS env var on an interval and
+// keeps the latest values they emitted.
+//
+// Commands print either a JSON object of numbers or lines of `key value`.
+// Lines that are empty or start with "#" are ignored.
+type execMetrics struct {
+	interval time.Duration
+	timeout  time.Duration
+	scripts  []*execScript
+}
+
+// execScript is a single configured command and its latest output
+type execScript struct {
+	sync.Mutex
+	name    string             // Base name of the executable, used in errors
+	args    []string           // Executable followed by its arguments
+	values  map[string]float64 // Values from the last run
+	err     error              // Error from the last run
+	updated time.Time          // Time of the last successful run
+}
+
+// newExecMetrics creates exec metrics from the EXEC_METRICS env var.
+// Returns nil if no commands are configured.
+//
+// EXEC_METRICS is a comma separated list of commands. Arguments are separated by spaces.
+func newExecMetrics() *execMetrics {
+	value, _ := GetEnv("EXEC_METRICS")
+	em := &execMetrics{
+		interval: getEnvDuration("EXEC_INTERVAL", defaultExecInterval),
+		timeout:  getEnvDuration("EXEC_TIMEOUT", defaultExecTimeout),
+	}
+	for command := range strings.SplitSeq(value, ",") {
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			continue
+		}
+		em.scripts = append(em.scripts, &execScript{name: filepath.Base(args[0]), args: args})
+	}
+	if len(em.scripts) == 0 {
+		return nil
+	}
+	if em.interval <= 0 {
+		em.interval = defaultExecInterval
+	}
+	if em.timeout <= 0 || em.timeout > em.interval {
+		em.timeout = min(defaultExecTimeout, em.interval)
+	}
+	slog.Info("EXEC_METRICS", "commands", len(em.scripts), "interval", em.interval)
+	return em
+}
+
+// start runs each command on the interval. Blocks forever.
+func (em *execMetrics) start() {
+	for _, script := range em.scripts[1:] {
+		go em.runLoop(script)
+	}
+	em.runLoop(em.scripts[0])
+}
+
+// runLoop runs a command on the interval. Blocks forever.
+func (em *execMetrics) runLoop(script *execScript) {
+	for {
+		em.run(script)
+		time.Sleep(em.interval)
+	}
+}
+
+// run executes the command once and stores its values
+func (em *execMetrics) run(script *execScript) {
+	ctx, cancel := context.WithTimeout(context.Background(), em.timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, script.args[0], script.args[1:]...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", em.timeout)
+		} else if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+	}
+
+	var values map[string]float64
+	if err == nil {
+		values, err = parseExecOutput(output)
+	}
+	if err != nil {
+		slog.Debug("Exec metrics", "command", script.name, "err", err)
+	}
+
+	script.Lock()
+	defer script.Unlock()
+	script.err = err
+	// keep partial values from output with invalid lines
+	if len(values) > 0 {
+		script.values = values
+		script.updated = time.Now()
+	}
+}
+
+// parseExecOutput parses a JSON object of numbers or lines of `key value`.
+// Returns the values that could be parsed along with an error for any that could not.
+func parseExecOutput(output []byte) (map[string]float64, error) {
+	output = bytes.TrimSpace(output)
+	if len(output) == 0 {
+		return nil, errors.New("no output")
+	}
+	if output[0] == '{' {
+		return parseExecJSON(output)
+	}
+
+	values := make(map[string]float64)
+	var invalid []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			invalid = append(invalid, line)
+			continue
+		}
+		val, err := strconv.ParseFloat(fields[1], 64)
+		// NaN and Inf can't be encoded in the JSON payload
+		if err != nil || math.IsNaN(val) || math.IsInf(val, 0) {
+			invalid = append(invalid, line)
+			continue
+		}
+		values[fields[0]] = val
+	}
+	if len(invalid) > 0 {
+		return values, fmt.Errorf("invalid lines: %q", invalid)
+	}
+	return values, nil
+}
+
+// parseExecJSON parses a JSON object, keeping only number values
+func parseExecJSON(output []byte) (map[string]float64, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, err
+	}
+	values := make(map[string]float64, len(raw))
+	var invalid []string
+	for key, value := range raw {
+		if val, ok := value.(float64); ok {
+			values[key] = val
+		} else {
+			invalid = append(invalid, key)
+		}
+	}
+	if len(invalid) > 0 {
+		slices.Sort(invalid)
+		return values, fmt.Errorf("non-numeric keys: %q", invalid)
+	}
+	return values, nil
+}
+
+// collectExecMetrics gets the latest values from all exec metrics commands.
+// Values not updated within three intervals are left out.
+func (a *Agent) collectExecMetrics(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	staleTime := time.Now().Add(-3 * a.execMetrics.interval)
+	values := make(map[string]float64)
+	var errs []error
+	for _, script := range a.execMetrics.scripts {
+		script.Lock()
+		if script.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", script.name, script.err))
+		}
+		if script.updated.After(staleTime) {
+			for key, val := range script.values {
+				values[key] = val
+			}
+		}
+		script.Unlock()
+	}
+	return func(data *system.CombinedData) {
+		addCustomMetrics(data, values)
+	}, errors.Join(errs...)
+}
+
+// addCustomMetrics adds values to the payload's custom metrics
+func addCustomMetrics(data *system.CombinedData, values map[string]float64) {
+	if len(values) == 0 {
+		return
+	}
+	if data.Stats.CustomMetrics == nil {
+		data.Stats.CustomMetrics = make(map[string]float64, len(values))
+	}
+	for key, val := range values {
+		data.Stats.CustomMetrics[key] = val
+	}
+}
@@ -0,0 +1,116 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExecOutput(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		expected  map[string]float64
+		expectErr bool
+	}{
+		{
+			name:     "key value lines",
+			output:   "# queue stats\nqueue_depth 12\n\nreplication_lag 0.25\n",
+			expected: map[string]float64{"queue_depth": 12, "replication_lag": 0.25},
+		},
+		{
+			name:      "invalid lines keep valid values",
+			output:    "queue_depth 12\nreplication_lag\nworkers many\nerrors NaN\n",
+			expected:  map[string]float64{"queue_depth": 12},
+			expectErr: true,
+		},
+		{
+			name:     "json object",
+			output:   `{"queue_depth": 12, "replication_lag": 0.25}`,
+			expected: map[string]float64{"queue_depth": 12, "replication_lag": 0.25},
+		},
+		{
+			name:      "json non-numeric values",
+			output:    `{"queue_depth": 12, "status": "ok"}`,
+			expected:  map[string]float64{"queue_depth": 12},
+			expectErr: true,
+		},
+		{
+			name:      "invalid json",
+			output:    `{"queue_depth": 12`,
+			expectErr: true,
+		},
+		{
+			name:      "no output",
+			output:    "  \n",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := parseExecOutput([]byte(tt.output))
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.expected != nil {
+				assert.Equal(t, tt.expected, values)
+			}
+		})
+	}
+}
+
+func TestNewExecMetrics(t *testing.T) {
+	t.Setenv("BESZEL_AGENT_EXEC_METRICS", "")
+	assert.Nil(t, newExecMetrics(), "Expected nil when no commands are configured")
+
+	t.Setenv("BESZEL_AGENT_EXEC_METRICS", "/usr/local/bin/queue-stats --json, /opt/lag.sh")
+	t.Setenv("BESZEL_AGENT_EXEC_INTERVAL", "1m")
+	t.Setenv("BESZEL_AGENT_EXEC_TIMEOUT", "2m")
+	em := newExecMetrics()
+	require.NotNil(t, em)
+	require.Len(t, em.scripts, 2)
+	assert.Equal(t, []string{"/usr/local/bin/queue-stats", "--json"}, em.scripts[0].args)
+	assert.Equal(t, "queue-stats", em.scripts[0].name)
+	assert.Equal(t, []string{"/opt/lag.sh"}, em.scripts[1].args)
+	assert.Equal(t, time.Minute, em.interval)
+	assert.Equal(t, defaultExecTimeout, em.timeout, "Timeout longer than the interval should be reset")
+}
+
+func TestExecMetricsRun(t *testing.T) {
+	em := &execMetrics{interval: time.Minute, timeout: time.Second}
+	ok := &execScript{name: "echo", args: []string{"echo", "queue_depth 12"}}
+	failing := &execScript{name: "false", args: []string{"false"}}
+	em.scripts = []*execScript{ok, failing}
+
+	em.run(ok)
+	em.run(failing)
+	require.NoError(t, ok.err)
+	assert.Equal(t, map[string]float64{"queue_depth": 12}, ok.values)
+	assert.Error(t, failing.err)
+	assert.True(t, failing.updated.IsZero())
+
+	a := &Agent{execMetrics: em}
+	apply, err := a.collectExecMetrics(context.Background(), &hubState{})
+	assert.ErrorContains(t, err, "false: ")
+	require.NotNil(t, apply)
+	data := &system.CombinedData{}
+	apply(data)
+	assert.Equal(t, map[string]float64{"queue_depth": 12}, data.Stats.CustomMetrics)
+
+	// stale values are left out
+	ok.updated = time.Now().Add(-4 * em.interval)
+	apply, _ = a.collectExecMetrics(context.Background(), &hubState{})
+	data = &system.CombinedData{}
+	apply(data)
+	assert.Nil(t, data.Stats.CustomMetrics)
+}
@@ -1,10 +1,48 @@
 package agent
 
 import (
+	"beszel/internal/entities/system"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net"
+	"os"
 	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Exit codes for each class of health check failure
+const (
+	HealthConnectFailed  = 1 // Could not connect to the agent
+	HealthAuthFailed     = 2 // SSH handshake or authentication failed
+	HealthTimeout        = 3 // No payload received before the timeout
+	HealthInvalidPayload = 4 // Payload could not be decoded or is missing data
+	HealthStalePayload   = 5 // Payload was collected too long before it was received
+	HealthCollectorHung  = 6 // A collector did not finish before its deadline
 )
 
+const (
+	// Default time allowed for the full deep health check
+	defaultHealthTimeout = 15 * time.Second
+	// Max time between the start of collection and receiving the payload
+	maxPayloadAge = 10 * time.Second
+)
+
+// HealthError is returned by DeepHealth with the exit code for the failure class
+type HealthError struct {
+	Code int
+	Err  error
+}
+
+func (e *HealthError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *HealthError) Unwrap() error {
+	return e.Err
+}
+
 // Health checks if the agent's server is running by attempting to connect to it.
 //
 // If an error occurs when attempting to connect to the server, it returns the error.
@@ -16,3 +54,100 @@ func Health(addr string, network string) error {
 	conn.Close()
 	return nil
 }
+
+// LoadHealthSigner loads the private key used for deep health checks from the
+// HEALTH_KEY_FILE env var. Returns nil if the env var is not set.
+func LoadHealthSigner() (gossh.Signer, error) {
+	keyFile, exists := GetEnv("HEALTH_KEY_FILE")
+	if !exists || keyFile == "" {
+		return nil, nil
+	}
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read health key file: %w", err)
+	}
+	return gossh.ParsePrivateKey(key)
+}
+
+// DeepHealth connects to the agent with signer, requests a payload like the hub does,
+// and validates it. The timeout is read from the HEALTH_TIMEOUT env var.
+//
+// Errors are returned as *HealthError with a distinct code for each failure class.
+func DeepHealth(addr string, network string, signer gossh.Signer) error {
+	timeout := getEnvDuration("HEALTH_TIMEOUT", defaultHealthTimeout)
+	deadline := time.Now().Add(timeout)
+
+	conn, err := net.DialTimeout(network, addr, 4*time.Second)
+	if err != nil {
+		return &HealthError{HealthConnectFailed, err}
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	config := &gossh.ClientConfig{
+		User:            "u",
+		Auth:            []gossh.AuthMethod{gossh.PublicKeys(signer)},
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+	}
+	sshConn, chans, reqs, err := gossh.NewClientConn(conn, addr, config)
+	if err != nil {
+		if isTimeout(err) {
+			return &HealthError{HealthTimeout, err}
+		}
+		return &HealthError{HealthAuthFailed, err}
+	}
+	client := gossh.NewClient(sshConn, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return &HealthError{HealthTimeout, err}
+	}
+	defer session.Close()
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return &HealthError{HealthInvalidPayload, err}
+	}
+	if err := session.Shell(); err != nil {
+		return &HealthError{HealthTimeout, err}
+	}
+
+	var data system.CombinedData
+	if err := json.NewDecoder(stdout).Decode(&data); err != nil {
+		if isTimeout(err) {
+			return &HealthError{HealthTimeout, fmt.Errorf("no payload received within %s: %w", timeout, err)}
+		}
+		return &HealthError{HealthInvalidPayload, err}
+	}
+	return validatePayload(&data, time.Now())
+}
+
+// validatePayload checks that a payload received at receivedAt contains data, is fresh,
+// and that no collector is hung
+func validatePayload(data *system.CombinedData, receivedAt time.Time) error {
+	if data.Timestamp == 0 {
+		return &HealthError{HealthInvalidPayload, errors.New("payload has no timestamp")}
+	}
+	if data.Info.AgentVersion == "" && data.Stats.Mem == 0 {
+		return &HealthError{HealthInvalidPayload, errors.New("payload has no system data")}
+	}
+	if age := receivedAt.Sub(time.UnixMilli(data.Timestamp)); age > maxPayloadAge {
+		return &HealthError{HealthStalePayload, fmt.Errorf("payload collection took %s", age.Round(time.Millisecond))}
+	}
+	// collectors which return errors (e.g. docker not installed) are expected on some
+	// hosts, but one that hangs means the agent is wedged
+	if data.Agent != nil {
+		for _, c := range data.Agent.Collectors {
+			if c.TimedOut {
+				return &HealthError{HealthCollectorHung, fmt.Errorf("collector %s: %s", c.Name, c.Error)}
+			}
+		}
+	}
+	return nil
+}
+
+// isTimeout reports whether err is a network timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -4,12 +4,17 @@
 package agent_test
 
 import (
+	"crypto/ed25519"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
 
 	"beszel/internal/agent"
 )
@@ -116,3 +121,90 @@ func TestHealth(t *testing.T) {
 		require.Error(t, err, "Health check should return an error when socket doesn't exist")
 	})
 }
+
+func TestDeepHealth(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("SAMPLE_INTERVAL", "0")
+	t.Setenv("HEALTH_TIMEOUT", "5s")
+
+	pubKey, privKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	signer, err := gossh.NewSignerFromKey(privKey)
+	require.NoError(t, err)
+	sshPubKey, err := gossh.NewPublicKey(pubKey)
+	require.NoError(t, err)
+	_, badPrivKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	badSigner, err := gossh.NewSignerFromKey(badPrivKey)
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	a := agent.NewAgent()
+	go a.StartServer(agent.ServerOptions{
+		Addr:    addr,
+		Network: "tcp",
+		Keys:    []agent.AuthorizedKey{{PublicKey: sshPubKey}},
+	})
+	require.Eventually(t, func() bool {
+		return agent.Health(addr, "tcp") == nil
+	}, 2*time.Second, 20*time.Millisecond)
+
+	requireHealthCode := func(t *testing.T, err error, code int) {
+		var healthErr *agent.HealthError
+		require.True(t, errors.As(err, &healthErr), "Expected HealthError, got %v", err)
+		require.Equal(t, code, healthErr.Code)
+	}
+
+	t.Run("valid key", func(t *testing.T) {
+		require.NoError(t, agent.DeepHealth(addr, "tcp", signer))
+	})
+
+	t.Run("unauthorized key", func(t *testing.T) {
+		requireHealthCode(t, agent.DeepHealth(addr, "tcp", badSigner), agent.HealthAuthFailed)
+	})
+
+	t.Run("server is not running", func(t *testing.T) {
+		requireHealthCode(t, agent.DeepHealth("127.0.0.1:65535", "tcp", signer), agent.HealthConnectFailed)
+	})
+
+	t.Run("server never responds", func(t *testing.T) {
+		t.Setenv("HEALTH_TIMEOUT", "200ms")
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		// accept the connection but never start the handshake
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}()
+		addr := listener.Addr().String()
+		requireHealthCode(t, agent.DeepHealth(addr, "tcp", signer), agent.HealthTimeout)
+	})
+}
+
+func TestLoadHealthSigner(t *testing.T) {
+	t.Setenv("HEALTH_KEY_FILE", "")
+	signer, err := agent.LoadHealthSigner()
+	require.NoError(t, err)
+	require.Nil(t, signer, "Expected no signer without key file")
+
+	_, privKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	block, err := gossh.MarshalPrivateKey(privKey, "")
+	require.NoError(t, err)
+	keyFile := t.TempDir() + "/health_key"
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600))
+
+	t.Setenv("HEALTH_KEY_FILE", keyFile)
+	signer, err = agent.LoadHealthSigner()
+	require.NoError(t, err)
+	require.NotNil(t, signer)
+}
@@ -0,0 +1,256 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// hwmonInput is a type of hwmon sensor input
+type hwmonInput struct {
+	prefix string  // Prefix of the sysfs attributes, e.g. "fan" for fan1_input
+	scale  float64 // Divides the raw value to get the reported unit
+}
+
+var (
+	hwmonTemp    = hwmonInput{prefix: "temp", scale: 1000}     // millidegrees to degrees
+	hwmonFan     = hwmonInput{prefix: "fan", scale: 1}         // RPM
+	hwmonVoltage = hwmonInput{prefix: "in", scale: 1000}       // millivolts to volts
+	hwmonCurrent = hwmonInput{prefix: "curr", scale: 1000}     // milliamps to amps
+	hwmonPower   = hwmonInput{prefix: "power", scale: 1000000} // microwatts to watts
+)
+
+// hwmonReader reads sensors from the sysfs hwmon class. Only available on Linux.
+type hwmonReader struct {
+	path     string              // Path of the hwmon class directory
+	spinning map[string]struct{} // Fans which have reported a speed since the agent started, for chips without fan limits
+}
+
+// hwmonChip is a hwmon chip directory
+type hwmonChip struct {
+	path   string
+	index  int    // Position in the hwmon class directory, appended to duplicate sensor keys
+	name   string // Driver name, e.g. coretemp
+	device string // Name of the chip's device, e.g. coretemp.1, stable across boots
+}
+
+// hwmonSensor is a single input of a hwmon chip
+type hwmonSensor struct {
+	key   string // Chip name and label, in the format gopsutil uses for temperatures
+	chip  hwmonChip
+	base  string  // Attribute name without suffix, e.g. fan1
+	raw   float64 // Value as read from sysfs
+	value float64 // Value in the reported unit
+}
+
+// hwmonStats are the readings of all hwmon chips, keyed by sensor name
+type hwmonStats struct {
+	fans          map[string]float64
+	voltages      map[string]float64
+	currents      map[string]float64
+	power         map[string]float64
+	voltageAlarms float64
+	groups        map[string]string
+}
+
+// newHwmonReader creates a hwmonReader if the system has any hwmon chips
+func newHwmonReader(sysPath string) *hwmonReader {
+	hr := &hwmonReader{path: filepath.Join(sysPath, "class/hwmon"), spinning: make(map[string]struct{})}
+	if chips, _ := filepath.Glob(filepath.Join(hr.path, "hwmon*")); len(chips) == 0 {
+		return nil
+	}
+	return hr
+}
+
+// collectHwmon gets the fan, voltage, current and power sensors allowed by the sensors config
+func (a *Agent) collectHwmon(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	// skip if sensors whitelist is set to empty string
+	if a.sensorConfig.skipCollection {
+		return nil, nil
+	}
+	chips, err := a.hwmon.chips()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := hwmonStats{groups: make(map[string]string)}
+	stats.fans = a.hwmon.readNamed(chips, hwmonFan, a.sensorConfig, stats.groups, func(name string, s hwmonSensor) bool {
+		// unconnected fan headers read zero, so stopped fans are left out unless the chip
+		// expects a speed from them or they have spun before
+		if s.value > 0 || fanExpected(s.chip.path, s.base) {
+			a.hwmon.spinning[name] = struct{}{}
+		}
+		_, ok := a.hwmon.spinning[name]
+		return ok
+	})
+	stats.voltages = a.hwmon.readNamed(chips, hwmonVoltage, a.sensorConfig, stats.groups, func(name string, s hwmonSensor) bool {
+		if voltageOutOfRange(s.chip.path, s.base, s.raw) {
+			stats.voltageAlarms++
+		}
+		return true
+	})
+	stats.currents = a.hwmon.readNamed(chips, hwmonCurrent, a.sensorConfig, stats.groups, nil)
+	stats.power = a.hwmon.readNamed(chips, hwmonPower, a.sensorConfig, stats.groups, nil)
+	slog.Debug("Hwmon", "fans", stats.fans, "voltages", stats.voltages)
+
+	return func(data *system.CombinedData) {
+		if len(stats.fans) > 0 {
+			data.Stats.Fans = stats.fans
+		}
+		if len(stats.voltages) > 0 {
+			data.Stats.Voltages = stats.voltages
+			data.Stats.VoltageAlarms = stats.voltageAlarms
+		}
+		if len(stats.currents) > 0 {
+			data.Stats.Currents = stats.currents
+		}
+		if len(stats.power) > 0 {
+			data.Stats.Power = stats.power
+		}
+		addSensorGroups(data, stats.groups)
+	}, nil
+}
+
+// chips returns the hwmon chips in the same order gopsutil reads them
+func (hr *hwmonReader) chips() ([]hwmonChip, error) {
+	paths, err := filepath.Glob(filepath.Join(hr.path, "hwmon*"))
+	if err != nil {
+		return nil, err
+	}
+	chips := make([]hwmonChip, 0, len(paths))
+	for i, path := range paths {
+		chip := hwmonChip{path: path, index: i, name: readSysfsString(path, "name"), device: hwmonDevice(path)}
+		if chip.name == "" {
+			chip.name = filepath.Base(path)
+		}
+		chips = append(chips, chip)
+	}
+	return chips, nil
+}
+
+// hwmonDevice returns the name of the device a hwmon chip belongs to. The hwmon
+// number depends on driver load order, but the device does not.
+func hwmonDevice(chip string) string {
+	if target, err := os.Readlink(filepath.Join(chip, "device")); err == nil {
+		return filepath.Base(target)
+	}
+	// class entries link to .../<device>/hwmon/hwmonN
+	if resolved, err := filepath.EvalSymlinks(chip); err == nil && resolved != chip {
+		if parent := filepath.Dir(resolved); filepath.Base(parent) == "hwmon" {
+			return filepath.Base(filepath.Dir(parent))
+		}
+	}
+	return filepath.Base(chip)
+}
+
+// read returns the inputs of a type from all chips
+func (hr *hwmonReader) read(chips []hwmonChip, input hwmonInput) []hwmonSensor {
+	var sensors []hwmonSensor
+	for _, chip := range chips {
+		paths, _ := filepath.Glob(filepath.Join(chip.path, input.prefix+"[0-9]*_input"))
+		// some drivers (amdgpu) only report average power
+		if input == hwmonPower && len(paths) == 0 {
+			paths, _ = filepath.Glob(filepath.Join(chip.path, "power[0-9]*_average"))
+		}
+		for _, path := range paths {
+			base, _, _ := strings.Cut(filepath.Base(path), "_")
+			raw, ok := readSysfsFloat(path)
+			if !ok {
+				continue
+			}
+			label := readSysfsString(chip.path, base+"_label")
+			// unlabelled temperatures are named after the chip only, as in gopsutil
+			if label == "" && input != hwmonTemp {
+				label = base
+			}
+			sensors = append(sensors, hwmonSensor{
+				key:   hwmonSensorKey(chip.name, label),
+				chip:  chip,
+				base:  base,
+				raw:   raw,
+				value: raw / input.scale,
+			})
+		}
+	}
+	return sensors
+}
+
+// readNamed returns the inputs of a type keyed by sensor name, adding the group of
+// each to groups. keep is called with each sensor allowed by the sensors config
+// and may leave it out by returning false.
+func (hr *hwmonReader) readNamed(chips []hwmonChip, input hwmonInput, config *SensorConfig, groups map[string]string, keep func(string, hwmonSensor) bool) map[string]float64 {
+	values := make(map[string]float64)
+	namer := config.newSensorNamer()
+	for _, s := range hr.read(chips, input) {
+		_, name, group, ok := namer.name(s.key, s.chip.device, s.chip.index)
+		if !ok || (keep != nil && !keep(name, s)) {
+			continue
+		}
+		values[name] = twoDecimals(s.value)
+		if group != "" {
+			groups[name] = group
+		}
+	}
+	return values
+}
+
+// voltageOutOfRange reports whether a voltage input is outside the limits set in
+// the chip, or the chip has raised its alarm
+func voltageOutOfRange(chip, base string, raw float64) bool {
+	if alarm, ok := readSysfsFloat(filepath.Join(chip, base+"_alarm")); ok && alarm != 0 {
+		return true
+	}
+	low, lowOk := readSysfsFloat(filepath.Join(chip, base+"_min"))
+	high, highOk := readSysfsFloat(filepath.Join(chip, base+"_max"))
+	// unconfigured limits are often both zero
+	if !lowOk || !highOk || high <= low {
+		return false
+	}
+	return raw < low || raw > high
+}
+
+// fanExpected reports whether a fan header is set up for a fan, with a minimum or
+// target speed, an alarm raised, or the input enabled on chips which disable
+// unconnected headers. A stopped fan on such a header has failed.
+func fanExpected(chip, base string) bool {
+	for _, attr := range []string{"_min", "_target", "_alarm", "_enable"} {
+		if value, ok := readSysfsFloat(filepath.Join(chip, base+attr)); ok && value > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hwmonSensorKey returns the sensor key in the same format gopsutil uses for temperatures
+func hwmonSensorKey(chip, label string) string {
+	if label == "" {
+		return chip
+	}
+	return chip + "_" + strings.Join(strings.Split(strings.ToLower(label), " "), "_")
+}
+
+// addSensorGroups adds the groups of named sensors to the payload
+func addSensorGroups(data *system.CombinedData, groups map[string]string) {
+	if len(groups) == 0 {
+		return
+	}
+	if data.SensorGroups == nil {
+		data.SensorGroups = make(map[string]string, len(groups))
+	}
+	maps.Copy(data.SensorGroups, groups)
+}
+
+// readSysfsFloat reads a sysfs attribute containing a number
+func readSysfsFloat(path string) (float64, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(content)), 64)
+	return value, err == nil
+}
@@ -0,0 +1,160 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestHwmonAgent creates an agent reading a fake sysfs with two chips of the
+// same name, numbered in the opposite order of their devices
+func newTestHwmonAgent(t *testing.T, sensors string) *Agent {
+	sysPath := t.TempDir()
+	writeSysfs(t, sysPath, map[string]string{
+		"devices/platform/nct6775.656/hwmon/hwmon0/name":         "nct6798",
+		"devices/platform/nct6775.656/hwmon/hwmon0/fan1_input":   "1200",
+		"devices/platform/nct6775.656/hwmon/hwmon0/fan1_label":   "CPU Fan",
+		"devices/platform/nct6775.656/hwmon/hwmon0/fan2_input":   "0",
+		"devices/platform/nct6775.656/hwmon/hwmon0/fan2_min":     "0",
+		"devices/platform/nct6775.656/hwmon/hwmon0/fan3_input":   "0",
+		"devices/platform/nct6775.656/hwmon/hwmon0/fan3_label":   "Pump",
+		"devices/platform/nct6775.656/hwmon/hwmon0/fan3_min":     "600",
+		"devices/platform/nct6775.656/hwmon/hwmon0/in0_input":    "1056",
+		"devices/platform/nct6775.656/hwmon/hwmon0/in0_label":    "Vcore",
+		"devices/platform/nct6775.656/hwmon/hwmon0/in0_min":      "800",
+		"devices/platform/nct6775.656/hwmon/hwmon0/in0_max":      "1500",
+		"devices/platform/nct6775.656/hwmon/hwmon0/in1_input":    "3300",
+		"devices/platform/nct6775.656/hwmon/hwmon0/in1_min":      "3400",
+		"devices/platform/nct6775.656/hwmon/hwmon0/in1_max":      "3600",
+		"devices/platform/nct6775.656/hwmon/hwmon0/in2_input":    "5000",
+		"devices/platform/nct6775.656/hwmon/hwmon0/in2_min":      "0",
+		"devices/platform/nct6775.656/hwmon/hwmon0/in2_max":      "0",
+		"devices/platform/nct6775.656/hwmon/hwmon0/in3_input":    "12000",
+		"devices/platform/nct6775.656/hwmon/hwmon0/in3_alarm":    "1",
+		"devices/platform/nct6775.656/hwmon/hwmon0/temp1_input":  "45000",
+		"devices/platform/nct6775.656/hwmon/hwmon0/temp1_label":  "SYSTIN",
+		"devices/platform/nct6775.2592/hwmon/hwmon2/name":        "nct6798",
+		"devices/platform/nct6775.2592/hwmon/hwmon2/fan1_input":  "900",
+		"devices/platform/nct6775.2592/hwmon/hwmon2/fan1_label":  "CPU Fan",
+		"devices/platform/nct6775.2592/hwmon/hwmon2/temp1_input": "38500",
+		"devices/platform/nct6775.2592/hwmon/hwmon2/temp1_label": "SYSTIN",
+		"class/hwmon/hwmon1/name":                                "amdgpu",
+		"class/hwmon/hwmon1/power1_average":                      "45000000",
+		"class/hwmon/hwmon1/curr1_input":                         "1500",
+		"class/hwmon/hwmon1/temp1_input":                         "52000",
+	})
+	require.NoError(t, os.Symlink("../../devices/platform/nct6775.656/hwmon/hwmon0", filepath.Join(sysPath, "class/hwmon/hwmon0")))
+	require.NoError(t, os.Symlink("../../devices/platform/nct6775.2592/hwmon/hwmon2", filepath.Join(sysPath, "class/hwmon/hwmon2")))
+	a := &Agent{sensorConfig: (&Agent{}).newSensorConfigWithEnv("", sysPath, sensors, false)}
+	a.hwmon = newHwmonReader(a.sensorConfig.sysPath)
+	require.NotNil(t, a.hwmon)
+	return a
+}
+
+func TestCollectHwmon(t *testing.T) {
+	a := newTestHwmonAgent(t, "")
+	apply, err := a.collectHwmon(context.Background(), &hubState{})
+	require.NoError(t, err)
+	data := &system.CombinedData{}
+	apply(data)
+
+	assert.Equal(t, map[string]float64{"nct6798_cpu_fan": 1200, "nct6798_pump": 0, "nct6798_cpu_fan_2": 900}, data.Stats.Fans,
+		"Expected stopped fans without a minimum speed which never spun to be left out and duplicate names to get the chip index")
+	assert.Equal(t, map[string]float64{
+		"nct6798_vcore": 1.06,
+		"nct6798_in1":   3.3,
+		"nct6798_in2":   5,
+		"nct6798_in3":   12,
+	}, data.Stats.Voltages)
+	assert.Equal(t, 2.0, data.Stats.VoltageAlarms, "Expected in1 below its minimum and in3 alarmed")
+	assert.Equal(t, map[string]float64{"amdgpu_curr1": 1.5}, data.Stats.Currents)
+	assert.Equal(t, map[string]float64{"amdgpu_power1": 45}, data.Stats.Power)
+	assert.Nil(t, data.SensorGroups)
+
+	// a fan that stops after spinning is reported
+	writeSysfs(t, a.sensorConfig.sysPath, map[string]string{"class/hwmon/hwmon0/fan1_input": "0"})
+	apply, err = a.collectHwmon(context.Background(), &hubState{})
+	require.NoError(t, err)
+	data = &system.CombinedData{}
+	apply(data)
+	assert.Equal(t, map[string]float64{"nct6798_cpu_fan": 0, "nct6798_pump": 0, "nct6798_cpu_fan_2": 900}, data.Stats.Fans)
+}
+
+func TestCollectHwmonFiltered(t *testing.T) {
+	a := newTestHwmonAgent(t, "-nct6798_in*,nct6798_vcore,amdgpu_*")
+	apply, err := a.collectHwmon(context.Background(), &hubState{})
+	require.NoError(t, err)
+	data := &system.CombinedData{}
+	apply(data)
+	assert.Len(t, data.Stats.Fans, 3)
+	assert.Nil(t, data.Stats.Voltages)
+	assert.Zero(t, data.Stats.VoltageAlarms)
+	assert.Nil(t, data.Stats.Currents)
+	assert.Nil(t, data.Stats.Power)
+
+	a.sensorConfig.skipCollection = true
+	apply, err = a.collectHwmon(context.Background(), &hubState{})
+	assert.NoError(t, err)
+	assert.Nil(t, apply)
+}
+
+func TestCollectTemperaturesHwmon(t *testing.T) {
+	a := newTestHwmonAgent(t, "")
+
+	// duplicate keys get the index of the sensor, as in gopsutil
+	apply, err := a.collectTemperatures(context.Background(), &hubState{})
+	require.NoError(t, err)
+	data := &system.CombinedData{}
+	apply(data)
+	assert.Equal(t, map[string]float64{"nct6798_systin": 45, "nct6798_systin_2": 38.5, "amdgpu": 52}, data.Stats.Temperatures)
+
+	aliases, err := parseSensorAliases([]byte(`
+- match: nct6798_systin*
+  device: nct6775.656
+  name: Board systin
+  group: Board
+- match: nct6798_systin*
+  device: nct6775.2592
+  name: Aux systin
+- match: amdgpu
+  name: GPU
+`))
+	require.NoError(t, err)
+	a.sensorConfig.aliases = aliases
+	a.sensorConfig.primarySensor = "nct6798_systin_2"
+
+	apply, err = a.collectTemperatures(context.Background(), &hubState{})
+	require.NoError(t, err)
+	data = &system.CombinedData{}
+	apply(data)
+	assert.Equal(t, map[string]float64{"Board systin": 45, "Aux systin": 38.5, "GPU": 52}, data.Stats.Temperatures)
+	assert.Equal(t, 38.5, data.Info.DashboardTemp)
+	assert.Equal(t, map[string]string{"Board systin": "Board"}, data.SensorGroups)
+
+	// aliases matching the device keep their names when the chips are numbered differently
+	sysPath := a.sensorConfig.sysPath
+	require.NoError(t, os.Remove(filepath.Join(sysPath, "class/hwmon/hwmon0")))
+	require.NoError(t, os.Remove(filepath.Join(sysPath, "class/hwmon/hwmon2")))
+	require.NoError(t, os.Symlink("../../devices/platform/nct6775.2592/hwmon/hwmon2", filepath.Join(sysPath, "class/hwmon/hwmon0")))
+	require.NoError(t, os.Symlink("../../devices/platform/nct6775.656/hwmon/hwmon0", filepath.Join(sysPath, "class/hwmon/hwmon2")))
+	apply, err = a.collectTemperatures(context.Background(), &hubState{})
+	require.NoError(t, err)
+	data = &system.CombinedData{}
+	apply(data)
+	assert.Equal(t, map[string]float64{"Board systin": 45, "Aux systin": 38.5, "GPU": 52}, data.Stats.Temperatures)
+
+	// primary sensor may be the alias
+	a.sensorConfig.primarySensor = "GPU"
+	apply, err = a.collectTemperatures(context.Background(), &hubState{})
+	require.NoError(t, err)
+	apply(data)
+	assert.Equal(t, 52.0, data.Info.DashboardTemp)
+}
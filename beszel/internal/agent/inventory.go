@@ -0,0 +1,265 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// Default time between sending the inventory to each hub
+const defaultInventoryInterval = 6 * time.Hour
+
+// inventoryReader reads the hardware and OS inventory. Hardware details are
+// read from sysfs so are only available on Linux.
+type inventoryReader struct {
+	interval      time.Duration // Time between sending the inventory to each hub
+	sysPath       string        // sysfs mount point
+	osReleasePath string        // os-release file
+	pciIdsPaths   []string      // pci.ids database locations, used for NIC model names
+}
+
+// newInventoryReader creates an inventoryReader using the INVENTORY_INTERVAL env var.
+// Returns nil if disabled with an interval of 0.
+func newInventoryReader() *inventoryReader {
+	interval := getEnvDuration("INVENTORY_INTERVAL", defaultInventoryInterval)
+	if interval <= 0 {
+		return nil
+	}
+	return &inventoryReader{
+		interval:      interval,
+		sysPath:       "/sys",
+		osReleasePath: "/etc/os-release",
+		pciIdsPaths:   []string{"/usr/share/hwdata/pci.ids", "/usr/share/misc/pci.ids", "/usr/share/pci.ids"},
+	}
+}
+
+// collectInventory gets the inventory if it has not been sent to the hub within the interval.
+// The time is set when the inventory is added to the payload, so it is sent again on the
+// next request if the collector times out.
+func (a *Agent) collectInventory(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	now := time.Now()
+	if now.Sub(hs.inventorySent) < a.inventory.interval {
+		return nil, nil
+	}
+	inventory := a.inventory.read(ctx)
+	return func(data *system.CombinedData) {
+		data.Inventory = inventory
+		hs.inventorySent = now
+	}, nil
+}
+
+// read gets the current inventory. Missing details are left empty.
+func (ir *inventoryReader) read(ctx context.Context) *system.Inventory {
+	inventory := &system.Inventory{}
+
+	// os
+	if platform, _, version, err := host.PlatformInformationWithContext(ctx); err == nil {
+		inventory.OsId, inventory.OsVersion = platform, version
+	}
+	if osRelease := readKeyValueFile(ir.osReleasePath); osRelease["PRETTY_NAME"] != "" {
+		inventory.OsName = osRelease["PRETTY_NAME"]
+		if osRelease["ID"] != "" {
+			inventory.OsId = osRelease["ID"]
+		}
+		if osRelease["VERSION_ID"] != "" {
+			inventory.OsVersion = osRelease["VERSION_ID"]
+		}
+	}
+
+	// dmi
+	dmiPath := filepath.Join(ir.sysPath, "class/dmi/id")
+	inventory.Vendor = readSysfsString(dmiPath, "sys_vendor")
+	inventory.Model = readSysfsString(dmiPath, "product_name")
+	inventory.Serial = readSysfsString(dmiPath, "product_serial")
+	inventory.BiosVendor = readSysfsString(dmiPath, "bios_vendor")
+	inventory.BiosVersion = readSysfsString(dmiPath, "bios_version")
+	inventory.BiosDate = readSysfsString(dmiPath, "bios_date")
+
+	// memory
+	if v, err := mem.VirtualMemoryWithContext(ctx); err == nil {
+		inventory.MemoryTotal = bytesToGigabytes(v.Total)
+	}
+	inventory.Dimms = ir.readDimms()
+	inventory.Nics = ir.readNics()
+	inventory.Disks = ir.readDisks()
+	return inventory
+}
+
+// readDimms reads memory modules from the EDAC driver, if loaded
+func (ir *inventoryReader) readDimms() []system.DimmInfo {
+	paths, _ := filepath.Glob(filepath.Join(ir.sysPath, "devices/system/edac/mc/mc*/dimm*"))
+	var dimms []system.DimmInfo
+	for _, path := range paths {
+		sizeMb, err := strconv.ParseFloat(readSysfsString(path, "size"), 64)
+		if err != nil || sizeMb == 0 {
+			continue
+		}
+		name := readSysfsString(path, "dimm_label")
+		if name == "" {
+			name = filepath.Base(filepath.Dir(path)) + "/" + filepath.Base(path)
+		}
+		dimms = append(dimms, system.DimmInfo{Name: name, Size: twoDecimals(sizeMb / 1024)})
+	}
+	return dimms
+}
+
+// readNics reads physical network interfaces. Virtual interfaces have no device link and are skipped.
+func (ir *inventoryReader) readNics() []system.NicInfo {
+	entries, _ := os.ReadDir(filepath.Join(ir.sysPath, "class/net"))
+	var nics []system.NicInfo
+	for _, entry := range entries {
+		ifacePath := filepath.Join(ir.sysPath, "class/net", entry.Name())
+		devicePath := filepath.Join(ifacePath, "device")
+		if _, err := os.Stat(devicePath); err != nil {
+			continue
+		}
+		nic := system.NicInfo{Name: entry.Name(), Mac: readSysfsString(ifacePath, "address")}
+		if driver, err := os.Readlink(filepath.Join(devicePath, "driver")); err == nil {
+			nic.Driver = filepath.Base(driver)
+		}
+		vendorID := strings.TrimPrefix(readSysfsString(devicePath, "vendor"), "0x")
+		deviceID := strings.TrimPrefix(readSysfsString(devicePath, "device"), "0x")
+		if vendorID != "" && deviceID != "" {
+			nic.Model = vendorID + ":" + deviceID
+		}
+		nics = append(nics, nic)
+	}
+	// models are replaced with names from a single read of pci.ids
+	ids := make(map[string]struct{}, len(nics))
+	for _, nic := range nics {
+		if nic.Model != "" {
+			ids[nic.Model] = struct{}{}
+		}
+	}
+	names := ir.pciNames(ids)
+	for i := range nics {
+		if name, ok := names[nics[i].Model]; ok {
+			nics[i].Model = name
+		}
+	}
+	return nics
+}
+
+// readDisks reads physical block devices. Virtual devices (loop, dm, md, zram) have
+// no device link and are skipped.
+func (ir *inventoryReader) readDisks() []system.BlockDevice {
+	entries, _ := os.ReadDir(filepath.Join(ir.sysPath, "block"))
+	var disks []system.BlockDevice
+	for _, entry := range entries {
+		blockPath := filepath.Join(ir.sysPath, "block", entry.Name())
+		devicePath := filepath.Join(blockPath, "device")
+		if _, err := os.Stat(devicePath); err != nil {
+			continue
+		}
+		// size is always in 512 byte sectors
+		sectors, _ := strconv.ParseUint(readSysfsString(blockPath, "size"), 10, 64)
+		if sectors == 0 {
+			continue
+		}
+		disks = append(disks, system.BlockDevice{
+			Name:       entry.Name(),
+			Model:      readSysfsString(devicePath, "model"),
+			Serial:     readSysfsString(devicePath, "serial"),
+			Size:       bytesToGigabytes(sectors * 512),
+			Rotational: readSysfsString(blockPath, "queue/rotational") == "1",
+		})
+	}
+	return disks
+}
+
+// pciNames returns the names of "vendor:device" ids from the first pci.ids database
+// found. Devices missing from a known vendor are named "vendor device" and ids with
+// an unknown vendor are left out.
+func (ir *inventoryReader) pciNames(ids map[string]struct{}) map[string]string {
+	if len(ids) == 0 {
+		return nil
+	}
+	for _, path := range ir.pciIdsPaths {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		names := parsePciIds(file, ids)
+		file.Close()
+		return names
+	}
+	return nil
+}
+
+// parsePciIds finds the names of "vendor:device" ids in a pci.ids database
+func parsePciIds(r io.Reader, ids map[string]struct{}) map[string]string {
+	vendors := make(map[string]string) // names of the vendors of ids
+	devices := make(map[string]string) // names of devices in ids
+	var vendorID string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "" || line[0] == '#':
+		case line[0] != '\t':
+			// vendor lines have no indent: "8086  Intel Corporation"
+			id, name, _ := strings.Cut(line, "  ")
+			vendorID = id
+			for key := range ids {
+				if strings.HasPrefix(key, id+":") {
+					vendors[id] = name
+					break
+				}
+			}
+		case vendors[vendorID] != "" && !strings.HasPrefix(line, "\t\t"):
+			// device lines have a single tab
+			id, name, _ := strings.Cut(line[1:], "  ")
+			key := vendorID + ":" + id
+			if _, ok := ids[key]; ok {
+				devices[key] = name
+			}
+		}
+	}
+	names := make(map[string]string, len(ids))
+	for key := range ids {
+		vendorID, deviceID, _ := strings.Cut(key, ":")
+		switch {
+		case devices[key] != "":
+			names[key] = vendors[vendorID] + " " + devices[key]
+		case vendors[vendorID] != "":
+			names[key] = vendors[vendorID] + " " + deviceID
+		}
+	}
+	return names
+}
+
+// readSysfsString returns the trimmed contents of a sysfs file, or an empty string
+func readSysfsString(dir, name string) string {
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// readKeyValueFile reads a file of KEY=value lines such as os-release,
+// removing quotes from values
+func readKeyValueFile(path string) map[string]string {
+	values := make(map[string]string)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return values
+	}
+	for line := range strings.Lines(string(content)) {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		values[key] = strings.Trim(value, `"'`)
+	}
+	return values
+}
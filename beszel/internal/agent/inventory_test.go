@@ -0,0 +1,141 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSysfs writes files relative to root, creating directories as needed
+func writeSysfs(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content+"\n"), 0o644))
+	}
+}
+
+func newTestInventoryReader(t *testing.T) *inventoryReader {
+	root := t.TempDir()
+	sysPath := filepath.Join(root, "sys")
+	writeSysfs(t, sysPath, map[string]string{
+		"class/dmi/id/sys_vendor":   "Dell Inc.",
+		"class/dmi/id/product_name": "PowerEdge R640",
+		"class/dmi/id/bios_version": "2.19.1",
+		"class/dmi/id/bios_date":    "06/05/2023",
+
+		"devices/system/edac/mc/mc0/dimm0/size":       "32768",
+		"devices/system/edac/mc/mc0/dimm0/dimm_label": "CPU_SrcID#0_MC#0_Chan#0_DIMM#0",
+		"devices/system/edac/mc/mc0/dimm1/size":       "16384",
+		"devices/system/edac/mc/mc0/dimm2/size":       "0",
+
+		"class/net/eno1/address":       "24:6e:96:00:00:01",
+		"class/net/eno1/device/vendor": "0x8086",
+		"class/net/eno1/device/device": "0x1533",
+		"class/net/eno2/address":       "24:6e:96:00:00:02",
+		"class/net/eno2/device/vendor": "0x14e4",
+		"class/net/eno2/device/device": "0x165f",
+		"class/net/docker0/address":    "02:42:00:00:00:01",
+
+		"block/sda/size":              "1953525168",
+		"block/sda/queue/rotational":  "1",
+		"block/sda/device/model":      "ST1000NM0033",
+		"block/nvme0n1/size":          "1000215216",
+		"block/nvme0n1/device/model":  "Samsung SSD 970 EVO Plus 500GB",
+		"block/nvme0n1/device/serial": "S4EVNX0N000000",
+		"block/loop0/size":            "1000",
+	})
+	require.NoError(t, os.MkdirAll(filepath.Join(sysPath, "bus/pci/drivers/igb"), 0o755))
+	require.NoError(t, os.Symlink(filepath.Join(sysPath, "bus/pci/drivers/igb"), filepath.Join(sysPath, "class/net/eno1/device/driver")))
+
+	writeSysfs(t, root, map[string]string{
+		"os-release": "PRETTY_NAME=\"Debian GNU/Linux 12 (bookworm)\"\nID=debian\nVERSION_ID=\"12\"\n# comment",
+		"pci.ids":    "# comment\n8086  Intel Corporation\n\t1533  I210 Gigabit Network Connection\n\t\t103c 0003  Ethernet I210-T1 GbE NIC\n14e4  Broadcom Inc. and subsidiaries\n\t1657  NetXtreme BCM5719",
+	})
+
+	return &inventoryReader{
+		interval:      time.Hour,
+		sysPath:       sysPath,
+		osReleasePath: filepath.Join(root, "os-release"),
+		pciIdsPaths:   []string{filepath.Join(root, "missing.ids"), filepath.Join(root, "pci.ids")},
+	}
+}
+
+func TestInventoryRead(t *testing.T) {
+	ir := newTestInventoryReader(t)
+	inventory := ir.read(context.Background())
+
+	assert.Equal(t, "Debian GNU/Linux 12 (bookworm)", inventory.OsName)
+	assert.Equal(t, "debian", inventory.OsId)
+	assert.Equal(t, "12", inventory.OsVersion)
+	assert.Equal(t, "Dell Inc.", inventory.Vendor)
+	assert.Equal(t, "PowerEdge R640", inventory.Model)
+	assert.Empty(t, inventory.Serial)
+	assert.Equal(t, "2.19.1", inventory.BiosVersion)
+	assert.Greater(t, inventory.MemoryTotal, 0.0)
+
+	assert.ElementsMatch(t, []system.DimmInfo{
+		{Name: "CPU_SrcID#0_MC#0_Chan#0_DIMM#0", Size: 32},
+		{Name: "mc0/dimm1", Size: 16},
+	}, inventory.Dimms)
+
+	assert.Equal(t, []system.NicInfo{
+		{Name: "eno1", Mac: "24:6e:96:00:00:01", Driver: "igb", Model: "Intel Corporation I210 Gigabit Network Connection"},
+		{Name: "eno2", Mac: "24:6e:96:00:00:02", Model: "Broadcom Inc. and subsidiaries 165f"},
+	}, inventory.Nics, "Expected physical NICs only")
+
+	assert.Equal(t, []system.BlockDevice{
+		{Name: "nvme0n1", Model: "Samsung SSD 970 EVO Plus 500GB", Serial: "S4EVNX0N000000", Size: 476.94},
+		{Name: "sda", Model: "ST1000NM0033", Size: 931.51, Rotational: true},
+	}, inventory.Disks, "Expected physical disks only")
+}
+
+func TestPciNamesFallback(t *testing.T) {
+	ir := &inventoryReader{pciIdsPaths: []string{"/nonexistent/pci.ids"}}
+	assert.Empty(t, ir.pciNames(map[string]struct{}{"8086:1533": {}}))
+
+	names := parsePciIds(strings.NewReader("8086  Intel Corporation\n\t1533  I210 Gigabit Network Connection\n"),
+		map[string]struct{}{"8086:1533": {}, "8086:10fb": {}, "15b3:1017": {}})
+	assert.Equal(t, map[string]string{
+		"8086:1533": "Intel Corporation I210 Gigabit Network Connection",
+		"8086:10fb": "Intel Corporation 10fb",
+	}, names, "Expected ids with an unknown vendor to be left out")
+}
+
+func TestCollectInventoryInterval(t *testing.T) {
+	a := &Agent{inventory: newTestInventoryReader(t)}
+	hs := &hubState{}
+
+	// inventory which is not added to the payload, e.g. after a timeout, is collected again
+	_, err := a.collectInventory(context.Background(), hs)
+	require.NoError(t, err)
+
+	apply, err := a.collectInventory(context.Background(), hs)
+	require.NoError(t, err)
+	require.NotNil(t, apply, "Expected inventory on first request")
+	data := &system.CombinedData{}
+	apply(data)
+	require.NotNil(t, data.Inventory)
+	assert.Equal(t, "PowerEdge R640", data.Inventory.Model)
+
+	apply, _ = a.collectInventory(context.Background(), hs)
+	assert.Nil(t, apply, "Expected no inventory within the interval")
+
+	// other hubs get their own inventory
+	apply, _ = a.collectInventory(context.Background(), &hubState{})
+	assert.NotNil(t, apply)
+
+	hs.inventorySent = time.Now().Add(-2 * time.Hour)
+	apply, _ = a.collectInventory(context.Background(), hs)
+	assert.NotNil(t, apply, "Expected inventory after the interval")
+}
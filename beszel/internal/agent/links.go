@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// linkReader reads the link state, speed and traffic of network interfaces from
+// sysfs. Only available on Linux.
+type linkReader struct {
+	path string // Path of the net class directory
+	nics bool   // NICS is set, so only the interfaces it selects are reported
+}
+
+// newLinkReader creates a linkReader if sysfs has network interfaces
+func newLinkReader() *linkReader {
+	path := "/sys/class/net"
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	_, nics := GetEnv("NICS")
+	return &linkReader{path: path, nics: nics}
+}
+
+// collectLinks gets the link state of the physical interfaces and the interfaces
+// used for bandwidth, and the percent of link speed they use
+func (a *Agent) collectLinks(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	names := a.links.interfaces(a.getNetInterfaces())
+	if len(names) == 0 {
+		return nil, nil
+	}
+	now := time.Now()
+	links := make([]system.NetLink, 0, len(names))
+	util := make(map[string]float64, len(names))
+	for _, name := range names {
+		link, ok := a.links.read(name)
+		if !ok {
+			continue
+		}
+		links = append(links, link)
+		if value, ok := a.links.utilization(link, &hs.linkRates, now); ok {
+			util[name] = value
+		}
+	}
+	if len(links) == 0 {
+		return nil, nil
+	}
+	return func(data *system.CombinedData) {
+		data.Links = links
+		if len(util) > 0 {
+			data.Stats.NetUtil = util
+		}
+	}, nil
+}
+
+// interfaces returns the sorted names of the interfaces used for bandwidth and,
+// unless NICS is set, every physical interface so links which are down when the
+// agent starts are reported too. Physical interfaces have a device link in sysfs.
+func (lr *linkReader) interfaces(netInterfaces map[string]struct{}) []string {
+	names := maps.Clone(netInterfaces)
+	if names == nil {
+		names = make(map[string]struct{})
+	}
+	if !lr.nics {
+		entries, _ := os.ReadDir(lr.path)
+		for _, entry := range entries {
+			if _, err := os.Stat(filepath.Join(lr.path, entry.Name(), "device")); err == nil {
+				names[entry.Name()] = struct{}{}
+			}
+		}
+	}
+	return slices.Sorted(maps.Keys(names))
+}
+
+// read gets the state, speed and duplex of an interface. Speed is unknown for
+// virtual interfaces and links which are down.
+func (lr *linkReader) read(name string) (system.NetLink, bool) {
+	dir := filepath.Join(lr.path, name)
+	link := system.NetLink{Name: name, State: readSysfsString(dir, "operstate")}
+	if link.State == "" {
+		return link, false
+	}
+	if speed, ok := readSysfsFloat(filepath.Join(dir, "speed")); ok && speed > 0 {
+		link.Speed = speed
+	}
+	if duplex := readSysfsString(dir, "duplex"); duplex == "full" || duplex == "half" {
+		link.Duplex = duplex
+	}
+	return link, true
+}
+
+// utilization returns the percent of link speed used since the hub's previous request.
+// Full duplex links are limited by the busier direction, while half duplex links
+// share the speed between both.
+func (lr *linkReader) utilization(link system.NetLink, rates *counterRates, now time.Time) (float64, bool) {
+	stats := filepath.Join(lr.path, link.Name, "statistics")
+	rxBytes, rxOk := readSysfsFloat(filepath.Join(stats, "rx_bytes"))
+	txBytes, txOk := readSysfsFloat(filepath.Join(stats, "tx_bytes"))
+	if !rxOk || !txOk {
+		return 0, false
+	}
+	rx, rxOk := rates.rate(link.Name+"/rx", rxBytes, now)
+	tx, txOk := rates.rate(link.Name+"/tx", txBytes, now)
+	if !rxOk || !txOk || link.Speed == 0 {
+		return 0, false
+	}
+	bytesPerSecond := max(rx, tx)
+	if link.Duplex == "half" {
+		bytesPerSecond = rx + tx
+	}
+	// speed is in megabits per second
+	return twoDecimals(min(100, bytesPerSecond*8/(link.Speed*1e6)*100)), true
+}
@@ -0,0 +1,90 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectLinks(t *testing.T) {
+	root := t.TempDir()
+	writeSysfs(t, root, map[string]string{
+		"eth0/operstate":           "up",
+		"eth0/speed":               "10000",
+		"eth0/duplex":              "full",
+		"eth0/statistics/rx_bytes": "0",
+		"eth0/statistics/tx_bytes": "0",
+		"eth1/operstate":           "down",
+		"eth1/speed":               "-1",
+		"eth1/duplex":              "unknown",
+		"eth1/statistics/rx_bytes": "0",
+		"eth1/statistics/tx_bytes": "0",
+		"eth2/operstate":           "down",
+		"eth2/device/vendor":       "0x8086",
+		"wg0/operstate":            "unknown",
+	})
+	a := &Agent{
+		links:         &linkReader{path: root},
+		netInterfaces: map[string]struct{}{"eth0": {}, "eth1": {}, "wg0": {}, "gone0": {}},
+	}
+	rates := &counterRates{}
+	start := time.Now()
+
+	link, ok := a.links.read("eth0")
+	require.True(t, ok)
+	assert.Equal(t, system.NetLink{Name: "eth0", State: "up", Speed: 10000, Duplex: "full"}, link)
+	_, ok = a.links.utilization(link, rates, start)
+	assert.False(t, ok, "Expected no utilization from the first sample")
+
+	// 250 MB/s received is 20% of 10G
+	writeSysfs(t, root, map[string]string{"eth0/statistics/rx_bytes": "2500000000", "eth0/statistics/tx_bytes": "100"})
+	util, ok := a.links.utilization(link, rates, start.Add(10*time.Second))
+	require.True(t, ok)
+	assert.Equal(t, 20.0, util)
+
+	fn, err := a.collectLinks(t.Context(), &hubState{})
+	require.NoError(t, err)
+	var data system.CombinedData
+	fn(&data)
+	assert.Equal(t, []system.NetLink{
+		{Name: "eth0", State: "up", Speed: 10000, Duplex: "full"},
+		{Name: "eth1", State: "down"},
+		{Name: "eth2", State: "down"},
+		{Name: "wg0", State: "unknown"},
+	}, data.Links, "Expected physical interfaces to be added and removed interfaces to be skipped")
+
+	// with NICS set only the selected interfaces are reported
+	a.links.nics = true
+	fn, err = a.collectLinks(t.Context(), &hubState{})
+	require.NoError(t, err)
+	data = system.CombinedData{}
+	fn(&data)
+	assert.Len(t, data.Links, 3)
+	assert.NotContains(t, data.Links, system.NetLink{Name: "eth2", State: "down"})
+}
+
+func TestCollectLinksDuringNetworkReset(t *testing.T) {
+	t.Setenv("NICS", "lo")
+	a := &Agent{links: &linkReader{path: t.TempDir(), nics: true}}
+	a.initializeNetIoStats()
+
+	// the network collector may replace the interfaces while links are collected
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 20 {
+			a.initializeNetIoStats()
+		}
+	}()
+	for range 20 {
+		_, err := a.collectLinks(t.Context(), &hubState{})
+		require.NoError(t, err)
+	}
+	<-done
+}
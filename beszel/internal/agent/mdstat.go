@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultMdstatPath is the kernel's software RAID status file
+const defaultMdstatPath = "/proc/mdstat"
+
+var (
+	// mdCountsRe matches the total and active devices of an array, e.g. [2/1]
+	mdCountsRe = regexp.MustCompile(`\[(\d+)/(\d+)\]`)
+	// mdSyncRe matches a resync, recovery, reshape, check or repair in progress
+	mdSyncRe = regexp.MustCompile(`(resync|recovery|reshape|check|repair)\s*=\s*([\d.]+)%(?:.*finish=(\S+))?`)
+	// mdDeviceRe matches a member device, e.g. sda1[0](F)
+	mdDeviceRe = regexp.MustCompile(`^(\S+)\[\d+\](?:\((\w)\))?$`)
+)
+
+// collectRaid gets the state of md software RAID arrays
+func (a *Agent) collectRaid(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	content, err := os.ReadFile(a.mdstat)
+	if err != nil {
+		return nil, err
+	}
+	arrays := parseMdstat(string(content))
+	if len(arrays) == 0 {
+		return nil, nil
+	}
+	return func(data *system.CombinedData) {
+		data.Raid = arrays
+	}, nil
+}
+
+// parseMdstat parses the arrays in /proc/mdstat. Each array starts with a line such as
+// "md0 : active raid1 sdb1[1] sda1[0](F)" followed by indented status lines.
+func parseMdstat(content string) []system.RaidArray {
+	var arrays []system.RaidArray
+	var array *system.RaidArray
+	for line := range strings.Lines(content) {
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			name, rest, ok := strings.Cut(line, " : ")
+			if !ok || !strings.HasPrefix(name, "md") {
+				array = nil
+				continue
+			}
+			arrays = append(arrays, parseMdArray(strings.TrimSpace(name), strings.Fields(rest)))
+			array = &arrays[len(arrays)-1]
+			continue
+		}
+		if array == nil {
+			continue
+		}
+		if m := mdCountsRe.FindStringSubmatch(line); m != nil && array.Total == 0 {
+			array.Total, _ = strconv.Atoi(m[1])
+			array.Active, _ = strconv.Atoi(m[2])
+			array.Degraded = array.Active < array.Total
+		}
+		if m := mdSyncRe.FindStringSubmatch(line); m != nil {
+			array.Sync = m[1]
+			array.SyncPct, _ = strconv.ParseFloat(m[2], 64)
+			array.SyncFinish = m[3]
+		} else if strings.Contains(line, "=DELAYED") || strings.Contains(line, "=PENDING") {
+			action, _, _ := strings.Cut(strings.TrimSpace(line), "=")
+			array.Sync = action
+		}
+	}
+	return arrays
+}
+
+// parseMdArray parses the state, level and member devices of an array
+func parseMdArray(name string, fields []string) system.RaidArray {
+	array := system.RaidArray{Name: name}
+	if len(fields) == 0 {
+		return array
+	}
+	array.State = fields[0]
+	for _, field := range fields[1:] {
+		switch {
+		case strings.HasPrefix(field, "("):
+			// (auto-read-only) or (read-only)
+			array.State += " " + field
+		case strings.HasPrefix(field, "raid") || field == "linear" || field == "multipath":
+			array.Level = field
+		default:
+			m := mdDeviceRe.FindStringSubmatch(field)
+			if m == nil {
+				continue
+			}
+			switch m[2] {
+			case "F":
+				array.Failed = append(array.Failed, m[1])
+			case "S":
+				array.Spare = append(array.Spare, m[1])
+			}
+		}
+	}
+	return array
+}
@@ -0,0 +1,61 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mdstatFixture = `Personalities : [raid1] [raid6] [raid5] [raid4]
+md1 : active raid1 sdb1[1] sda1[0](F)
+      976630464 blocks super 1.2 [2/1] [_U]
+      [===>.................]  recovery = 17.3% (169004032/976630464) finish=64.4min speed=208830K/sec
+      bitmap: 2/8 pages [8KB], 65536KB chunk
+
+md0 : active raid5 sde[3](S) sdd[2] sdc[1] sdb[0]
+      1953259520 blocks super 1.2 level 5, 512k chunk, algorithm 2 [3/3] [UUU]
+      	resync=DELAYED
+
+md127 : inactive sdf[0](S)
+      976630464 blocks super 1.2
+
+unused devices: <none>
+`
+
+func TestParseMdstat(t *testing.T) {
+	arrays := parseMdstat(mdstatFixture)
+	require.Len(t, arrays, 3)
+
+	assert.Equal(t, system.RaidArray{
+		Name:       "md1",
+		Level:      "raid1",
+		State:      "active",
+		Total:      2,
+		Active:     1,
+		Degraded:   true,
+		Failed:     []string{"sda1"},
+		Sync:       "recovery",
+		SyncPct:    17.3,
+		SyncFinish: "64.4min",
+	}, arrays[0])
+
+	assert.Equal(t, system.RaidArray{
+		Name:   "md0",
+		Level:  "raid5",
+		State:  "active",
+		Total:  3,
+		Active: 3,
+		Spare:  []string{"sde"},
+		Sync:   "resync",
+	}, arrays[1], "Expected counts from the first match and a delayed resync")
+
+	assert.Equal(t, "md127", arrays[2].Name)
+	assert.Equal(t, "inactive", arrays[2].State)
+	assert.Empty(t, arrays[2].Level)
+	assert.False(t, arrays[2].Degraded)
+}
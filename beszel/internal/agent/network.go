@@ -9,8 +9,8 @@ import (
 )
 
 func (a *Agent) initializeNetIoStats() {
-	// reset valid network interfaces
-	a.netInterfaces = make(map[string]struct{}, 0)
+	// valid network interfaces, published once complete since other collectors read them
+	interfaces := make(map[string]struct{}, 0)
 
 	// map of network interface names passed in via NICS env var
 	var nicsMap map[string]struct{}
@@ -46,9 +46,21 @@ func (a *Agent) initializeNetIoStats() {
 			a.netIoStats.BytesSent += v.BytesSent
 			a.netIoStats.BytesRecv += v.BytesRecv
 			// store as a valid network interface
-			a.netInterfaces[v.Name] = struct{}{}
+			interfaces[v.Name] = struct{}{}
 		}
 	}
+	a.netLock.Lock()
+	a.netInterfaces = interfaces
+	a.netLock.Unlock()
+	a.sampler.setNetInterfaces(interfaces)
+}
+
+// getNetInterfaces returns the valid network interfaces. The map is replaced
+// rather than modified, so it can be read after the lock is released.
+func (a *Agent) getNetInterfaces() map[string]struct{} {
+	a.netLock.Lock()
+	defer a.netLock.Unlock()
+	return a.netInterfaces
 }
 
 func (a *Agent) skipNetworkInterface(v psutilNet.IOCountersStat) bool {
@@ -0,0 +1,211 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Default port of the Network UPS Tools server
+	defaultNutPort = "3493"
+	// Time to connect to and read from upsd
+	nutTimeout = 2 * time.Second
+)
+
+// powerReader reads batteries and AC adapters from the sysfs power_supply class
+// and UPS status from Network UPS Tools servers.
+//
+// NUT_UPS is a comma separated list of UPS names in upsc format: ups@host:port,
+// where host defaults to localhost and port to 3493.
+type powerReader struct {
+	path string   // Path of the power_supply class directory
+	ups  []nutUps // UPS devices to query
+}
+
+// nutUps is a UPS on a NUT server
+type nutUps struct {
+	name string
+	addr string // host:port of upsd
+}
+
+// newPowerReader creates a powerReader if the system has power supplies or NUT_UPS is set
+func newPowerReader() *powerReader {
+	pr := &powerReader{path: "/sys/class/power_supply"}
+	value, _ := GetEnv("NUT_UPS")
+	for target := range strings.SplitSeq(value, ",") {
+		if target = strings.TrimSpace(target); target != "" {
+			pr.ups = append(pr.ups, parseNutTarget(target))
+		}
+	}
+	if len(pr.ups) > 0 {
+		slog.Info("NUT_UPS", "ups", value)
+	}
+	if supplies, _ := filepath.Glob(filepath.Join(pr.path, "*")); len(supplies) == 0 && len(pr.ups) == 0 {
+		return nil
+	}
+	return pr
+}
+
+// parseNutTarget parses a UPS in upsc format: ups[@host[:port]]
+func parseNutTarget(target string) nutUps {
+	name, host, _ := strings.Cut(target, "@")
+	if host == "" {
+		host = "localhost"
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), defaultNutPort)
+	}
+	return nutUps{name: name, addr: host}
+}
+
+// collectPower gets the state of batteries, AC adapters and UPS devices
+func (a *Agent) collectPower(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	pr := a.power
+	status, err := pr.readPowerSupplies()
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range pr.ups {
+		ups, err := queryNut(ctx, u)
+		if err != nil {
+			slog.Debug("NUT", "ups", u.name, "err", err)
+			ups.Error = err.Error()
+		}
+		status.UPS = append(status.UPS, ups)
+	}
+	if status.AC == nil && len(status.Batteries) == 0 && len(status.UPS) == 0 {
+		return nil, nil
+	}
+	return func(data *system.CombinedData) {
+		data.Power = status
+	}, nil
+}
+
+// readPowerSupplies reads batteries and AC adapters from sysfs. AC is set if the
+// system has any mains or USB power supply, and true if one of them is online.
+func (pr *powerReader) readPowerSupplies() (*system.PowerStatus, error) {
+	supplies, err := filepath.Glob(filepath.Join(pr.path, "*"))
+	if err != nil {
+		return nil, err
+	}
+	status := &system.PowerStatus{}
+	for _, supply := range supplies {
+		switch readSysfsString(supply, "type") {
+		case "Mains", "USB":
+			if status.AC == nil {
+				status.AC = new(bool)
+			}
+			*status.AC = *status.AC || readSysfsString(supply, "online") == "1"
+		case "Battery":
+			// peripherals such as mice report batteries with scope Device
+			if readSysfsString(supply, "scope") == "Device" {
+				continue
+			}
+			capacity, _ := readSysfsFloat(filepath.Join(supply, "capacity"))
+			battery := system.Battery{
+				Name:     filepath.Base(supply),
+				Capacity: capacity,
+				Status:   readSysfsString(supply, "status"),
+			}
+			if battery.Status == "Discharging" {
+				battery.Runtime = batteryRuntime(supply)
+			}
+			status.Batteries = append(status.Batteries, battery)
+		}
+	}
+	return status, nil
+}
+
+// batteryRuntime estimates the seconds left on a discharging battery from its
+// energy and power, or charge and current. Returns 0 if unknown.
+func batteryRuntime(supply string) int64 {
+	for _, pair := range [][2]string{{"energy_now", "power_now"}, {"charge_now", "current_now"}} {
+		remaining, ok1 := readSysfsFloat(filepath.Join(supply, pair[0]))
+		rate, ok2 := readSysfsFloat(filepath.Join(supply, pair[1]))
+		if ok1 && ok2 && rate > 0 {
+			return int64(remaining / rate * 3600)
+		}
+	}
+	return 0
+}
+
+// queryNut reads the variables of a UPS from upsd using the NUT network protocol
+func queryNut(ctx context.Context, u nutUps) (system.UPS, error) {
+	ups := system.UPS{Name: u.name}
+	ctx, cancel := context.WithTimeout(ctx, nutTimeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", u.addr)
+	if err != nil {
+		return ups, err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	if _, err := fmt.Fprintf(conn, "LIST VAR %s\n", u.name); err != nil {
+		return ups, err
+	}
+	vars, err := parseNutVars(bufio.NewScanner(conn), u.name)
+	fmt.Fprint(conn, "LOGOUT\n")
+	if err != nil {
+		return ups, err
+	}
+	setUpsVars(&ups, vars)
+	return ups, nil
+}
+
+// parseNutVars parses the response to LIST VAR, which is a list of lines like
+// VAR <ups> <name> "<value>" between BEGIN and END lines
+func parseNutVars(scanner *bufio.Scanner, name string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "ERR "):
+			return nil, fmt.Errorf("upsd: %s", strings.ToLower(strings.TrimPrefix(line, "ERR ")))
+		case strings.HasPrefix(line, "END LIST VAR"):
+			return vars, nil
+		case strings.HasPrefix(line, "VAR "+name+" "):
+			key, value, ok := strings.Cut(strings.TrimPrefix(line, "VAR "+name+" "), " ")
+			if !ok {
+				continue
+			}
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			vars[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("upsd: incomplete response")
+}
+
+// setUpsVars sets the fields of a UPS from its NUT variables
+func setUpsVars(ups *system.UPS, vars map[string]string) {
+	ups.Status = vars["ups.status"]
+	for flag := range strings.FieldsSeq(ups.Status) {
+		switch flag {
+		case "OB":
+			ups.OnBattery = true
+		case "LB":
+			ups.LowBattery = true
+		}
+	}
+	ups.Load, _ = strconv.ParseFloat(vars["ups.load"], 64)
+	ups.Charge, _ = strconv.ParseFloat(vars["battery.charge"], 64)
+	if runtime, err := strconv.ParseFloat(vars["battery.runtime"], 64); err == nil {
+		ups.Runtime = int64(runtime)
+	}
+	ups.Model = strings.TrimSpace(vars["device.mfr"] + " " + vars["device.model"])
+}
@@ -0,0 +1,119 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveNut answers LIST VAR requests for the UPS named ups like upsd
+func serveNut(t *testing.T, vars map[string]string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			scanner := bufio.NewScanner(conn)
+			scanner.Scan()
+			var ups string
+			if _, err := fmt.Sscanf(scanner.Text(), "LIST VAR %s", &ups); err != nil || ups != "ups" {
+				fmt.Fprint(conn, "ERR UNKNOWN-UPS\n")
+				conn.Close()
+				continue
+			}
+			fmt.Fprintf(conn, "BEGIN LIST VAR %s\n", ups)
+			for key, value := range vars {
+				fmt.Fprintf(conn, "VAR %s %s %q\n", ups, key, value)
+			}
+			fmt.Fprintf(conn, "END LIST VAR %s\n", ups)
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestParseNutTarget(t *testing.T) {
+	assert.Equal(t, nutUps{name: "ups", addr: "localhost:3493"}, parseNutTarget("ups"))
+	assert.Equal(t, nutUps{name: "ups", addr: "nas:3493"}, parseNutTarget("ups@nas"))
+	assert.Equal(t, nutUps{name: "ups", addr: "nas:3000"}, parseNutTarget("ups@nas:3000"))
+	assert.Equal(t, nutUps{name: "ups", addr: "[::1]:3493"}, parseNutTarget("ups@[::1]"))
+}
+
+func TestCollectPower(t *testing.T) {
+	path := t.TempDir()
+	writeSysfs(t, path, map[string]string{
+		"AC/type":            "Mains",
+		"AC/online":          "0",
+		"BAT0/type":          "Battery",
+		"BAT0/status":        "Discharging",
+		"BAT0/capacity":      "80",
+		"BAT0/energy_now":    "40000000",
+		"BAT0/power_now":     "10000000",
+		"hid-mouse/type":     "Battery",
+		"hid-mouse/scope":    "Device",
+		"hid-mouse/capacity": "50",
+		"ucsi-source/type":   "USB",
+		"ucsi-source/online": "0",
+		"BAT1/type":          "Battery",
+		"BAT1/status":        "Full",
+		"BAT1/capacity":      "100",
+		"BAT1/charge_now":    "3000000",
+		"BAT1/current_now":   "0",
+	})
+	addr := serveNut(t, map[string]string{
+		"ups.status":      "OB LB",
+		"ups.load":        "35",
+		"battery.charge":  "20",
+		"battery.runtime": "300",
+		"device.mfr":      "APC",
+		"device.model":    "Back-UPS 700",
+	})
+	a := &Agent{power: &powerReader{path: path, ups: []nutUps{
+		{name: "ups", addr: addr},
+		{name: "missing", addr: addr},
+	}}}
+
+	apply, err := a.collectPower(context.Background(), &hubState{})
+	require.NoError(t, err)
+	data := &system.CombinedData{}
+	apply(data)
+	require.NotNil(t, data.Power)
+	require.NotNil(t, data.Power.AC)
+	assert.False(t, *data.Power.AC)
+	assert.Equal(t, []system.Battery{
+		{Name: "BAT0", Capacity: 80, Status: "Discharging", Runtime: 4 * 3600},
+		{Name: "BAT1", Capacity: 100, Status: "Full"},
+	}, data.Power.Batteries, "Expected device batteries to be skipped")
+	require.Len(t, data.Power.UPS, 2)
+	assert.Equal(t, system.UPS{
+		Name:       "ups",
+		Model:      "APC Back-UPS 700",
+		Status:     "OB LB",
+		OnBattery:  true,
+		LowBattery: true,
+		Load:       35,
+		Charge:     20,
+		Runtime:    300,
+	}, data.Power.UPS[0])
+	assert.NotEmpty(t, data.Power.UPS[1].Error, "Expected an error for a UPS the server does not have")
+}
+
+func TestCollectPowerNone(t *testing.T) {
+	a := &Agent{power: &powerReader{path: t.TempDir()}}
+	apply, err := a.collectPower(context.Background(), &hubState{})
+	require.NoError(t, err)
+	assert.Nil(t, apply)
+}
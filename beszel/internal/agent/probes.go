@@ -0,0 +1,278 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Default time between runs of each probe
+	defaultProbeInterval = 30 * time.Second
+	// Default time a probe may take before it fails
+	defaultProbeTimeout = 10 * time.Second
+	// Max size of an HTTP response body checked against the body pattern
+	maxProbeBodyBytes = 1 << 20
+)
+
+// ProbeConfig is a single probe in the PROBES_FILE yaml file
+type ProbeConfig struct {
+	Name       string        `yaml:"name"`
+	Type       string        `yaml:"type"`                  // http, tcp or dns
+	Target     string        `yaml:"target"`                // URL, host:port or hostname
+	Interval   time.Duration `yaml:"interval,omitempty"`    // Time between runs
+	Timeout    time.Duration `yaml:"timeout,omitempty"`     // Time before the probe fails
+	MaxLatency time.Duration `yaml:"max_latency,omitempty"` // Fail if slower than this
+	Status     int           `yaml:"status,omitempty"`      // http: expected status code (default any 2xx)
+	Body       string        `yaml:"body,omitempty"`        // http: regular expression the body must match
+	Insecure   bool          `yaml:"insecure,omitempty"`    // http: skip TLS certificate verification
+	Server     string        `yaml:"server,omitempty"`      // dns: server to query (host:port, default system resolver)
+	Expect     string        `yaml:"expect,omitempty"`      // dns: address which must be in the results
+}
+
+// probe is a configured probe and its latest result
+type probe struct {
+	sync.Mutex
+	ProbeConfig
+	body    *regexp.Regexp
+	latency time.Duration // Latency of the last run
+	err     error         // Error from the last run, nil if it succeeded
+	updated time.Time     // Time of the last run
+}
+
+// loadProbes reads probes from the yaml file in the PROBES_FILE env var.
+// Invalid probes are logged and skipped.
+func loadProbes() []*probe {
+	path, _ := GetEnv("PROBES_FILE")
+	if path == "" {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error("Error reading PROBES_FILE", "err", err)
+		return nil
+	}
+	probes, err := parseProbes(content)
+	if err != nil {
+		slog.Error("Invalid PROBES_FILE", "err", err)
+	}
+	slog.Info("PROBES_FILE", "path", path, "probes", len(probes))
+	return probes
+}
+
+// parseProbes parses a yaml list of probes. Returns the valid probes along
+// with an error for any that are invalid.
+func parseProbes(content []byte) ([]*probe, error) {
+	var configs []ProbeConfig
+	if err := yaml.Unmarshal(content, &configs); err != nil {
+		return nil, err
+	}
+	var probes []*probe
+	var errs []error
+	names := make(map[string]struct{}, len(configs))
+	for i, config := range configs {
+		p, err := newProbe(config)
+		if err == nil {
+			if _, exists := names[p.Name]; exists {
+				err = errors.New("duplicate name")
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("probe %d (%s): %w", i+1, config.Name, err))
+			continue
+		}
+		names[p.Name] = struct{}{}
+		probes = append(probes, p)
+	}
+	return probes, errors.Join(errs...)
+}
+
+// newProbe validates a probe config and sets defaults
+func newProbe(config ProbeConfig) (*probe, error) {
+	if config.Target == "" {
+		return nil, errors.New("target is required")
+	}
+	if config.Name == "" {
+		config.Name = config.Target
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaultProbeInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = min(defaultProbeTimeout, config.Interval)
+	}
+	p := &probe{ProbeConfig: config}
+	switch config.Type {
+	case "http":
+		if config.Body != "" {
+			re, err := regexp.Compile(config.Body)
+			if err != nil {
+				return nil, fmt.Errorf("invalid body: %w", err)
+			}
+			p.body = re
+		}
+	case "tcp":
+		if _, _, err := net.SplitHostPort(config.Target); err != nil {
+			return nil, err
+		}
+	case "dns":
+	default:
+		return nil, fmt.Errorf("unknown type %q", config.Type)
+	}
+	return p, nil
+}
+
+// startProbes runs each probe on its interval
+func startProbes(probes []*probe) {
+	for _, p := range probes {
+		go func() {
+			for {
+				p.run()
+				time.Sleep(p.Interval)
+			}
+		}()
+	}
+}
+
+// run executes the probe once and stores the result
+func (p *probe) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	switch p.Type {
+	case "http":
+		err = p.checkHTTP(ctx)
+	case "tcp":
+		err = p.checkTCP(ctx)
+	case "dns":
+		err = p.checkDNS(ctx)
+	}
+	latency := time.Since(start)
+	if err == nil && p.MaxLatency > 0 && latency > p.MaxLatency {
+		err = fmt.Errorf("latency %s exceeds %s", latency.Round(time.Millisecond), p.MaxLatency)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", p.Timeout)
+		}
+		slog.Debug("Probe failed", "name", p.Name, "err", err)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	p.latency = latency
+	p.err = err
+	p.updated = time.Now()
+}
+
+// checkHTTP makes a GET request and checks the status and body
+func (p *probe) checkHTTP(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Target, nil)
+	if err != nil {
+		return err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableKeepAlives = true
+	if p.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if p.Status != 0 && resp.StatusCode != p.Status {
+		return fmt.Errorf("status %d, expected %d", resp.StatusCode, p.Status)
+	}
+	if p.Status == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if p.body != nil {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBodyBytes))
+		if err != nil {
+			return err
+		}
+		if !p.body.Match(body) {
+			return fmt.Errorf("body does not match %q", p.Body)
+		}
+	}
+	return nil
+}
+
+// checkTCP connects to the target
+func (p *probe) checkTCP(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", p.Target)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// checkDNS resolves the target and checks for the expected address
+func (p *probe) checkDNS(ctx context.Context) error {
+	resolver := net.DefaultResolver
+	if p.Server != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, p.Server)
+			},
+		}
+	}
+	addrs, err := resolver.LookupHost(ctx, p.Target)
+	if err != nil {
+		return err
+	}
+	if p.Expect != "" && !slices.Contains(addrs, p.Expect) {
+		return fmt.Errorf("%s not in results %v", p.Expect, addrs)
+	}
+	return nil
+}
+
+// collectProbes gets the latest result of each probe.
+// Probes not run within three intervals are left out.
+func (a *Agent) collectProbes(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	now := time.Now()
+	probes := make(map[string]system.ProbeStats, len(a.probes))
+	probeErrors := make(map[string]string)
+	for _, p := range a.probes {
+		p.Lock()
+		if now.Sub(p.updated) < 3*p.Interval {
+			stats := system.ProbeStats{Latency: twoDecimals(float64(p.latency.Microseconds()) / 1000)}
+			if p.err != nil {
+				probeErrors[p.Name] = p.err.Error()
+			} else {
+				stats.Success = 100
+			}
+			probes[p.Name] = stats
+		}
+		p.Unlock()
+	}
+	return func(data *system.CombinedData) {
+		if len(probes) > 0 {
+			data.Stats.Probes = probes
+		}
+		// errors are kept on the system rather than in every stats record
+		if len(probeErrors) > 0 {
+			data.Info.ProbeErrors = probeErrors
+		}
+	}, nil
+}
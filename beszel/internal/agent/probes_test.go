@@ -0,0 +1,156 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProbes(t *testing.T) {
+	content := []byte(`
+- name: api
+  type: http
+  target: https://api.internal/health
+  status: 204
+  body: "ok|healthy"
+  max_latency: 500ms
+  interval: 1m
+- type: tcp
+  target: db.internal:5432
+- name: resolver
+  type: dns
+  target: api.internal
+  server: 10.0.0.53:53
+  expect: 10.0.0.10
+- name: missing port
+  type: tcp
+  target: db.internal
+- name: bad type
+  type: icmp
+  target: 10.0.0.1
+- name: api
+  type: tcp
+  target: api.internal:443
+`)
+	probes, err := parseProbes(content)
+	require.Len(t, probes, 3)
+	assert.ErrorContains(t, err, "probe 4 (missing port)")
+	assert.ErrorContains(t, err, `unknown type "icmp"`)
+	assert.ErrorContains(t, err, "probe 6 (api): duplicate name")
+
+	assert.Equal(t, "api", probes[0].Name)
+	assert.Equal(t, 204, probes[0].Status)
+	assert.Equal(t, 500*time.Millisecond, probes[0].MaxLatency)
+	assert.Equal(t, time.Minute, probes[0].Interval)
+	assert.Equal(t, defaultProbeTimeout, probes[0].Timeout)
+	assert.True(t, probes[0].body.MatchString("healthy"))
+
+	assert.Equal(t, "db.internal:5432", probes[1].Name, "Name should default to target")
+	assert.Equal(t, defaultProbeInterval, probes[1].Interval)
+
+	assert.Equal(t, "10.0.0.53:53", probes[2].Server)
+
+	_, err = parseProbes([]byte("not: a list"))
+	assert.Error(t, err)
+}
+
+func TestProbeHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			fmt.Fprint(w, `{"status":"healthy"}`)
+		case "/slow":
+			time.Sleep(50 * time.Millisecond)
+		default:
+			http.Error(w, "down", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		config ProbeConfig
+		err    string
+	}{
+		{name: "ok", config: ProbeConfig{Target: server.URL + "/health"}},
+		{name: "body match", config: ProbeConfig{Target: server.URL + "/health", Body: `"status":"healthy"`}},
+		{name: "body mismatch", config: ProbeConfig{Target: server.URL + "/health", Body: "degraded"}, err: "body does not match"},
+		{name: "bad status", config: ProbeConfig{Target: server.URL + "/down"}, err: "status 503"},
+		{name: "expected status", config: ProbeConfig{Target: server.URL + "/down", Status: 503}},
+		{name: "unexpected status", config: ProbeConfig{Target: server.URL + "/health", Status: 204}, err: "status 200, expected 204"},
+		{name: "max latency", config: ProbeConfig{Target: server.URL + "/slow", MaxLatency: time.Millisecond}, err: "exceeds 1ms"},
+		{name: "timeout", config: ProbeConfig{Target: server.URL + "/slow", Timeout: 10 * time.Millisecond}, err: "timed out after 10ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Type = "http"
+			p, err := newProbe(tt.config)
+			require.NoError(t, err)
+			p.run()
+			if tt.err == "" {
+				assert.NoError(t, p.err)
+			} else {
+				assert.ErrorContains(t, p.err, tt.err)
+			}
+			assert.False(t, p.updated.IsZero())
+		})
+	}
+}
+
+func TestProbeTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+
+	p, err := newProbe(ProbeConfig{Type: "tcp", Target: addr})
+	require.NoError(t, err)
+	p.run()
+	assert.NoError(t, p.err)
+
+	listener.Close()
+	p.run()
+	assert.Error(t, p.err)
+}
+
+func TestProbeDNS(t *testing.T) {
+	p, err := newProbe(ProbeConfig{Type: "dns", Target: "localhost", Expect: "127.0.0.1"})
+	require.NoError(t, err)
+	p.run()
+	assert.NoError(t, p.err)
+
+	p.Expect = "192.0.2.1"
+	p.run()
+	assert.ErrorContains(t, p.err, "192.0.2.1 not in results")
+}
+
+func TestCollectProbes(t *testing.T) {
+	ok := &probe{ProbeConfig: ProbeConfig{Name: "ok", Interval: time.Minute}}
+	ok.latency, ok.updated = 12345*time.Microsecond, time.Now()
+	failed := &probe{ProbeConfig: ProbeConfig{Name: "failed", Interval: time.Minute}}
+	failed.err, failed.updated = fmt.Errorf("connection refused"), time.Now()
+	stale := &probe{ProbeConfig: ProbeConfig{Name: "stale", Interval: time.Minute}}
+	stale.updated = time.Now().Add(-4 * time.Minute)
+
+	a := &Agent{probes: []*probe{ok, failed, stale}}
+	apply, err := a.collectProbes(context.Background(), &hubState{})
+	require.NoError(t, err)
+	data := &system.CombinedData{}
+	apply(data)
+	assert.Equal(t, map[string]system.ProbeStats{
+		"ok":     {Latency: 12.35, Success: 100},
+		"failed": {},
+	}, data.Stats.Probes)
+	assert.Equal(t, map[string]string{"failed": "connection refused"}, data.Info.ProbeErrors,
+		"Expected errors on the system info rather than in stats records")
+}
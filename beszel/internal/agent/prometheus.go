@@ -0,0 +1,173 @@
+package agent
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// promSample is a single series from the Prometheus text exposition format
+type promSample struct {
+	name       string // Metric name
+	series     string // Metric name with labels, used as the custom metric key
+	metricType string // gauge, counter, untyped, histogram or summary
+	value      float64
+}
+
+// parsePromText parses the Prometheus text exposition format.
+// Lines that can't be parsed are skipped and returned in the error.
+func parsePromText(r io.Reader) ([]promSample, error) {
+	types := make(map[string]string)
+	var samples []promSample
+	var invalid []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			// # TYPE name type
+			if fields := strings.Fields(line); len(fields) == 4 && fields[1] == "TYPE" {
+				types[fields[2]] = fields[3]
+			}
+			continue
+		}
+		sample, err := parsePromLine(line)
+		if err != nil {
+			invalid = append(invalid, line)
+			continue
+		}
+		sample.metricType = promMetricType(types, sample.name)
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return samples, err
+	}
+	if len(invalid) > 0 {
+		return samples, fmt.Errorf("invalid lines: %q", invalid)
+	}
+	return samples, nil
+}
+
+// promMetricType returns the declared type of a metric, including the
+// _bucket, _sum and _count series of histograms and summaries
+func promMetricType(types map[string]string, name string) string {
+	if t, ok := types[name]; ok {
+		return t
+	}
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if base, ok := strings.CutSuffix(name, suffix); ok {
+			if t, ok := types[base]; ok && (t == "histogram" || t == "summary") {
+				return t
+			}
+		}
+	}
+	return "untyped"
+}
+
+// parsePromLine parses a sample line: name{label="value",...} value [timestamp]
+func parsePromLine(line string) (promSample, error) {
+	var sample promSample
+	var labels []string
+	rest := line
+
+	nameEnd := strings.IndexAny(rest, "{ \t")
+	if nameEnd <= 0 {
+		return sample, fmt.Errorf("no value")
+	}
+	sample.name = rest[:nameEnd]
+	rest = rest[nameEnd:]
+
+	if rest[0] == '{' {
+		var err error
+		labels, rest, err = parsePromLabels(rest[1:])
+		if err != nil {
+			return sample, err
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return sample, fmt.Errorf("invalid value")
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample, err
+	}
+	sample.value = value
+
+	sample.series = sample.name
+	if len(labels) > 0 {
+		slices.Sort(labels)
+		sample.series += "{" + strings.Join(labels, ",") + "}"
+	}
+	return sample, nil
+}
+
+// parsePromLabels parses labels up to the closing brace. Returns the labels
+// formatted as name="value" and the remainder of the line.
+func parsePromLabels(s string) (labels []string, rest string, err error) {
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return nil, "", fmt.Errorf("unterminated labels")
+		}
+		if s[0] == '}' {
+			return labels, s[1:], nil
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || len(s) < eq+2 || s[eq+1] != '"' {
+			return nil, "", fmt.Errorf("invalid label")
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = s[eq+2:]
+
+		// find closing quote, skipping escaped characters
+		var value strings.Builder
+		closed := false
+		for i := 0; i < len(s); i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				if s[i] == 'n' {
+					value.WriteByte('\n')
+				} else {
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			if s[i] == '"' {
+				s = s[i+1:]
+				closed = true
+				break
+			}
+			value.WriteByte(s[i])
+		}
+		if !closed {
+			return nil, "", fmt.Errorf("unterminated label value")
+		}
+		labels = append(labels, name+"="+strconv.Quote(value.String()))
+	}
+}
+
+// promValue returns a sample's custom metric value, converting counters to
+// rates. ok is false if the sample should not be forwarded.
+func promValue(sample promSample, rates *counterRates, t time.Time) (value float64, ok bool) {
+	// NaN and Inf can't be encoded in the JSON payload
+	if math.IsNaN(sample.value) || math.IsInf(sample.value, 0) {
+		return 0, false
+	}
+	switch sample.metricType {
+	case "gauge", "untyped":
+		return sample.value, true
+	case "counter":
+		return rates.rate(sample.series, sample.value, t)
+	}
+	return 0, false
+}
@@ -0,0 +1,106 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePromText(t *testing.T) {
+	input := `# HELP backup_last_success_timestamp Last successful backup
+# TYPE backup_last_success_timestamp gauge
+backup_last_success_timestamp 1.7e+09
+# TYPE jobs_total counter
+jobs_total{queue="mail",status="ok"} 42 1700000000000
+jobs_total{status="failed", queue="mail"} 3
+# TYPE request_seconds histogram
+request_seconds_bucket{le="0.5"} 10
+request_seconds_sum 4.2
+request_seconds_count 12
+label_escapes{path="C:\\tmp\"x\""} 1
+untyped_metric 7
+invalid_line
+`
+	samples, err := parsePromText(strings.NewReader(input))
+	assert.ErrorContains(t, err, "invalid_line")
+	require.Len(t, samples, 8)
+
+	expected := []promSample{
+		{name: "backup_last_success_timestamp", series: "backup_last_success_timestamp", metricType: "gauge", value: 1.7e9},
+		{name: "jobs_total", series: `jobs_total{queue="mail",status="ok"}`, metricType: "counter", value: 42},
+		{name: "jobs_total", series: `jobs_total{queue="mail",status="failed"}`, metricType: "counter", value: 3},
+		{name: "request_seconds_bucket", series: `request_seconds_bucket{le="0.5"}`, metricType: "histogram", value: 10},
+		{name: "request_seconds_sum", series: "request_seconds_sum", metricType: "histogram", value: 4.2},
+		{name: "request_seconds_count", series: "request_seconds_count", metricType: "histogram", value: 12},
+		{name: "label_escapes", series: `label_escapes{path="C:\\tmp\"x\""}`, metricType: "untyped", value: 1},
+		{name: "untyped_metric", series: "untyped_metric", metricType: "untyped", value: 7},
+	}
+	assert.Equal(t, expected, samples)
+}
+
+func TestParsePromLineInvalid(t *testing.T) {
+	for _, line := range []string{
+		"no_value",
+		"bad_value abc",
+		`unterminated{a="b" 1`,
+		`bad_label{a=b} 1`,
+		"too_many 1 2 3",
+	} {
+		_, err := parsePromLine(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestCollectTextfileMetrics(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "backup.prom")
+	write := func(content string, modTime time.Time) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.txt"), []byte("ignored 1\n"), 0o644))
+
+	t.Setenv("BESZEL_AGENT_TEXTFILE_DIR", dir)
+	a := &Agent{textfiles: newTextfileMetrics()}
+	require.NotNil(t, a.textfiles)
+
+	hs := &hubState{}
+	collect := func() (map[string]float64, error) {
+		apply, err := a.collectTextfileMetrics(context.Background(), hs)
+		data := &system.CombinedData{}
+		if apply != nil {
+			apply(data)
+		}
+		return data.Stats.CustomMetrics, err
+	}
+
+	start := time.Now().Add(-time.Hour)
+	write("# TYPE backup_size_bytes gauge\nbackup_size_bytes 1024\n# TYPE backups_total counter\nbackups_total 10\n", start)
+	values, err := collect()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"backup_size_bytes": 1024}, values, "Counter has no rate until the file is updated")
+
+	write("# TYPE backup_size_bytes gauge\nbackup_size_bytes 2048\n# TYPE backups_total counter\nbackups_total 40\n", start.Add(time.Minute))
+	values, err = collect()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"backup_size_bytes": 2048, "backups_total": 0.5}, values)
+
+	// unchanged file keeps the counter rate
+	values, err = collect()
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, values["backups_total"])
+
+	require.NoError(t, os.Remove(path))
+	_, err = collect()
+	assert.ErrorContains(t, err, "no .prom files")
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// raplReader reads package and DRAM energy counters from the powercap RAPL
+// interface and converts them to watts. Only available on Linux, and the
+// counters are only readable by root on recent kernels.
+type raplReader struct {
+	zones []*raplZone
+}
+
+// raplZone is a RAPL domain with an energy counter which wraps at maxRange
+type raplZone struct {
+	key      string  // Domain name, e.g. package-0, dram-0 or psys
+	path     string  // Path of the energy_uj counter
+	maxRange float64 // Value the counter wraps at in microjoules
+	last     float64 // Last counter value
+	offset   float64 // Sum of wraps added to the counter
+}
+
+// newRaplReader creates a raplReader if the system has readable RAPL domains
+func newRaplReader() *raplReader {
+	zones := findRaplZones("/sys/class/powercap")
+	if len(zones) == 0 {
+		return nil
+	}
+	if _, err := os.ReadFile(zones[0].path); err != nil {
+		slog.Debug("RAPL", "err", err)
+		return nil
+	}
+	return &raplReader{zones: zones}
+}
+
+// findRaplZones finds the package, psys and DRAM domains in the powercap directory.
+// Core and uncore domains are skipped since they are part of the package domain.
+func findRaplZones(path string) []*raplZone {
+	dirs, _ := filepath.Glob(filepath.Join(path, "intel-rapl:*"))
+	var zones []*raplZone
+	for _, dir := range dirs {
+		name := readSysfsString(dir, "name")
+		// subdomains are named intel-rapl:<package>:<index>
+		parts := strings.Split(filepath.Base(dir), ":")
+		switch {
+		case len(parts) == 2 && (strings.HasPrefix(name, "package") || name == "psys"):
+		case len(parts) == 3 && name == "dram":
+			name += "-" + parts[1]
+		default:
+			continue
+		}
+		maxRange, _ := readSysfsFloat(filepath.Join(dir, "max_energy_range_uj"))
+		zones = append(zones, &raplZone{key: name, path: filepath.Join(dir, "energy_uj"), maxRange: maxRange})
+	}
+	return zones
+}
+
+// collectRapl gets the power of each RAPL domain in watts
+func (a *Agent) collectRapl(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	rr := a.rapl
+	now := time.Now()
+	watts := make(map[string]float64, len(rr.zones))
+	for _, z := range rr.zones {
+		value, ok := readSysfsFloat(z.path)
+		if !ok {
+			continue
+		}
+		if value < z.last {
+			z.offset += z.maxRange
+		}
+		z.last = value
+		if rate, ok := hs.raplRates.rate(z.key, value+z.offset, now); ok {
+			// microjoules per second to watts
+			watts[z.key] = twoDecimals(rate / 1e6)
+		}
+	}
+	return func(data *system.CombinedData) {
+		if len(watts) > 0 {
+			data.Stats.Rapl = watts
+		}
+	}, nil
+}
@@ -0,0 +1,55 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectRapl(t *testing.T) {
+	path := t.TempDir()
+	writeSysfs(t, path, map[string]string{
+		"intel-rapl:0/name":                     "package-0",
+		"intel-rapl:0/energy_uj":                "900",
+		"intel-rapl:0/max_energy_range_uj":      "1000",
+		"intel-rapl:0:0/name":                   "core",
+		"intel-rapl:0:0/energy_uj":              "500",
+		"intel-rapl:0:1/name":                   "dram",
+		"intel-rapl:0:1/energy_uj":              "100",
+		"intel-rapl:0:1/max_energy_range_uj":    "1000",
+		"intel-rapl-mmio:0/name":                "package-0",
+		"intel-rapl-mmio:0/energy_uj":           "900",
+		"intel-rapl-mmio:0/max_energy_range_uj": "1000",
+	})
+	zones := findRaplZones(path)
+	require.Len(t, zones, 2, "Expected core and mmio domains to be skipped")
+	assert.Equal(t, "package-0", zones[0].key)
+	assert.Equal(t, "dram-0", zones[1].key)
+	a := &Agent{rapl: &raplReader{zones: zones}}
+
+	hs := &hubState{}
+	apply, err := a.collectRapl(context.Background(), hs)
+	require.NoError(t, err)
+	data := &system.CombinedData{}
+	apply(data)
+	assert.Nil(t, data.Stats.Rapl, "Expected no power from the first sample")
+
+	writeSysfs(t, path, map[string]string{
+		"intel-rapl:0/energy_uj":   "100",
+		"intel-rapl:0:1/energy_uj": "300",
+	})
+	apply, err = a.collectRapl(context.Background(), hs)
+	require.NoError(t, err)
+	apply(data)
+	assert.Len(t, data.Stats.Rapl, 2)
+	assert.Equal(t, 1100.0, hs.raplRates.prev["package-0"].value, "Expected the wrapped counter to continue from its range")
+	assert.Equal(t, 300.0, hs.raplRates.prev["dram-0"].value)
+	assert.Equal(t, filepath.Join(path, "intel-rapl:0:1", "energy_uj"), zones[1].path)
+}
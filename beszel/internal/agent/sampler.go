@@ -0,0 +1,223 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	psutilNet "github.com/shirou/gopsutil/v4/net"
+)
+
+const (
+	// Default time between high resolution samples
+	defaultSampleInterval = 5 * time.Second
+	// Samples older than this are discarded
+	maxSampleAge = 10 * time.Minute
+)
+
+// statsSampler takes high resolution samples of CPU, network and disk I/O between
+// hub requests so that short bursts are visible as max / p95 in the 1m record.
+type statsSampler struct {
+	sync.Mutex
+	interval      time.Duration       // Time between samples
+	netInterfaces map[string]struct{} // Network interfaces to sum
+	diskDevice    string              // Root I/O device
+	prev          rawSample           // Previous counter values
+	samples       []sample            // Samples within maxSampleAge, oldest first
+}
+
+// rawSample holds cumulative counter values at a point in time
+type rawSample struct {
+	time      time.Time
+	cpuBusy   float64
+	cpuTotal  float64
+	netSent   uint64
+	netRecv   uint64
+	diskRead  uint64
+	diskWrite uint64
+	hasDisk   bool
+}
+
+// sample holds rates calculated between two raw samples
+type sample struct {
+	time      time.Time
+	cpu       float64 // percent
+	netSent   float64 // MB/s
+	netRecv   float64 // MB/s
+	diskRead  float64 // MB/s
+	diskWrite float64 // MB/s
+}
+
+// newStatsSampler creates a sampler using the SAMPLE_INTERVAL env var.
+// Returns nil if sampling is disabled.
+func newStatsSampler() *statsSampler {
+	interval := getEnvDuration("SAMPLE_INTERVAL", defaultSampleInterval)
+	if interval <= 0 {
+		return nil
+	}
+	return &statsSampler{interval: interval}
+}
+
+// start takes samples on an interval. Blocks forever.
+func (s *statsSampler) start() {
+	for {
+		s.takeSample()
+		time.Sleep(s.interval)
+	}
+}
+
+// setNetInterfaces sets the network interfaces to be summed in each sample
+func (s *statsSampler) setNetInterfaces(interfaces map[string]struct{}) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.netInterfaces = make(map[string]struct{}, len(interfaces))
+	for name := range interfaces {
+		s.netInterfaces[name] = struct{}{}
+	}
+	// reset baseline so the next sample doesn't compare different interface sets
+	s.prev = rawSample{}
+}
+
+// setDiskDevice sets the root I/O device to sample
+func (s *statsSampler) setDiskDevice(device string) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.diskDevice = device
+	s.prev = rawSample{}
+}
+
+// takeSample reads current counters and stores the rates since the previous sample
+func (s *statsSampler) takeSample() {
+	s.Lock()
+	defer s.Unlock()
+
+	raw := rawSample{time: time.Now()}
+	if times, err := cpu.Times(false); err == nil && len(times) > 0 {
+		raw.cpuBusy, raw.cpuTotal = cpuBusyTotal(times[0])
+	}
+	if netIO, err := psutilNet.IOCounters(true); err == nil {
+		for _, v := range netIO {
+			if _, exists := s.netInterfaces[v.Name]; exists {
+				raw.netSent += v.BytesSent
+				raw.netRecv += v.BytesRecv
+			}
+		}
+	}
+	if s.diskDevice != "" {
+		if ioCounters, err := disk.IOCounters(s.diskDevice); err == nil {
+			if d, ok := ioCounters[s.diskDevice]; ok {
+				raw.diskRead = d.ReadBytes
+				raw.diskWrite = d.WriteBytes
+				raw.hasDisk = true
+			}
+		}
+	}
+
+	if !s.prev.time.IsZero() {
+		s.addSample(calculateSample(s.prev, raw))
+	}
+	s.prev = raw
+}
+
+// addSample appends a sample and discards samples older than maxSampleAge
+func (s *statsSampler) addSample(smp sample) {
+	cutoff := smp.time.Add(-maxSampleAge)
+	i := 0
+	for i < len(s.samples) && s.samples[i].time.Before(cutoff) {
+		i++
+	}
+	s.samples = append(s.samples[i:], smp)
+}
+
+// calculateSample returns the rates between two raw samples.
+// Counters that went backwards (reset / wrap) produce a zero rate.
+func calculateSample(prev, cur rawSample) sample {
+	smp := sample{time: cur.time}
+	secondsElapsed := cur.time.Sub(prev.time).Seconds()
+	if secondsElapsed <= 0 {
+		return smp
+	}
+	if totalDelta := cur.cpuTotal - prev.cpuTotal; totalDelta > 0 {
+		smp.cpu = twoDecimals(math.Max(0, math.Min(100, (cur.cpuBusy-prev.cpuBusy)/totalDelta*100)))
+	}
+	smp.netSent = counterRate(prev.netSent, cur.netSent, secondsElapsed)
+	smp.netRecv = counterRate(prev.netRecv, cur.netRecv, secondsElapsed)
+	if prev.hasDisk && cur.hasDisk {
+		smp.diskRead = counterRate(prev.diskRead, cur.diskRead, secondsElapsed)
+		smp.diskWrite = counterRate(prev.diskWrite, cur.diskWrite, secondsElapsed)
+	}
+	return smp
+}
+
+// counterRate returns the rate in MB/s between two byte counters
+func counterRate(prev, cur uint64, secondsElapsed float64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return bytesToMegabytes(float64(cur-prev) / secondsElapsed)
+}
+
+// cpuBusyTotal returns busy and total cpu time, using the same calculation as gopsutil's cpu.Percent
+func cpuBusyTotal(t cpu.TimesStat) (busy, total float64) {
+	total = t.Total()
+	busy = total - t.Idle - t.Iowait
+	return busy, total
+}
+
+// applySummary sets max and p95 values in systemStats from samples taken after since
+func (s *statsSampler) applySummary(systemStats *system.Stats, since time.Time) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	var cpuVals, sentVals, recvVals, readVals, writeVals []float64
+	for _, smp := range s.samples {
+		if !smp.time.After(since) {
+			continue
+		}
+		cpuVals = append(cpuVals, smp.cpu)
+		sentVals = append(sentVals, smp.netSent)
+		recvVals = append(recvVals, smp.netRecv)
+		readVals = append(readVals, smp.diskRead)
+		writeVals = append(writeVals, smp.diskWrite)
+	}
+	if len(cpuVals) == 0 {
+		return
+	}
+
+	// max values are never lower than the average over the full interval
+	systemStats.MaxCpu = max(systemStats.Cpu, slices.Max(cpuVals))
+	systemStats.MaxNetworkSent = max(systemStats.NetworkSent, slices.Max(sentVals))
+	systemStats.MaxNetworkRecv = max(systemStats.NetworkRecv, slices.Max(recvVals))
+	systemStats.MaxDiskReadPs = max(systemStats.DiskReadPs, slices.Max(readVals))
+	systemStats.MaxDiskWritePs = max(systemStats.DiskWritePs, slices.Max(writeVals))
+
+	systemStats.P95Cpu = percentile(cpuVals, 95)
+	systemStats.P95NetworkSent = percentile(sentVals, 95)
+	systemStats.P95NetworkRecv = percentile(recvVals, 95)
+	systemStats.P95DiskReadPs = percentile(readVals, 95)
+	systemStats.P95DiskWritePs = percentile(writeVals, 95)
+}
+
+// percentile returns the nearest-rank percentile p (0-100) of values.
+// values is sorted in place.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	slices.Sort(values)
+	rank := int(math.Ceil(p/100*float64(len(values)))) - 1
+	return values[max(0, rank)]
+}
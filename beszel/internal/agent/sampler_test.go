@@ -0,0 +1,120 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		p        float64
+		expected float64
+	}{
+		{"empty", nil, 95, 0},
+		{"single value", []float64{7}, 95, 7},
+		{"unsorted input", []float64{5, 1, 4, 2, 3}, 50, 3},
+		{"p95 of twenty values", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 100}, 95, 19},
+		{"p100 is max", []float64{3, 9, 1}, 100, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, percentile(tt.values, tt.p))
+		})
+	}
+}
+
+func TestCalculateSample(t *testing.T) {
+	start := time.Now()
+	prev := rawSample{
+		time:     start,
+		cpuBusy:  100,
+		cpuTotal: 1000,
+		netSent:  0,
+		netRecv:  10 * 1048576,
+		diskRead: 5 * 1048576,
+		hasDisk:  true,
+	}
+	cur := rawSample{
+		time:      start.Add(2 * time.Second),
+		cpuBusy:   150,
+		cpuTotal:  1100,
+		netSent:   4 * 1048576,
+		netRecv:   0, // counter reset
+		diskRead:  9 * 1048576,
+		diskWrite: 2 * 1048576,
+		hasDisk:   true,
+	}
+
+	smp := calculateSample(prev, cur)
+	assert.Equal(t, 50.0, smp.cpu)
+	assert.Equal(t, 2.0, smp.netSent)
+	assert.Equal(t, 0.0, smp.netRecv, "reset counter should produce zero rate")
+	assert.Equal(t, 2.0, smp.diskRead)
+	assert.Equal(t, 1.0, smp.diskWrite)
+
+	// no disk in previous sample
+	prev.hasDisk = false
+	smp = calculateSample(prev, cur)
+	assert.Equal(t, 0.0, smp.diskRead)
+}
+
+func TestStatsSamplerApplySummary(t *testing.T) {
+	now := time.Now()
+	s := &statsSampler{}
+	for i := range 20 {
+		s.addSample(sample{
+			time:    now.Add(time.Duration(i) * 5 * time.Second),
+			cpu:     float64(i + 1),
+			netSent: 1,
+		})
+	}
+	// one burst sample
+	s.addSample(sample{time: now.Add(100 * time.Second), cpu: 99, netSent: 50})
+
+	stats := system.Stats{Cpu: 12, NetworkSent: 3}
+	s.applySummary(&stats, time.Time{})
+	assert.Equal(t, 99.0, stats.MaxCpu)
+	assert.Equal(t, 20.0, stats.P95Cpu)
+	assert.Equal(t, 50.0, stats.MaxNetworkSent)
+	assert.Equal(t, 1.0, stats.P95NetworkSent)
+
+	// only samples after since are used
+	stats = system.Stats{Cpu: 12}
+	s.applySummary(&stats, now.Add(90*time.Second))
+	assert.Equal(t, 99.0, stats.MaxCpu)
+	assert.Equal(t, 99.0, stats.P95Cpu)
+
+	// max is never lower than the interval average
+	stats = system.Stats{Cpu: 80}
+	s.applySummary(&stats, now.Add(80*time.Second))
+	assert.Equal(t, 99.0, stats.MaxCpu)
+	stats = system.Stats{Cpu: 80}
+	s.applySummary(&stats, now.Add(-time.Hour))
+	assert.Equal(t, 99.0, stats.MaxCpu)
+
+	// no samples in window leaves stats untouched
+	stats = system.Stats{Cpu: 12}
+	s.applySummary(&stats, now.Add(time.Hour))
+	assert.Zero(t, stats.MaxCpu)
+
+	// nil sampler is a no-op
+	var nilSampler *statsSampler
+	assert.NotPanics(t, func() { nilSampler.applySummary(&stats, time.Time{}) })
+}
+
+func TestStatsSamplerDiscardsOldSamples(t *testing.T) {
+	now := time.Now()
+	s := &statsSampler{}
+	s.addSample(sample{time: now.Add(-maxSampleAge - time.Minute)})
+	s.addSample(sample{time: now.Add(-time.Minute)})
+	s.addSample(sample{time: now})
+	assert.Len(t, s.samples, 2)
+}
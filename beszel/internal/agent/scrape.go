@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// Max size of a scraped response
+	maxScrapeBytes = 10 << 20
+	// Max series forwarded from a single target, so an unfiltered exporter
+	// can't bloat every payload and stats record
+	maxScrapeSeries = 200
+)
+
+// scrapeTargets scrapes the local Prometheus endpoints in the SCRAPE_URLS env
+// var and forwards series matching SCRAPE_FILTER as custom metrics.
+type scrapeTargets struct {
+	urls   []string
+	filter *regexp.Regexp // Metric names to forward (nil forwards all)
+	client *http.Client
+}
+
+// newScrapeTargets creates scrape targets from the SCRAPE_URLS and SCRAPE_FILTER env vars.
+// Returns nil if no URLs are configured.
+//
+// SCRAPE_URLS is a comma separated list of URLs. SCRAPE_FILTER is a regular
+// expression matched against metric names.
+func newScrapeTargets() *scrapeTargets {
+	value, _ := GetEnv("SCRAPE_URLS")
+	st := &scrapeTargets{client: &http.Client{}}
+	for url := range strings.SplitSeq(value, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			st.urls = append(st.urls, url)
+		}
+	}
+	if len(st.urls) == 0 {
+		return nil
+	}
+	if filter, _ := GetEnv("SCRAPE_FILTER"); filter != "" {
+		re, err := regexp.Compile(filter)
+		if err != nil {
+			slog.Error("Invalid SCRAPE_FILTER", "err", err)
+			return nil
+		}
+		st.filter = re
+	}
+	slog.Info("SCRAPE_URLS", "urls", st.urls, "filter", st.filter)
+	return st
+}
+
+// collectScrapedMetrics scrapes all targets. Counters are converted to per second rates between the hub's scrapes.
+func (a *Agent) collectScrapedMetrics(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	st := a.scrapeTargets
+	values := make(map[string]float64)
+	var errs []error
+	for _, url := range st.urls {
+		if err := st.scrape(ctx, url, &hs.scrapeRates, values); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+		}
+	}
+	hs.scrapeRates.prune()
+
+	return func(data *system.CombinedData) {
+		addCustomMetrics(data, values)
+	}, errors.Join(errs...)
+}
+
+// scrape adds the matching series from a single target
+func (st *scrapeTargets) scrape(ctx context.Context, url string, rates *counterRates, values map[string]float64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+	resp, err := st.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	scrapeTime := time.Now()
+	samples, parseErr := parsePromText(io.LimitReader(resp.Body, maxScrapeBytes))
+	samples = slices.DeleteFunc(samples, func(sample promSample) bool {
+		if sample.metricType == "histogram" || sample.metricType == "summary" {
+			return true
+		}
+		return st.filter != nil && !st.filter.MatchString(sample.name)
+	})
+	var limitErr error
+	if len(samples) > maxScrapeSeries {
+		// keep the same series on every scrape
+		slices.SortFunc(samples, func(a, b promSample) int { return strings.Compare(a.series, b.series) })
+		samples = samples[:maxScrapeSeries]
+		limitErr = fmt.Errorf("more than %d series, set SCRAPE_FILTER to select fewer", maxScrapeSeries)
+	}
+	for _, sample := range samples {
+		if value, ok := promValue(sample, rates, scrapeTime); ok {
+			values[sample.series] = value
+		}
+	}
+	return errors.Join(limitErr, parseErr)
+}
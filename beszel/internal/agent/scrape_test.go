@@ -0,0 +1,102 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewScrapeTargets(t *testing.T) {
+	t.Setenv("BESZEL_AGENT_SCRAPE_URLS", "")
+	assert.Nil(t, newScrapeTargets())
+
+	t.Setenv("BESZEL_AGENT_SCRAPE_URLS", "http://127.0.0.1:9187/metrics, http://localhost:8080/metrics")
+	t.Setenv("BESZEL_AGENT_SCRAPE_FILTER", "^pg_")
+	st := newScrapeTargets()
+	require.NotNil(t, st)
+	assert.Equal(t, []string{"http://127.0.0.1:9187/metrics", "http://localhost:8080/metrics"}, st.urls)
+	assert.True(t, st.filter.MatchString("pg_up"))
+
+	t.Setenv("BESZEL_AGENT_SCRAPE_FILTER", "[")
+	assert.Nil(t, newScrapeTargets(), "Invalid filter should disable scraping")
+}
+
+func TestCollectScrapedMetrics(t *testing.T) {
+	body := `# TYPE pg_up gauge
+pg_up 1
+# TYPE pg_replication_lag_seconds gauge
+pg_replication_lag_seconds{replica="db2"} 0.5
+# TYPE pg_xact_commit_total counter
+pg_xact_commit_total 100
+# TYPE go_goroutines gauge
+go_goroutines 12
+# TYPE pg_query_seconds histogram
+pg_query_seconds_bucket{le="1"} 3
+pg_query_seconds_count 3
+`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	t.Setenv("BESZEL_AGENT_SCRAPE_URLS", server.URL+"/metrics,"+server.URL+"/missing")
+	t.Setenv("BESZEL_AGENT_SCRAPE_FILTER", "^pg_")
+	a := &Agent{scrapeTargets: newScrapeTargets()}
+	require.NotNil(t, a.scrapeTargets)
+
+	hs := &hubState{}
+	apply, err := a.collectScrapedMetrics(context.Background(), hs)
+	assert.ErrorContains(t, err, "/missing: unexpected status 404")
+	require.NotNil(t, apply)
+	data := &system.CombinedData{}
+	apply(data)
+	assert.Equal(t, map[string]float64{
+		"pg_up": 1,
+		`pg_replication_lag_seconds{replica="db2"}`: 0.5,
+	}, data.Stats.CustomMetrics, "Expected filtered gauges, counter without a rate and no histograms")
+
+	// counter has a rate after the second scrape
+	apply, _ = a.collectScrapedMetrics(context.Background(), hs)
+	data = &system.CombinedData{}
+	apply(data)
+	assert.Contains(t, data.Stats.CustomMetrics, "pg_xact_commit_total")
+
+	// another hub takes its own baseline
+	apply, _ = a.collectScrapedMetrics(context.Background(), &hubState{})
+	data = &system.CombinedData{}
+	apply(data)
+	assert.NotContains(t, data.Stats.CustomMetrics, "pg_xact_commit_total")
+}
+
+func TestScrapeSeriesLimit(t *testing.T) {
+	var body strings.Builder
+	for i := range maxScrapeSeries + 10 {
+		fmt.Fprintf(&body, "series_%03d %d\n", i, i)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body.String())
+	}))
+	defer server.Close()
+
+	st := &scrapeTargets{urls: []string{server.URL}, client: server.Client()}
+	values := make(map[string]float64)
+	err := st.scrape(context.Background(), server.URL, &counterRates{}, values)
+	assert.ErrorContains(t, err, "SCRAPE_FILTER")
+	assert.Len(t, values, maxScrapeSeries)
+	assert.Contains(t, values, "series_000")
+	assert.NotContains(t, values, fmt.Sprintf("series_%03d", maxScrapeSeries))
+}
@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SensorAliasConfig is a single alias in the SENSOR_ALIASES_FILE yaml file
+type SensorAliasConfig struct {
+	Match  string `yaml:"match"`            // Sensor key, may contain * wildcards
+	Device string `yaml:"device,omitempty"` // Only match sensors of this hwmon device, e.g. coretemp.1
+	Name   string `yaml:"name"`             // New name, each * is replaced with the text matched by the wildcard
+	Group  string `yaml:"group,omitempty"`  // Sensors in a group are charted separately
+}
+
+// sensorAlias is a configured alias with its compiled pattern
+type sensorAlias struct {
+	SensorAliasConfig
+	pattern *regexp.Regexp
+}
+
+// sensorAliases renames sensors to stable friendly names. Aliases are applied in
+// the agent so the history stored by the hub keeps the same names.
+type sensorAliases []sensorAlias
+
+// loadSensorAliases reads aliases from the yaml file in the SENSOR_ALIASES_FILE env var.
+// Invalid aliases are logged and skipped.
+func loadSensorAliases() sensorAliases {
+	path, _ := GetEnv("SENSOR_ALIASES_FILE")
+	if path == "" {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error("Error reading SENSOR_ALIASES_FILE", "err", err)
+		return nil
+	}
+	aliases, err := parseSensorAliases(content)
+	if err != nil {
+		slog.Error("Invalid SENSOR_ALIASES_FILE", "err", err)
+	}
+	slog.Info("SENSOR_ALIASES_FILE", "path", path, "aliases", len(aliases))
+	return aliases
+}
+
+// parseSensorAliases parses a yaml list of aliases. Returns the valid aliases along
+// with an error for any that are invalid.
+func parseSensorAliases(content []byte) (sensorAliases, error) {
+	var configs []SensorAliasConfig
+	if err := yaml.Unmarshal(content, &configs); err != nil {
+		return nil, err
+	}
+	var aliases sensorAliases
+	var errs []error
+	for i, config := range configs {
+		if config.Match == "" || config.Name == "" {
+			errs = append(errs, fmt.Errorf("alias %d (%s): match and name are required", i+1, config.Match))
+			continue
+		}
+		parts := strings.Split(config.Match, "*")
+		for j := range parts {
+			parts[j] = regexp.QuoteMeta(parts[j])
+		}
+		pattern := regexp.MustCompile("^" + strings.Join(parts, "(.*?)") + "$")
+		aliases = append(aliases, sensorAlias{SensorAliasConfig: config, pattern: pattern})
+	}
+	return aliases, errors.Join(errs...)
+}
+
+// resolve returns the name and group of a sensor from the first matching alias.
+// Returns the key unchanged if no alias matches.
+func (sa sensorAliases) resolve(key, device string) (name, group string) {
+	for _, alias := range sa {
+		if alias.Device != "" && alias.Device != device {
+			continue
+		}
+		match := alias.pattern.FindStringSubmatch(key)
+		if match == nil {
+			continue
+		}
+		name = alias.Name
+		for _, wildcard := range match[1:] {
+			if !strings.Contains(name, "*") {
+				break
+			}
+			name = strings.Replace(name, "*", wildcard, 1)
+		}
+		return name, alias.Group
+	}
+	return key, ""
+}
@@ -4,6 +4,7 @@ import (
 	"beszel/internal/entities/system"
 	"context"
 	"log/slog"
+	"os"
 	"path"
 	"strconv"
 	"strings"
@@ -14,7 +15,9 @@ import (
 
 type SensorConfig struct {
 	context        context.Context
+	sysPath        string // Root of sysfs, used to read hwmon sensors directly
 	sensors        map[string]struct{}
+	aliases        sensorAliases // Friendly names and groups from SENSOR_ALIASES_FILE
 	primarySensor  string
 	isBlacklist    bool
 	hasWildcards   bool
@@ -27,7 +30,9 @@ func (a *Agent) newSensorConfig() *SensorConfig {
 	sensorsEnvVal, sensorsSet := GetEnv("SENSORS")
 	skipCollection := sensorsSet && sensorsEnvVal == ""
 
-	return a.newSensorConfigWithEnv(primarySensor, sysSensors, sensorsEnvVal, skipCollection)
+	config := a.newSensorConfigWithEnv(primarySensor, sysSensors, sensorsEnvVal, skipCollection)
+	config.aliases = loadSensorAliases()
+	return config
 }
 
 // newSensorConfigWithEnv creates a SensorConfig with the provided environment variables
@@ -35,14 +40,19 @@ func (a *Agent) newSensorConfig() *SensorConfig {
 func (a *Agent) newSensorConfigWithEnv(primarySensor, sysSensors, sensorsEnvVal string, skipCollection bool) *SensorConfig {
 	config := &SensorConfig{
 		context:        context.Background(),
+		sysPath:        "/sys",
 		primarySensor:  primarySensor,
 		skipCollection: skipCollection,
 		sensors:        make(map[string]struct{}),
 	}
 
 	// Set sensors context (allows overriding sys location for sensors)
+	if hostSys := os.Getenv("HOST_SYS"); hostSys != "" {
+		config.sysPath = hostSys
+	}
 	if sysSensors != "" {
 		slog.Info("SYS_SENSORS", "path", sysSensors)
+		config.sysPath = sysSensors
 		config.context = context.WithValue(config.context,
 			common.EnvKey, common.EnvMap{common.HostSysEnvKey: sysSensors},
 		)
@@ -67,49 +77,110 @@ func (a *Agent) newSensorConfigWithEnv(primarySensor, sysSensors, sensorsEnvVal
 	return config
 }
 
-// updateTemperatures updates the agent with the latest sensor temperatures
-func (a *Agent) updateTemperatures(systemStats *system.Stats) {
+// readTemperatures reads all temperature sensors along with the hwmon device of
+// each. On Linux they are read from hwmon directly, otherwise gopsutil is used and
+// devices is nil.
+func (a *Agent) readTemperatures() (temps []sensors.TemperatureStat, devices []string, err error) {
+	if a.hwmon != nil {
+		chips, _ := a.hwmon.chips()
+		for _, s := range a.hwmon.read(chips, hwmonTemp) {
+			temps = append(temps, sensors.TemperatureStat{SensorKey: s.key, Temperature: s.value})
+			devices = append(devices, s.chip.device)
+		}
+	}
+	if len(temps) == 0 {
+		temps, err = sensors.TemperaturesWithContext(a.sensorConfig.context)
+		devices = nil
+	}
+	return temps, devices, err
+}
+
+// collectTemperatures gets the sensor temperatures allowed by the sensors config
+func (a *Agent) collectTemperatures(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
 	// skip if sensors whitelist is set to empty string
 	if a.sensorConfig.skipCollection {
 		slog.Debug("Skipping temperature collection")
-		return
+		return nil, nil
 	}
 
-	// reset high temp
-	a.systemInfo.DashboardTemp = 0
-
-	// get sensor data
-	temps, _ := sensors.TemperaturesWithContext(a.sensorConfig.context)
+	temps, devices, err := a.readTemperatures()
 	slog.Debug("Temperature", "sensors", temps)
 
 	// return if no sensors
 	if len(temps) == 0 {
-		return
+		// gopsutil returns an error along with partial data, only report if nothing was found
+		return nil, err
 	}
 
-	systemStats.Temperatures = make(map[string]float64, len(temps))
+	var dashboardTemp float64
+	temperatures := make(map[string]float64, len(temps))
+	groups := make(map[string]string)
+	namer := a.sensorConfig.newSensorNamer()
 	for i, sensor := range temps {
+		var device string
+		if devices != nil {
+			device = devices[i]
+		}
 		// skip if temperature is unreasonable
 		if sensor.Temperature <= 0 || sensor.Temperature >= 200 {
 			continue
 		}
-		sensorName := sensor.SensorKey
-		if _, ok := systemStats.Temperatures[sensorName]; ok {
-			// if key already exists, append int to key
-			sensorName = sensorName + "_" + strconv.Itoa(i)
-		}
 		// skip if not in whitelist or blacklist
-		if !isValidSensor(sensorName, a.sensorConfig) {
+		key, sensorName, group, ok := namer.name(sensor.SensorKey, device, i)
+		if !ok {
 			continue
 		}
-		// set dashboard temperature
+		// set dashboard temperature (primary sensor may be the key or the alias)
 		if a.sensorConfig.primarySensor == "" {
-			a.systemInfo.DashboardTemp = max(a.systemInfo.DashboardTemp, sensor.Temperature)
-		} else if a.sensorConfig.primarySensor == sensorName {
-			a.systemInfo.DashboardTemp = sensor.Temperature
+			dashboardTemp = max(dashboardTemp, sensor.Temperature)
+		} else if a.sensorConfig.primarySensor == sensorName || a.sensorConfig.primarySensor == key {
+			dashboardTemp = sensor.Temperature
+		}
+		temperatures[sensorName] = twoDecimals(sensor.Temperature)
+		if group != "" {
+			groups[sensorName] = group
 		}
-		systemStats.Temperatures[sensorName] = twoDecimals(sensor.Temperature)
 	}
+
+	return func(data *system.CombinedData) {
+		data.Stats.Temperatures = temperatures
+		data.Info.DashboardTemp = dashboardTemp
+		addSensorGroups(data, groups)
+	}, nil
+}
+
+// sensorNamer names the sensors of one type in a collection
+type sensorNamer struct {
+	config *SensorConfig
+	keys   map[string]struct{} // Keys already used, to make duplicates unique
+}
+
+func (config *SensorConfig) newSensorNamer() *sensorNamer {
+	return &sensorNamer{config: config, keys: make(map[string]struct{})}
+}
+
+// name returns the unique key, name and group of a sensor. Keys already in use get
+// index i appended, as in previous versions, and then a counter if that is in use
+// too, so the keys of duplicates depend on the order chips are found in. Aliases
+// matching the device give them stable names. The whitelist or blacklist applies
+// to the unique key, and ok is false if the sensor is not allowed.
+func (n *sensorNamer) name(key, device string, i int) (uniqueKey, name, group string, ok bool) {
+	if _, exists := n.keys[key]; exists {
+		base := key + "_" + strconv.Itoa(i)
+		key = base
+		for count := 2; ; count++ {
+			if _, exists := n.keys[key]; !exists {
+				break
+			}
+			key = base + "_" + strconv.Itoa(count)
+		}
+	}
+	if !isValidSensor(key, n.config) {
+		return key, "", "", false
+	}
+	n.keys[key] = struct{}{}
+	name, group = n.config.aliases.resolve(key, device)
+	return key, name, group, true
 }
 
 // isValidSensor checks if a sensor is valid based on the sensor name and the sensor config
@@ -320,6 +320,7 @@ func TestNewSensorConfigWithEnv(t *testing.T) {
 				sysPath, ok := envMap[common.HostSysEnvKey]
 				require.True(t, ok, "EnvMap should contain HostSysEnvKey")
 				assert.Equal(t, tt.sysSensors, sysPath)
+				assert.Equal(t, tt.sysSensors, result.sysPath)
 			}
 		})
 	}
@@ -372,3 +373,49 @@ func TestNewSensorConfig(t *testing.T) {
 	require.True(t, ok, "EnvMap should contain HostSysEnvKey")
 	assert.Equal(t, "/test/path", sysPath)
 }
+
+func TestParseSensorAliases(t *testing.T) {
+	aliases, err := parseSensorAliases([]byte(`
+- match: coretemp_core_*_coretemp_*
+  name: "Core * (CPU *)"
+  group: CPU
+- match: coretemp_core_*
+  device: coretemp.0
+  name: "CPU 0 core *"
+  group: CPU
+- match: nvme_composite
+  name: NVMe
+- name: missing match
+`))
+	assert.Error(t, err)
+	require.Len(t, aliases, 3)
+
+	tests := []struct {
+		key, device string
+		name, group string
+	}{
+		{"coretemp_core_3", "coretemp.0", "CPU 0 core 3", "CPU"},
+		{"coretemp_core_3_coretemp_1", "coretemp.1", "Core 3 (CPU 1)", "CPU"},
+		{"coretemp_core_3", "coretemp.1", "coretemp_core_3", ""},
+		{"nvme_composite", "nvme0", "NVMe", ""},
+		{"nvme_composite_nvme1", "nvme1", "nvme_composite_nvme1", ""},
+	}
+	for _, tt := range tests {
+		name, group := aliases.resolve(tt.key, tt.device)
+		assert.Equal(t, tt.name, name, tt.key)
+		assert.Equal(t, tt.group, group, tt.key)
+	}
+}
+
+func TestSensorNamerDuplicates(t *testing.T) {
+	namer := (&SensorConfig{}).newSensorNamer()
+	var keys []string
+	for range 4 {
+		key, name, _, ok := namer.name("it8686_fan", "it87.2624", 1)
+		require.True(t, ok)
+		assert.Equal(t, key, name)
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []string{"it8686_fan", "it8686_fan_1", "it8686_fan_1_2", "it8686_fan_1_3"}, keys,
+		"Expected identical labels on the same chip to get unique keys")
+}
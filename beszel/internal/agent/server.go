@@ -15,12 +15,10 @@ import (
 type ServerOptions struct {
 	Addr    string
 	Network string
-	Keys    []gossh.PublicKey
+	Keys    []AuthorizedKey
 }
 
 func (a *Agent) StartServer(opts ServerOptions) error {
-	ssh.Handle(a.handleSession)
-
 	slog.Info("Starting SSH server", "addr", opts.Addr, "network", opts.Network)
 
 	if opts.Network == "unix" {
@@ -37,22 +35,79 @@ func (a *Agent) StartServer(opts ServerOptions) error {
 	}
 	defer ln.Close()
 
+	guard := newConnGuard()
+	server := &ssh.Server{
+		Handler: func(s ssh.Session) {
+			a.handleSession(s, opts.Keys, guard)
+		},
+		IdleTimeout:              guard.idleTimeout,
+		ConnCallback:             guard.connCallback,
+		ConnectionFailedCallback: guard.connectionFailed,
+	}
+	server.SetOption(ssh.NoPty())
+	server.SetOption(ssh.PublicKeyAuth(func(ctx ssh.Context, key ssh.PublicKey) bool {
+		return authenticate(ctx, key, opts.Keys, guard)
+	}))
+
 	// Start SSH server on the listener
-	return ssh.Serve(ln, nil, ssh.NoPty(),
-		ssh.PublicKeyAuth(func(ctx ssh.Context, key ssh.PublicKey) bool {
-			for _, pubKey := range opts.Keys {
-				if ssh.KeysEqual(key, pubKey) {
-					return true
-				}
-			}
-			return false
-		}),
-	)
+	return server.Serve(ln)
+}
+
+// authenticate checks if key is one of the authorized keys and is allowed from the
+// client's address. Failed attempts are logged and counted towards a lockout.
+//
+// This is also called when a client asks whether a key would be accepted, before it
+// proves it holds the private key, so success is only recorded in handleSession.
+func authenticate(ctx ssh.Context, key ssh.PublicKey, authorizedKeys []AuthorizedKey, guard *connGuard) bool {
+	logAttrs := []any{"client", ctx.RemoteAddr(), "user", ctx.User(), "key", gossh.FingerprintSHA256(key), "type", key.Type()}
+	if guard.isLockedOut(ctx.RemoteAddr()) {
+		slog.Debug("Auth rejected, source locked out", logAttrs...)
+		return false
+	}
+	reason := "unknown key"
+	if authorizedKey := findAuthorizedKey(authorizedKeys, key); authorizedKey != nil {
+		if authorizedKey.allowsAddr(ctx.RemoteAddr()) {
+			return true
+		}
+		logAttrs = append(logAttrs, "comment", authorizedKey.Comment)
+		reason = "key not allowed from address"
+	}
+	slog.Warn("Auth failed", append(logAttrs, "reason", reason)...)
+	if guard.authFailed(ctx.RemoteAddr()) {
+		slog.Warn("Too many failed auth attempts, locking out source", "client", ctx.RemoteAddr(), "duration", guard.lockoutDuration)
+	}
+	return false
+}
+
+// findAuthorizedKey returns the authorized key matching key, or nil if there is none
+func findAuthorizedKey(authorizedKeys []AuthorizedKey, key ssh.PublicKey) *AuthorizedKey {
+	if key == nil {
+		return nil
+	}
+	for i := range authorizedKeys {
+		if ssh.KeysEqual(key, authorizedKeys[i].PublicKey) {
+			return &authorizedKeys[i]
+		}
+	}
+	return nil
 }
 
-func (a *Agent) handleSession(s ssh.Session) {
-	slog.Debug("New session", "client", s.RemoteAddr())
-	stats := a.gatherStats(s.Context().SessionID())
+// handleSession sends stats to a client once the handshake has completed, so the
+// client has signed with the session's key.
+func (a *Agent) handleSession(s ssh.Session, authorizedKeys []AuthorizedKey, guard *connGuard) {
+	authorizedKey := findAuthorizedKey(authorizedKeys, s.PublicKey())
+	if authorizedKey == nil {
+		slog.Warn("Session without an authorized key", "client", s.RemoteAddr())
+		s.Exit(1)
+		return
+	}
+	slog.Info("Authenticated", "client", s.RemoteAddr(), "user", s.User(), "key", hubID(s.PublicKey()),
+		"type", s.PublicKey().Type(), "comment", authorizedKey.Comment)
+	guard.authSucceeded(s.RemoteAddr())
+
+	stats := a.gatherStats(hubID(s.PublicKey()))
+	// remove sections the key is not allowed to request
+	authorizedKey.filterSections(stats)
 	if err := json.NewEncoder(s).Encode(stats); err != nil {
 		slog.Error("Error encoding stats", "err", err, "stats", stats)
 		s.Exit(1)
@@ -60,10 +115,18 @@ func (a *Agent) handleSession(s ssh.Session) {
 	s.Exit(0)
 }
 
+// hubID returns the identifier used to track state for the hub that authenticated with key
+func hubID(key ssh.PublicKey) string {
+	if key == nil {
+		return ""
+	}
+	return gossh.FingerprintSHA256(key)
+}
+
 // ParseKeys parses a string containing SSH public keys in authorized_keys format.
-// It returns a slice of ssh.PublicKey and an error if any key fails to parse.
-func ParseKeys(input string) ([]gossh.PublicKey, error) {
-	var parsedKeys []gossh.PublicKey
+// It returns a slice of AuthorizedKey and an error if any key or option fails to parse.
+func ParseKeys(input string) ([]AuthorizedKey, error) {
+	var parsedKeys []AuthorizedKey
 	for line := range strings.Lines(input) {
 		line = strings.TrimSpace(line)
 		// Skip empty lines or comments
@@ -71,7 +134,7 @@ func ParseKeys(input string) ([]gossh.PublicKey, error) {
 			continue
 		}
 		// Parse the key
-		parsedKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(line))
+		parsedKey, err := parseAuthorizedKey(line)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse key: %s, error: %w", line, err)
 		}
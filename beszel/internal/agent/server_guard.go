@@ -0,0 +1,253 @@
+package agent
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+)
+
+const (
+	defaultMaxAuthFailures = 10
+	defaultLockoutDuration = 15 * time.Minute
+	defaultMaxConnections  = 50
+	defaultIdleTimeout     = 5 * time.Minute
+
+	// Failed authentications are counted within this window
+	authFailureWindow = 10 * time.Minute
+	// Max concurrent connections from a single source
+	maxConnectionsPerSource = 10
+	// Max new connections from a single source per connectionRateWindow
+	maxConnectionRate    = 30
+	connectionRateWindow = time.Minute
+)
+
+var (
+	errTooManyConnections = errors.New("too many connections")
+	errSourceLockedOut    = errors.New("source locked out")
+	errRateLimited        = errors.New("connection rate exceeded")
+)
+
+// connGuard limits connections to the SSH server and locks out sources
+// which repeatedly fail authentication.
+type connGuard struct {
+	sync.Mutex
+	maxAuthFailures int                     // Failed attempts before lockout, 0 disables lockout
+	lockoutDuration time.Duration           // How long a source is locked out
+	maxConnections  int                     // Max concurrent connections from all sources
+	idleTimeout     time.Duration           // Connection timeout when no activity
+	active          int                     // Number of open connections
+	sources         map[string]*sourceState // State per source IP
+}
+
+// sourceState tracks connections and failed authentications from a single IP
+type sourceState struct {
+	active          int       // Number of open connections
+	failures        int       // Failed authentications in current window
+	failureStart    time.Time // Start of failure window
+	lockedUntil     time.Time // Source is rejected until this time
+	rateCount       int       // New connections in current rate window
+	rateWindowStart time.Time // Start of rate window
+}
+
+// guardedConn releases its connection slot when closed
+type guardedConn struct {
+	net.Conn
+	release func()
+	once    sync.Once
+}
+
+func (c *guardedConn) Close() error {
+	c.once.Do(c.release)
+	return c.Conn.Close()
+}
+
+// newConnGuard creates a connGuard configured from environment variables
+func newConnGuard() *connGuard {
+	return &connGuard{
+		maxAuthFailures: getEnvInt("MAX_AUTH_FAILURES", defaultMaxAuthFailures),
+		lockoutDuration: getEnvDuration("LOCKOUT_DURATION", defaultLockoutDuration),
+		maxConnections:  getEnvInt("MAX_CONNECTIONS", defaultMaxConnections),
+		idleTimeout:     getEnvDuration("IDLE_TIMEOUT", defaultIdleTimeout),
+		sources:         make(map[string]*sourceState),
+	}
+}
+
+// sourceIP returns the IP of a remote address, or an empty string if
+// the address is not an IP (unix socket)
+func sourceIP(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return ""
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// acceptConn reserves a connection slot for addr.
+// Returns a function to release the slot, or an error if the connection is not allowed.
+func (g *connGuard) acceptConn(addr net.Addr) (release func(), err error) {
+	g.Lock()
+	defer g.Unlock()
+
+	now := time.Now()
+	g.prune(now)
+
+	if g.maxConnections > 0 && g.active >= g.maxConnections {
+		return nil, errTooManyConnections
+	}
+
+	ip := sourceIP(addr)
+	var source *sourceState
+	if ip != "" {
+		source = g.getSource(ip)
+		if now.Before(source.lockedUntil) {
+			return nil, errSourceLockedOut
+		}
+		if now.Sub(source.rateWindowStart) > connectionRateWindow {
+			source.rateWindowStart = now
+			source.rateCount = 0
+		}
+		if source.rateCount >= maxConnectionRate {
+			return nil, errRateLimited
+		}
+		if source.active >= maxConnectionsPerSource {
+			return nil, errTooManyConnections
+		}
+		source.rateCount++
+		source.active++
+	}
+	g.active++
+
+	return func() {
+		g.Lock()
+		defer g.Unlock()
+		g.active--
+		if source != nil {
+			source.active--
+		}
+	}, nil
+}
+
+// isLockedOut reports whether addr is currently locked out
+func (g *connGuard) isLockedOut(addr net.Addr) bool {
+	ip := sourceIP(addr)
+	if ip == "" {
+		return false
+	}
+	g.Lock()
+	defer g.Unlock()
+	source, ok := g.sources[ip]
+	return ok && time.Now().Before(source.lockedUntil)
+}
+
+// authFailed records a failed authentication from addr.
+// Returns true if the source was locked out as a result.
+func (g *connGuard) authFailed(addr net.Addr) (lockedOut bool) {
+	ip := sourceIP(addr)
+	if ip == "" || g.maxAuthFailures <= 0 {
+		return false
+	}
+	g.Lock()
+	defer g.Unlock()
+
+	now := time.Now()
+	source := g.getSource(ip)
+	if now.Sub(source.failureStart) > authFailureWindow {
+		source.failureStart = now
+		source.failures = 0
+	}
+	source.failures++
+	if source.failures >= g.maxAuthFailures {
+		source.lockedUntil = now.Add(g.lockoutDuration)
+		source.failures = 0
+		return true
+	}
+	return false
+}
+
+// authSucceeded clears failed authentications for addr
+func (g *connGuard) authSucceeded(addr net.Addr) {
+	ip := sourceIP(addr)
+	if ip == "" {
+		return
+	}
+	g.Lock()
+	defer g.Unlock()
+	if source, ok := g.sources[ip]; ok {
+		source.failures = 0
+	}
+}
+
+// getSource returns the state for ip, creating it if needed. Must hold lock.
+func (g *connGuard) getSource(ip string) *sourceState {
+	source, ok := g.sources[ip]
+	if !ok {
+		source = &sourceState{}
+		g.sources[ip] = source
+	}
+	return source
+}
+
+// prune removes sources with no open connections and no recent activity. Must hold lock.
+func (g *connGuard) prune(now time.Time) {
+	for ip, source := range g.sources {
+		if source.active == 0 &&
+			now.After(source.lockedUntil) &&
+			now.Sub(source.failureStart) > authFailureWindow &&
+			now.Sub(source.rateWindowStart) > connectionRateWindow {
+			delete(g.sources, ip)
+		}
+	}
+}
+
+// connCallback rejects connections which exceed limits or come from a locked out source
+func (g *connGuard) connCallback(ctx ssh.Context, conn net.Conn) net.Conn {
+	release, err := g.acceptConn(conn.RemoteAddr())
+	if err != nil {
+		slog.Debug("Connection rejected", "client", conn.RemoteAddr(), "err", err)
+		return nil
+	}
+	return &guardedConn{Conn: conn, release: release}
+}
+
+// connectionFailed logs connections which fail the SSH handshake
+func (g *connGuard) connectionFailed(conn net.Conn, err error) {
+	slog.Info("SSH handshake failed", "client", conn.RemoteAddr(), "err", err)
+}
+
+// getEnvInt returns an integer environment variable, or def if unset or invalid
+func getEnvInt(key string, def int) int {
+	value, exists := GetEnv(key)
+	if !exists {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Error("Invalid "+key, "err", err)
+		return def
+	}
+	slog.Info(key, "value", parsed)
+	return parsed
+}
+
+// getEnvDuration returns a duration environment variable, or def if unset or invalid
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	value, exists := GetEnv(key)
+	if !exists {
+		return def
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		slog.Error("Invalid "+key, "err", err)
+		return def
+	}
+	slog.Info(key, "value", parsed)
+	return parsed
+}
@@ -0,0 +1,137 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGuard() *connGuard {
+	return &connGuard{
+		maxAuthFailures: 3,
+		lockoutDuration: time.Minute,
+		maxConnections:  maxConnectionsPerSource + 2,
+		sources:         make(map[string]*sourceState),
+	}
+}
+
+func tcpAddr(ip string) net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}
+}
+
+func TestConnGuardLockout(t *testing.T) {
+	guard := newTestGuard()
+	addr := tcpAddr("203.0.113.5")
+
+	assert.False(t, guard.authFailed(addr))
+	assert.False(t, guard.authFailed(addr))
+	assert.True(t, guard.authFailed(addr), "Expected lockout after max failures")
+	assert.True(t, guard.isLockedOut(addr))
+	assert.False(t, guard.isLockedOut(tcpAddr("203.0.113.6")), "Other sources should not be locked out")
+
+	_, err := guard.acceptConn(addr)
+	assert.ErrorIs(t, err, errSourceLockedOut)
+
+	// lockout expires
+	guard.sources["203.0.113.5"].lockedUntil = time.Now().Add(-time.Second)
+	assert.False(t, guard.isLockedOut(addr))
+	release, err := guard.acceptConn(addr)
+	require.NoError(t, err)
+	release()
+}
+
+func TestConnGuardAuthSucceededResetsFailures(t *testing.T) {
+	guard := newTestGuard()
+	addr := tcpAddr("203.0.113.5")
+
+	guard.authFailed(addr)
+	guard.authFailed(addr)
+	guard.authSucceeded(addr)
+	assert.False(t, guard.authFailed(addr), "Failures should reset after successful auth")
+	assert.False(t, guard.isLockedOut(addr))
+}
+
+func TestConnGuardConnectionLimits(t *testing.T) {
+	guard := newTestGuard()
+	addr := tcpAddr("203.0.113.5")
+
+	var releases []func()
+	for range maxConnectionsPerSource {
+		release, err := guard.acceptConn(addr)
+		require.NoError(t, err)
+		releases = append(releases, release)
+	}
+	_, err := guard.acceptConn(addr)
+	assert.ErrorIs(t, err, errTooManyConnections, "Expected per source limit")
+
+	// other sources can connect until the global limit
+	for range guard.maxConnections - maxConnectionsPerSource {
+		release, err := guard.acceptConn(tcpAddr("203.0.113.6"))
+		require.NoError(t, err)
+		releases = append(releases, release)
+	}
+	_, err = guard.acceptConn(tcpAddr("203.0.113.7"))
+	assert.ErrorIs(t, err, errTooManyConnections, "Expected global limit")
+
+	for _, release := range releases {
+		release()
+	}
+	assert.Zero(t, guard.active)
+	release, err := guard.acceptConn(tcpAddr("203.0.113.7"))
+	require.NoError(t, err)
+	release()
+}
+
+func TestConnGuardRateLimit(t *testing.T) {
+	guard := newTestGuard()
+	guard.maxConnections = 0
+	addr := tcpAddr("203.0.113.5")
+
+	for range maxConnectionRate {
+		release, err := guard.acceptConn(addr)
+		require.NoError(t, err)
+		release()
+	}
+	_, err := guard.acceptConn(addr)
+	assert.ErrorIs(t, err, errRateLimited)
+
+	// new window allows connections again
+	guard.sources["203.0.113.5"].rateWindowStart = time.Now().Add(-2 * connectionRateWindow)
+	release, err := guard.acceptConn(addr)
+	require.NoError(t, err)
+	release()
+}
+
+func TestConnGuardUnixSocket(t *testing.T) {
+	guard := newTestGuard()
+	addr := &net.UnixAddr{Name: "@", Net: "unix"}
+
+	for range guard.maxAuthFailures + 1 {
+		assert.False(t, guard.authFailed(addr), "Unix sockets should never be locked out")
+	}
+	release, err := guard.acceptConn(addr)
+	require.NoError(t, err)
+	release()
+	assert.Empty(t, guard.sources)
+}
+
+func TestGuardedConnClose(t *testing.T) {
+	guard := newTestGuard()
+	client, server := net.Pipe()
+	defer client.Close()
+
+	release, err := guard.acceptConn(tcpAddr("203.0.113.5"))
+	require.NoError(t, err)
+	conn := &guardedConn{Conn: server, release: release}
+	assert.Equal(t, 1, guard.active)
+
+	conn.Close()
+	conn.Close()
+	assert.Zero(t, guard.active, "Slot should be released once")
+}
@@ -2,7 +2,9 @@ package agent
 
 import (
 	"crypto/ed25519"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -46,7 +48,7 @@ func TestStartServer(t *testing.T) {
 			config: ServerOptions{
 				Network: "tcp",
 				Addr:    ":45987",
-				Keys:    []ssh.PublicKey{sshPubKey},
+				Keys:    []AuthorizedKey{{PublicKey: sshPubKey}},
 			},
 		},
 		{
@@ -54,7 +56,7 @@ func TestStartServer(t *testing.T) {
 			config: ServerOptions{
 				Network: "tcp4",
 				Addr:    "127.0.0.1:45988",
-				Keys:    []ssh.PublicKey{sshPubKey},
+				Keys:    []AuthorizedKey{{PublicKey: sshPubKey}},
 			},
 		},
 		{
@@ -62,7 +64,7 @@ func TestStartServer(t *testing.T) {
 			config: ServerOptions{
 				Network: "tcp6",
 				Addr:    "[::1]:45989",
-				Keys:    []ssh.PublicKey{sshPubKey},
+				Keys:    []AuthorizedKey{{PublicKey: sshPubKey}},
 			},
 		},
 		{
@@ -70,7 +72,7 @@ func TestStartServer(t *testing.T) {
 			config: ServerOptions{
 				Network: "unix",
 				Addr:    socketFile,
-				Keys:    []ssh.PublicKey{sshPubKey},
+				Keys:    []AuthorizedKey{{PublicKey: sshPubKey}},
 			},
 			setup: func() error {
 				// Create a socket file that should be removed
@@ -89,7 +91,7 @@ func TestStartServer(t *testing.T) {
 			config: ServerOptions{
 				Network: "tcp",
 				Addr:    ":45987",
-				Keys:    []ssh.PublicKey{sshBadPubKey},
+				Keys:    []AuthorizedKey{{PublicKey: sshBadPubKey}},
 			},
 			wantErr:     true,
 			errContains: "ssh: handshake failed",
@@ -99,7 +101,7 @@ func TestStartServer(t *testing.T) {
 			config: ServerOptions{
 				Network: "tcp",
 				Addr:    ":45987",
-				Keys:    []ssh.PublicKey{sshPubKey},
+				Keys:    []AuthorizedKey{{PublicKey: sshPubKey}},
 			},
 		},
 	}
@@ -287,3 +289,49 @@ func TestParseInvalidKey(t *testing.T) {
 		t.Fatalf("Expected error message to contain '%s', got: %v", expectedErrMsg, err)
 	}
 }
+
+// failingSigner offers a public key but can't sign, so the client only asks
+// whether the key would be accepted
+type failingSigner struct {
+	ssh.Signer
+}
+
+func (s failingSigner) Sign(io.Reader, []byte) (*ssh.Signature, error) {
+	return nil, errors.New("no private key")
+}
+
+func TestAuthProbeDoesNotResetLockout(t *testing.T) {
+	t.Setenv("MAX_AUTH_FAILURES", "2")
+	_, privKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privKey)
+	require.NoError(t, err)
+	_, badPrivKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	badSigner, err := ssh.NewSignerFromKey(badPrivKey)
+	require.NoError(t, err)
+
+	addr := "127.0.0.1:45988"
+	agent := NewAgent()
+	go agent.StartServer(ServerOptions{Network: "tcp", Addr: addr, Keys: []AuthorizedKey{{PublicKey: signer.PublicKey()}}})
+	time.Sleep(100 * time.Millisecond)
+
+	dial := func(s ssh.Signer) error {
+		client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+			User:            "a",
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(s)},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+			Timeout:         4 * time.Second,
+		})
+		if err == nil {
+			client.Close()
+		}
+		return err
+	}
+
+	require.Error(t, dial(badSigner))
+	// offering the authorized key without signing must not clear the failure
+	require.Error(t, dial(failingSigner{signer}))
+	require.Error(t, dial(badSigner))
+	assert.Error(t, dial(signer), "Expected source to be locked out after two failures")
+}
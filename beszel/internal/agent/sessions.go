@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/netip"
+	"os"
+	"slices"
+)
+
+const (
+	// Size of a utmp record on Linux (glibc, 32 bit time fields on all architectures)
+	utmpSize = 384
+	// Type of a utmp record for a logged in user
+	utmpUserProcess = 7
+	// Number of records read from the end of wtmp for recent logins
+	wtmpTailRecords = 200
+	// Number of recent logins sent to the hub
+	maxRecentLogins = 20
+)
+
+// sessionReader reads logged in users from utmp and recent logins from wtmp.
+// Only available on Linux.
+type sessionReader struct {
+	utmpPath string
+	wtmpPath string
+}
+
+// newSessionReader creates a sessionReader if utmp is readable
+func newSessionReader() *sessionReader {
+	sr := &sessionReader{utmpPath: "/var/run/utmp", wtmpPath: "/var/log/wtmp"}
+	if _, err := os.Stat(sr.utmpPath); err != nil {
+		return nil
+	}
+	return sr
+}
+
+// collectSessions gets the current sessions and recent logins
+func (a *Agent) collectSessions(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	content, err := os.ReadFile(a.sessions.utmpPath)
+	if err != nil {
+		return nil, err
+	}
+	sessions := parseUtmp(content)
+
+	// wtmp may be rotated or not exist, so errors are ignored
+	var logins []system.Session
+	if content, err := readFileTail(a.sessions.wtmpPath, wtmpTailRecords*utmpSize, utmpSize); err == nil {
+		logins = parseUtmp(content)
+		slices.Reverse(logins)
+		logins = logins[:min(len(logins), maxRecentLogins)]
+	}
+
+	return func(data *system.CombinedData) {
+		data.Sessions = sessions
+		data.Logins = logins
+	}, nil
+}
+
+// parseUtmp returns the user sessions in utmp format records. The layout is:
+//
+//	type int16, pad int16, pid int32, line [32]byte, id [4]byte, user [32]byte,
+//	host [256]byte, exit [2]int16, session int32, tv [2]int32, addr [4]int32, unused [20]byte
+func parseUtmp(content []byte) []system.Session {
+	var sessions []system.Session
+	for len(content) >= utmpSize {
+		record := content[:utmpSize]
+		content = content[utmpSize:]
+		if binary.LittleEndian.Uint16(record[0:2]) != utmpUserProcess {
+			continue
+		}
+		session := system.Session{
+			User: cString(record[44:76]),
+			Line: cString(record[8:40]),
+			Host: cString(record[76:332]),
+			Time: int64(int32(binary.LittleEndian.Uint32(record[340:344]))),
+			Pid:  int32(binary.LittleEndian.Uint32(record[4:8])),
+		}
+		// prefer the source address since host may be a resolved name
+		if addr := utmpAddr(record[348:364]); addr.IsValid() {
+			session.Host = addr.String()
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// utmpAddr returns the address in a utmp record. IPv4 addresses only use the first word.
+func utmpAddr(b []byte) netip.Addr {
+	if bytes.Count(b, []byte{0}) == len(b) {
+		return netip.Addr{}
+	}
+	if bytes.Count(b[4:], []byte{0}) == len(b)-4 {
+		return netip.AddrFrom4([4]byte(b[:4]))
+	}
+	return netip.AddrFrom16([16]byte(b))
+}
+
+// cString returns the string before the first null byte
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// readFileTail reads up to size bytes from the end of a file, starting on a
+// multiple of align bytes
+func readFileTail(path string, size, align int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := max(0, info.Size()-size)
+	offset -= offset % align
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(f)
+}
@@ -0,0 +1,73 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// utmpRecord encodes a utmp record
+func utmpRecord(typ uint16, pid int32, line, user, host string, t int32, addr []byte) []byte {
+	b := make([]byte, utmpSize)
+	binary.LittleEndian.PutUint16(b[0:], typ)
+	binary.LittleEndian.PutUint32(b[4:], uint32(pid))
+	copy(b[8:40], line)
+	copy(b[44:76], user)
+	copy(b[76:332], host)
+	binary.LittleEndian.PutUint32(b[340:], uint32(t))
+	copy(b[348:364], addr)
+	return b
+}
+
+func TestParseUtmp(t *testing.T) {
+	ipv6 := []byte{0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
+	var content []byte
+	content = append(content, utmpRecord(2, 0, "~", "reboot", "6.1.0-18-amd64", 1_700_000_000, nil)...)
+	content = append(content, utmpRecord(utmpUserProcess, 812, "tty1", "root", "", 1_700_000_100, nil)...)
+	content = append(content, utmpRecord(utmpUserProcess, 1501, "pts/0", "alice", "bastion.example.com", 1_700_000_200, []byte{10, 0, 0, 5})...)
+	content = append(content, utmpRecord(utmpUserProcess, 1602, "pts/1", "bob", "2001:db8::1", 1_700_000_300, ipv6)...)
+	content = append(content, utmpRecord(8, 1501, "pts/0", "", "", 1_700_000_400, nil)...)
+	// truncated record is ignored
+	content = append(content, make([]byte, 100)...)
+
+	assert.Equal(t, []system.Session{
+		{User: "root", Line: "tty1", Time: 1_700_000_100, Pid: 812},
+		{User: "alice", Line: "pts/0", Host: "10.0.0.5", Time: 1_700_000_200, Pid: 1501},
+		{User: "bob", Line: "pts/1", Host: "2001:db8::1", Time: 1_700_000_300, Pid: 1602},
+	}, parseUtmp(content))
+}
+
+func TestCollectSessions(t *testing.T) {
+	dir := t.TempDir()
+	sr := &sessionReader{utmpPath: filepath.Join(dir, "utmp"), wtmpPath: filepath.Join(dir, "wtmp")}
+	a := &Agent{sessions: sr}
+
+	require.NoError(t, os.WriteFile(sr.utmpPath, utmpRecord(utmpUserProcess, 1501, "pts/0", "alice", "", 1_700_000_000, []byte{10, 0, 0, 5}), 0o644))
+	apply, err := a.collectSessions(context.Background(), &hubState{})
+	require.NoError(t, err, "Expected a missing wtmp to be ignored")
+	data := &system.CombinedData{}
+	apply(data)
+	assert.Len(t, data.Sessions, 1)
+	assert.Empty(t, data.Logins)
+
+	var wtmp []byte
+	for i := range wtmpTailRecords + 10 {
+		wtmp = append(wtmp, utmpRecord(utmpUserProcess, int32(i), "pts/0", "alice", "", int32(1_700_000_000+i), nil)...)
+		wtmp = append(wtmp, utmpRecord(8, int32(i), "pts/0", "", "", int32(1_700_000_000+i), nil)...)
+	}
+	require.NoError(t, os.WriteFile(sr.wtmpPath, wtmp, 0o644))
+	apply, err = a.collectSessions(context.Background(), &hubState{})
+	require.NoError(t, err)
+	apply(data)
+	require.Len(t, data.Logins, maxRecentLogins)
+	assert.Equal(t, int64(1_700_000_000+wtmpTailRecords+9), data.Logins[0].Time, "Expected newest login first")
+}
@@ -0,0 +1,221 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	// Default time between SMART checks
+	defaultSmartInterval = time.Hour
+	// Time smartctl may take for each device
+	smartTimeout = 30 * time.Second
+)
+
+// smartChecker reads the SMART health of each disk with smartctl on the
+// SMART_INTERVAL. smartctl needs root to query most devices.
+type smartChecker struct {
+	sync.Mutex
+	interval time.Duration
+	smartctl string               // Path of the smartctl command
+	devices  []system.SmartDevice // Result of the last check
+}
+
+// newSmartChecker creates a smartChecker if smartctl is installed.
+// Returns nil if disabled with an interval of 0.
+func newSmartChecker() *smartChecker {
+	interval := getEnvDuration("SMART_INTERVAL", defaultSmartInterval)
+	if interval <= 0 {
+		return nil
+	}
+	smartctl, err := exec.LookPath("smartctl")
+	if err != nil {
+		slog.Debug("SMART", "err", err)
+		return nil
+	}
+	return &smartChecker{interval: interval, smartctl: smartctl}
+}
+
+// start checks the devices on the interval. Blocks forever.
+func (sc *smartChecker) start() {
+	for {
+		sc.check()
+		time.Sleep(sc.interval)
+	}
+}
+
+// smartScan is the device list from smartctl --scan --json
+type smartScan struct {
+	Devices []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"devices"`
+}
+
+// check scans for devices and stores the SMART health of each
+func (sc *smartChecker) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), smartTimeout)
+	output, err := exec.CommandContext(ctx, sc.smartctl, "--scan", "--json").Output()
+	cancel()
+	var scan smartScan
+	if err == nil {
+		err = json.Unmarshal(output, &scan)
+	}
+	if err != nil {
+		slog.Debug("SMART", "err", err)
+		return
+	}
+
+	devices := make([]system.SmartDevice, 0, len(scan.Devices))
+	for _, d := range scan.Devices {
+		devices = append(devices, sc.readDevice(d.Name, d.Type))
+	}
+	sc.Lock()
+	sc.devices = devices
+	sc.Unlock()
+}
+
+// readDevice runs smartctl for a device. smartctl sets exit status bits for
+// failing disks, so the output is parsed whenever it is valid JSON.
+func (sc *smartChecker) readDevice(name, deviceType string) system.SmartDevice {
+	ctx, cancel := context.WithTimeout(context.Background(), smartTimeout)
+	defer cancel()
+	args := []string{"--json", "-i", "-H", "-A", name}
+	if deviceType != "" {
+		args = append(args, "-d", deviceType)
+	}
+	output, err := exec.CommandContext(ctx, sc.smartctl, args...).Output()
+	device, parseErr := parseSmartctl(output)
+	device.Name = name
+	if parseErr != nil {
+		if err == nil {
+			err = parseErr
+		}
+		slog.Debug("SMART", "device", name, "err", err)
+		device.Error = err.Error()
+	}
+	return device
+}
+
+// smartctlOutput is the part of smartctl --json output used for health
+type smartctlOutput struct {
+	Smartctl struct {
+		Messages []struct {
+			String   string `json:"string"`
+			Severity string `json:"severity"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	Device struct {
+		Protocol string `json:"protocol"`
+	} `json:"device"`
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	SmartStatus  *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current float64 `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime struct {
+		Hours uint64 `json:"hours"`
+	} `json:"power_on_time"`
+	AtaSmartAttributes struct {
+		Table []struct {
+			ID         int    `json:"id"`
+			Name       string `json:"name"`
+			Value      int    `json:"value"`
+			WhenFailed string `json:"when_failed"`
+			Raw        struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NvmeHealth *struct {
+		CriticalWarning int    `json:"critical_warning"`
+		PercentageUsed  int    `json:"percentage_used"`
+		MediaErrors     uint64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// ATA attribute ids reporting wear as the normalized percent of life remaining
+var ataWearAttributes = map[int]bool{
+	177: true, // Wear_Leveling_Count
+	231: true, // SSD_Life_Left
+	233: true, // Media_Wearout_Indicator
+}
+
+// parseSmartctl parses the health of a device from smartctl --json output.
+// Attributes which have failed and NVMe media errors or warnings are added
+// to Failing.
+func parseSmartctl(output []byte) (system.SmartDevice, error) {
+	var out smartctlOutput
+	if err := json.Unmarshal(output, &out); err != nil {
+		return system.SmartDevice{}, err
+	}
+	device := system.SmartDevice{
+		Model:    out.ModelName,
+		Serial:   out.SerialNumber,
+		Protocol: out.Device.Protocol,
+		Temp:     out.Temperature.Current,
+		Hours:    out.PowerOnTime.Hours,
+	}
+	if out.SmartStatus == nil {
+		// smartctl reports errors such as missing permissions as messages
+		for _, msg := range out.Smartctl.Messages {
+			if msg.Severity == "error" {
+				return device, fmt.Errorf("%s", msg.String)
+			}
+		}
+		return device, fmt.Errorf("no SMART status")
+	}
+	device.Health = "PASSED"
+	if !out.SmartStatus.Passed {
+		device.Health = "FAILED"
+	}
+
+	for _, attr := range out.AtaSmartAttributes.Table {
+		switch {
+		case attr.ID == 5:
+			device.Reallocated = attr.Raw.Value
+		case attr.ID == 197:
+			device.Pending = attr.Raw.Value
+		case ataWearAttributes[attr.ID] && device.Wear == 0:
+			device.Wear = float64(max(0, 100-attr.Value))
+		}
+		// when_failed is "now" for attributes at or below their threshold, or "past"
+		// for attributes which were once but have since recovered
+		if attr.WhenFailed == "now" {
+			device.Failing = append(device.Failing, fmt.Sprintf("%s (%s)", attr.Name, attr.WhenFailed))
+		}
+	}
+	if nvme := out.NvmeHealth; nvme != nil {
+		device.Wear = float64(nvme.PercentageUsed)
+		device.MediaErrors = nvme.MediaErrors
+		if nvme.CriticalWarning != 0 {
+			device.Failing = append(device.Failing, fmt.Sprintf("critical_warning (0x%02x)", nvme.CriticalWarning))
+		}
+		if nvme.MediaErrors > 0 {
+			device.Failing = append(device.Failing, fmt.Sprintf("media_errors (%d)", nvme.MediaErrors))
+		}
+	}
+	return device, nil
+}
+
+// collectSmart gets the SMART health from the last check
+func (a *Agent) collectSmart(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	a.smart.Lock()
+	devices := a.smart.devices
+	a.smart.Unlock()
+	if devices == nil {
+		return nil, nil
+	}
+	return func(data *system.CombinedData) {
+		data.Smart = devices
+	}, nil
+}
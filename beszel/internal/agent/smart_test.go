@@ -0,0 +1,92 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const smartAtaOutput = `{
+  "device": {"name": "/dev/sda", "type": "sat", "protocol": "ATA"},
+  "model_name": "WDC WD40EFRX",
+  "serial_number": "WD-1234",
+  "smart_status": {"passed": true},
+  "temperature": {"current": 34},
+  "power_on_time": {"hours": 21000},
+  "ata_smart_attributes": {"table": [
+    {"id": 5, "name": "Reallocated_Sector_Ct", "value": 100, "raw": {"value": 8}},
+    {"id": 177, "name": "Wear_Leveling_Count", "value": 93, "raw": {"value": 70}},
+    {"id": 184, "name": "End-to-End_Error", "value": 1, "when_failed": "now", "raw": {"value": 99}},
+    {"id": 190, "name": "Airflow_Temperature_Cel", "value": 66, "when_failed": "past", "raw": {"value": 34}},
+    {"id": 197, "name": "Current_Pending_Sector", "value": 100, "raw": {"value": 2}}
+  ]}
+}`
+
+const smartNvmeOutput = `{
+  "device": {"name": "/dev/nvme0", "type": "nvme", "protocol": "NVMe"},
+  "model_name": "Samsung SSD 980",
+  "smart_status": {"passed": false},
+  "temperature": {"current": 41},
+  "nvme_smart_health_information_log": {"critical_warning": 4, "percentage_used": 12, "media_errors": 3}
+}`
+
+func TestParseSmartctl(t *testing.T) {
+	device, err := parseSmartctl([]byte(smartAtaOutput))
+	require.NoError(t, err)
+	assert.Equal(t, "WDC WD40EFRX", device.Model)
+	assert.Equal(t, "ATA", device.Protocol)
+	assert.Equal(t, "PASSED", device.Health)
+	assert.Equal(t, 34.0, device.Temp)
+	assert.Equal(t, uint64(21000), device.Hours)
+	assert.Equal(t, uint64(8), device.Reallocated)
+	assert.Equal(t, uint64(2), device.Pending)
+	assert.Equal(t, 7.0, device.Wear)
+	assert.Equal(t, []string{"End-to-End_Error (now)"}, device.Failing, "Expected attributes which failed in the past to be skipped")
+
+	device, err = parseSmartctl([]byte(smartNvmeOutput))
+	require.NoError(t, err)
+	assert.Equal(t, "FAILED", device.Health)
+	assert.Equal(t, 12.0, device.Wear)
+	assert.Equal(t, uint64(3), device.MediaErrors)
+	assert.Equal(t, []string{"critical_warning (0x04)", "media_errors (3)"}, device.Failing)
+
+	_, err = parseSmartctl([]byte(`{"smartctl": {"messages": [{"string": "Permission denied", "severity": "error"}]}}`))
+	assert.EqualError(t, err, "Permission denied")
+}
+
+func TestSmartCheck(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sda.json"), []byte(smartAtaOutput), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nvme0.json"), []byte(smartNvmeOutput), 0o644))
+	// Stub smartctl which prints the fixture of the device and fails like smartctl does for failing disks
+	stub := filepath.Join(dir, "smartctl")
+	script := `#!/bin/sh
+if [ "$1" = "--scan" ]; then
+	echo '{"devices": [{"name": "/dev/sda", "type": "sat"}, {"name": "/dev/nvme0", "type": "nvme"}, {"name": "/dev/sdz", "type": "sat"}]}'
+	exit 0
+fi
+case "$5" in
+/dev/sda) cat ` + dir + `/sda.json ;;
+/dev/nvme0) cat ` + dir + `/nvme0.json; exit 8 ;;
+*) exit 2 ;;
+esac
+`
+	require.NoError(t, os.WriteFile(stub, []byte(script), 0o755))
+
+	sc := &smartChecker{smartctl: stub}
+	sc.check()
+	require.Len(t, sc.devices, 3)
+	assert.Equal(t, "/dev/sda", sc.devices[0].Name)
+	assert.Equal(t, "PASSED", sc.devices[0].Health)
+	assert.Equal(t, "/dev/nvme0", sc.devices[1].Name)
+	assert.Equal(t, "FAILED", sc.devices[1].Health, "Expected output to be parsed despite the exit status")
+	assert.Empty(t, sc.devices[1].Error)
+	assert.Equal(t, "/dev/sdz", sc.devices[2].Name)
+	assert.NotEmpty(t, sc.devices[2].Error)
+}
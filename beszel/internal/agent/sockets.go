@@ -0,0 +1,279 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tcpStates maps the state codes in /proc/net/tcp to their names
+var tcpStates = map[uint8]string{
+	0x01: "ESTABLISHED",
+	0x02: "SYN_SENT",
+	0x03: "SYN_RECV",
+	0x04: "FIN_WAIT1",
+	0x05: "FIN_WAIT2",
+	0x06: "TIME_WAIT",
+	0x07: "CLOSE",
+	0x08: "CLOSE_WAIT",
+	0x09: "LAST_ACK",
+	0x0B: "CLOSING",
+	0x0C: "NEW_SYN_RECV",
+}
+
+const (
+	// State of listening TCP sockets
+	tcpListen = 0x0A
+	// State of unconnected UDP sockets
+	udpUnconnected = 0x07
+)
+
+// socketReader reads listening sockets, TCP connection states and
+// retransmits from procfs. Only available on Linux.
+type socketReader struct {
+	procPath  string
+	owners    map[uint64]socketOwner // Owning process of each listening socket inode
+	ephemeral uint16                 // Start of the ephemeral port range
+}
+
+// socketOwner is the process which owns a socket. Empty if it can't be read.
+type socketOwner struct {
+	pid  int32
+	name string
+}
+
+// procSocket is a single line of /proc/net/{tcp,udp}{,6}
+type procSocket struct {
+	local      netip.AddrPort
+	remotePort uint16
+	state      uint8
+	inode      uint64
+}
+
+// newSocketReader creates a socketReader if /proc/net/tcp is readable
+func newSocketReader() *socketReader {
+	sr := &socketReader{procPath: "/proc", owners: make(map[uint64]socketOwner), ephemeral: 32768}
+	if _, err := os.Stat(filepath.Join(sr.procPath, "net/tcp")); err != nil {
+		return nil
+	}
+	if content, err := os.ReadFile(filepath.Join(sr.procPath, "sys/net/ipv4/ip_local_port_range")); err == nil {
+		if fields := strings.Fields(string(content)); len(fields) > 0 {
+			if low, err := strconv.ParseUint(fields[0], 10, 16); err == nil {
+				sr.ephemeral = uint16(low)
+			}
+		}
+	}
+	return sr
+}
+
+// collectSockets gets TCP connection states, the retransmit rate and listening sockets
+func (a *Agent) collectSockets(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	sr := a.sockets
+	states := make(map[string]float64)
+	// listening sockets and their inodes, keyed by protocol, address and port
+	type listener struct {
+		system.Listener
+		inode uint64
+	}
+	listeners := make(map[string]listener)
+	var errs []error
+
+	for _, file := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		proto := strings.TrimSuffix(file, "6")
+		err := sr.scanProcNet(file, func(s procSocket) {
+			switch {
+			case proto == "tcp" && s.state == tcpListen:
+			case proto == "tcp":
+				if name, ok := tcpStates[s.state]; ok {
+					states[name]++
+				}
+				return
+			// unconnected udp sockets in the ephemeral range are clients, not listeners
+			case s.state != udpUnconnected || s.remotePort != 0 || s.local.Port() >= sr.ephemeral:
+				return
+			}
+			key := fmt.Sprintf("%s %s", proto, s.local)
+			// sockets sharing a port (SO_REUSEPORT) are listed once
+			if _, exists := listeners[key]; !exists {
+				listeners[key] = listener{
+					Listener: system.Listener{Proto: proto, Address: s.local.Addr().String(), Port: s.local.Port()},
+					inode:    s.inode,
+				}
+			}
+		})
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	inodes := make([]uint64, 0, len(listeners))
+	for _, l := range listeners {
+		inodes = append(inodes, l.inode)
+	}
+	sr.resolveOwners(inodes)
+	result := make([]system.Listener, 0, len(listeners))
+	for _, l := range listeners {
+		owner := sr.owners[l.inode]
+		l.Pid, l.Process = owner.pid, owner.name
+		result = append(result, l.Listener)
+	}
+	slices.SortFunc(result, func(a, b system.Listener) int {
+		if a.Port != b.Port {
+			return int(a.Port) - int(b.Port)
+		}
+		return strings.Compare(a.Proto+" "+a.Address, b.Proto+" "+b.Address)
+	})
+
+	retrans, retransOk := 0.0, false
+	if segs, err := sr.readRetransSegs(); err != nil {
+		errs = append(errs, err)
+	} else {
+		retrans, retransOk = hs.socketRates.rate("RetransSegs", segs, time.Now())
+	}
+
+	return func(data *system.CombinedData) {
+		data.Stats.TcpStates = states
+		if retransOk {
+			data.Stats.TcpRetrans = twoDecimals(retrans)
+		}
+		data.Listeners = result
+	}, errors.Join(errs...)
+}
+
+// scanProcNet calls fn for each socket in a /proc/net file
+func (sr *socketReader) scanProcNet(file string, fn func(procSocket)) error {
+	f, err := os.Open(filepath.Join(sr.procPath, "net", file))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return parseProcNet(f, fn)
+}
+
+// parseProcNet parses the socket table format of /proc/net/{tcp,udp}{,6}
+func parseProcNet(r io.Reader, fn func(procSocket)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		local, err := parseProcNetAddr(fields[1])
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", fields[1], err)
+		}
+		_, remotePort, _ := strings.Cut(fields[2], ":")
+		port, _ := strconv.ParseUint(remotePort, 16, 16)
+		state, _ := strconv.ParseUint(fields[3], 16, 8)
+		inode, _ := strconv.ParseUint(fields[9], 10, 64)
+		fn(procSocket{local: local, remotePort: uint16(port), state: uint8(state), inode: inode})
+	}
+	return scanner.Err()
+}
+
+// parseProcNetAddr parses an address like "0100007F:0035". Addresses are
+// written as 32 bit words in host byte order, assumed to be little endian.
+func parseProcNetAddr(s string) (netip.AddrPort, error) {
+	hexAddr, hexPort, ok := strings.Cut(s, ":")
+	if !ok {
+		return netip.AddrPort{}, errors.New("missing port")
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	b, err := hex.DecodeString(hexAddr)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	if len(b) != 4 && len(b) != 16 {
+		return netip.AddrPort{}, errors.New("invalid length")
+	}
+	for i := 0; i < len(b); i += 4 {
+		slices.Reverse(b[i : i+4])
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return netip.AddrPortFrom(addr.Unmap(), uint16(port)), nil
+}
+
+// resolveOwners finds the owning process of socket inodes not already known by
+// scanning /proc/*/fd. Owners of closed sockets are removed.
+func (sr *socketReader) resolveOwners(inodes []uint64) {
+	unknown := make(map[uint64]struct{})
+	for _, inode := range inodes {
+		if _, ok := sr.owners[inode]; !ok {
+			unknown[inode] = struct{}{}
+		}
+	}
+	for inode := range sr.owners {
+		if !slices.Contains(inodes, inode) {
+			delete(sr.owners, inode)
+		}
+	}
+	if len(unknown) == 0 {
+		return
+	}
+
+	fdPaths, _ := filepath.Glob(filepath.Join(sr.procPath, "[0-9]*/fd/*"))
+	for _, fdPath := range fdPaths {
+		link, err := os.Readlink(fdPath)
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+		inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, ok := unknown[inode]; !ok {
+			continue
+		}
+		pidDir := filepath.Dir(filepath.Dir(fdPath))
+		pid, _ := strconv.ParseInt(filepath.Base(pidDir), 10, 32)
+		sr.owners[inode] = socketOwner{pid: int32(pid), name: readSysfsString(pidDir, "comm")}
+		delete(unknown, inode)
+		if len(unknown) == 0 {
+			return
+		}
+	}
+	// sockets owned by processes we can't read are not looked up again
+	for inode := range unknown {
+		sr.owners[inode] = socketOwner{}
+	}
+}
+
+// readRetransSegs reads the total retransmitted TCP segments from /proc/net/snmp
+func (sr *socketReader) readRetransSegs() (float64, error) {
+	content, err := os.ReadFile(filepath.Join(sr.procPath, "net/snmp"))
+	if err != nil {
+		return 0, err
+	}
+	var header []string
+	for line := range strings.Lines(string(content)) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "Tcp:" {
+			continue
+		}
+		// the first Tcp: line has the names and the second the values
+		if header == nil {
+			header = fields
+			continue
+		}
+		if i := slices.Index(header, "RetransSegs"); i > 0 && i < len(fields) {
+			return strconv.ParseFloat(fields[i], 64)
+		}
+		break
+	}
+	return 0, errors.New("RetransSegs not found in net/snmp")
+}
@@ -0,0 +1,105 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProcNetAddr(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"0100007F:0035", "127.0.0.1:53"},
+		{"00000000:0016", "0.0.0.0:22"},
+		{"00000000000000000000000000000000:0050", "[::]:80"},
+		{"00000000000000000000000001000000:1F90", "[::1]:8080"},
+		{"0000000000000000FFFF00000100A8C0:01BB", "192.168.0.1:443"},
+	}
+	for _, tt := range tests {
+		addr, err := parseProcNetAddr(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, addr.String())
+	}
+
+	for _, input := range []string{"0100007F", "0100007F:zz", "01007F:0035"} {
+		_, err := parseProcNetAddr(input)
+		assert.Error(t, err, input)
+	}
+}
+
+const procNetHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+func newTestSocketReader(t *testing.T) *socketReader {
+	procPath := t.TempDir()
+	writeSysfs(t, procPath, map[string]string{
+		"net/tcp": procNetHeader +
+			"   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0 100 0 0 10 0\n" +
+			"   1: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1002 1 0 100 0 0 10 0\n" +
+			"   2: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1003 1 0 100 0 0 10 0\n" +
+			"   3: 0F02000A:0016 0100000A:D431 01 00000000:00000000 02:00000000 00000000     0        0 2001 1 0 20 4 30 10 -1\n" +
+			"   4: 0F02000A:0016 0200000A:D432 01 00000000:00000000 02:00000000 00000000     0        0 2002 1 0 20 4 30 10 -1\n" +
+			"   5: 0F02000A:C350 0300000A:0050 08 00000000:00000000 00:00000000 00000000     0        0 2003 1 0 20 4 30 10 -1\n" +
+			"   6: 0F02000A:C351 0300000A:0050 06 00000000:00000000 03:00000000 00000000     0        0 0 3 0000000000000000",
+		"net/tcp6": procNetHeader +
+			"   0: 00000000000000000000000000000000:0016 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1004 1 0 100 0 0 10 0",
+		"net/udp": procNetHeader +
+			"   0: 00000000:0044 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 1005 2 0 0\n" +
+			"   1: 0F02000A:E290 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 1006 2 0 0\n" +
+			"   2: 0F02000A:0035 0300000A:0035 01 00000000:00000000 00:00000000 00000000     0        0 1007 2 0 0",
+		"net/snmp": "Ip: Forwarding DefaultTTL\nIp: 1 64\n" +
+			"Tcp: RtoAlgorithm RtoMin RtoMax MaxConn ActiveOpens PassiveOpens AttemptFails EstabResets CurrEstab InSegs OutSegs RetransSegs InErrs OutRsts InCsumErrors\n" +
+			"Tcp: 1 200 120000 -1 100 50 0 0 2 10000 9000 120 0 10 0",
+		"123/comm": "sshd",
+		"456/comm": "python3",
+	})
+	require.NoError(t, os.MkdirAll(filepath.Join(procPath, "123/fd"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(procPath, "456/fd"), 0o755))
+	require.NoError(t, os.Symlink("socket:[1001]", filepath.Join(procPath, "123/fd/3")))
+	require.NoError(t, os.Symlink("socket:[1004]", filepath.Join(procPath, "123/fd/4")))
+	require.NoError(t, os.Symlink("/dev/null", filepath.Join(procPath, "456/fd/0")))
+	require.NoError(t, os.Symlink("socket:[1002]", filepath.Join(procPath, "456/fd/5")))
+	return &socketReader{procPath: procPath, owners: make(map[uint64]socketOwner), ephemeral: 32768}
+}
+
+func TestCollectSockets(t *testing.T) {
+	a := &Agent{sockets: newTestSocketReader(t)}
+	apply, err := a.collectSockets(context.Background(), &hubState{})
+	require.NoError(t, err)
+	data := &system.CombinedData{}
+	apply(data)
+
+	assert.Equal(t, map[string]float64{"ESTABLISHED": 2, "CLOSE_WAIT": 1, "TIME_WAIT": 1}, data.Stats.TcpStates)
+	assert.Zero(t, data.Stats.TcpRetrans, "Expected no rate from the first sample")
+	assert.Equal(t, []system.Listener{
+		{Proto: "tcp", Address: "0.0.0.0", Port: 22, Process: "sshd", Pid: 123},
+		{Proto: "tcp", Address: "::", Port: 22, Process: "sshd", Pid: 123},
+		{Proto: "udp", Address: "0.0.0.0", Port: 68},
+		{Proto: "tcp", Address: "127.0.0.1", Port: 8080, Process: "python3", Pid: 456},
+	}, data.Listeners, "Expected reuseport sockets once and no ephemeral or connected udp sockets")
+
+	// owners of closed sockets are forgotten
+	assert.Len(t, a.sockets.owners, 4)
+	a.sockets.resolveOwners([]uint64{1001})
+	assert.Equal(t, map[uint64]socketOwner{1001: {pid: 123, name: "sshd"}}, a.sockets.owners)
+}
+
+func TestReadRetransSegs(t *testing.T) {
+	sr := newTestSocketReader(t)
+	segs, err := sr.readRetransSegs()
+	require.NoError(t, err)
+	assert.Equal(t, 120.0, segs)
+
+	sr.procPath = t.TempDir()
+	_, err = sr.readRetransSegs()
+	assert.Error(t, err)
+}
@@ -3,11 +3,11 @@ package agent
 import (
 	"beszel"
 	"beszel/internal/entities/system"
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -65,212 +65,269 @@ func (a *Agent) initializeSystemInfo() {
 	}
 }
 
-// Returns current info, stats about the host system
-func (a *Agent) getSystemStats() system.Stats {
-	systemStats := system.Stats{}
-
-	// cpu percent
-	cpuPct, err := cpu.Percent(0, false)
+// collectCpu calculates cpu usage against the previous values stored in the hub's state
+func (a *Agent) collectCpu(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	times, err := cpu.TimesWithContext(ctx, false)
 	if err != nil {
-		slog.Error("Error getting cpu percent", "err", err)
-	} else if len(cpuPct) > 0 {
-		systemStats.Cpu = twoDecimals(cpuPct[0])
+		slog.Error("Error getting cpu times", "err", err)
+		return nil, err
+	}
+	if len(times) == 0 {
+		return nil, errors.New("no cpu times")
+	}
+	busy, total := cpuBusyTotal(times[0])
+	// take a baseline on the hub's first request, usage is reported from the next
+	if hs.cpuTotal == 0 {
+		hs.cpuBusy, hs.cpuTotal = busy, total
+		return nil, nil
 	}
+	var cpuPct float64
+	if totalDelta := total - hs.cpuTotal; totalDelta > 0 {
+		cpuPct = twoDecimals(max(0, min(100, (busy-hs.cpuBusy)/totalDelta*100)))
+	}
+	hs.cpuBusy, hs.cpuTotal = busy, total
+	return func(data *system.CombinedData) {
+		data.Stats.Cpu = cpuPct
+	}, nil
+}
 
-	// memory
-	if v, err := mem.VirtualMemory(); err == nil {
-		// swap
-		systemStats.Swap = bytesToGigabytes(v.SwapTotal)
-		systemStats.SwapUsed = bytesToGigabytes(v.SwapTotal - v.SwapFree - v.SwapCached)
-		// cache + buffers value for default mem calculation
-		cacheBuff := v.Total - v.Free - v.Used
-		// htop memory calculation overrides
-		if a.memCalc == "htop" {
-			// note: gopsutil automatically adds SReclaimable to v.Cached
-			cacheBuff = v.Cached + v.Buffers - v.Shared
-			v.Used = v.Total - (v.Free + cacheBuff)
+// collectMemory gets memory, swap and ZFS ARC usage
+func (a *Agent) collectMemory(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	v, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var memStats system.Stats
+	// swap
+	memStats.Swap = bytesToGigabytes(v.SwapTotal)
+	memStats.SwapUsed = bytesToGigabytes(v.SwapTotal - v.SwapFree - v.SwapCached)
+	// cache + buffers value for default mem calculation
+	cacheBuff := v.Total - v.Free - v.Used
+	// htop memory calculation overrides
+	if a.memCalc == "htop" {
+		// note: gopsutil automatically adds SReclaimable to v.Cached
+		cacheBuff = v.Cached + v.Buffers - v.Shared
+		v.Used = v.Total - (v.Free + cacheBuff)
+		v.UsedPercent = float64(v.Used) / float64(v.Total) * 100.0
+	}
+	// subtract ZFS ARC size from used memory and add as its own category
+	if a.zfs {
+		if arcSize, _ := getARCSize(); arcSize > 0 && arcSize < v.Used {
+			v.Used = v.Used - arcSize
 			v.UsedPercent = float64(v.Used) / float64(v.Total) * 100.0
+			memStats.MemZfsArc = bytesToGigabytes(arcSize)
 		}
-		// subtract ZFS ARC size from used memory and add as its own category
-		if a.zfs {
-			if arcSize, _ := getARCSize(); arcSize > 0 && arcSize < v.Used {
-				v.Used = v.Used - arcSize
-				v.UsedPercent = float64(v.Used) / float64(v.Total) * 100.0
-				systemStats.MemZfsArc = bytesToGigabytes(arcSize)
-			}
-		}
-		systemStats.Mem = bytesToGigabytes(v.Total)
-		systemStats.MemBuffCache = bytesToGigabytes(cacheBuff)
-		systemStats.MemUsed = bytesToGigabytes(v.Used)
-		systemStats.MemPct = twoDecimals(v.UsedPercent)
 	}
+	memStats.Mem = bytesToGigabytes(v.Total)
+	memStats.MemBuffCache = bytesToGigabytes(cacheBuff)
+	memStats.MemUsed = bytesToGigabytes(v.Used)
+	memStats.MemPct = twoDecimals(v.UsedPercent)
+
+	return func(data *system.CombinedData) {
+		data.Stats.Swap = memStats.Swap
+		data.Stats.SwapUsed = memStats.SwapUsed
+		data.Stats.Mem = memStats.Mem
+		data.Stats.MemBuffCache = memStats.MemBuffCache
+		data.Stats.MemUsed = memStats.MemUsed
+		data.Stats.MemPct = memStats.MemPct
+		data.Stats.MemZfsArc = memStats.MemZfsArc
+	}, nil
+}
+
+// collectDisk gets disk usage and I/O rates for all monitored filesystems.
+// I/O rates are calculated against the previous values stored in the hub's state.
+func (a *Agent) collectDisk(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	var diskStats system.Stats
+	var errs []error
 
 	// disk usage
-	for _, stats := range a.fsStats {
-		if d, err := disk.Usage(stats.Mountpoint); err == nil {
+	extraFs := make(map[string]*system.FsStats)
+	for name, stats := range a.fsStats {
+		if d, err := disk.UsageWithContext(ctx, stats.Mountpoint); err == nil {
 			stats.DiskTotal = bytesToGigabytes(d.Total)
 			stats.DiskUsed = bytesToGigabytes(d.Used)
 			if stats.Root {
-				systemStats.DiskTotal = bytesToGigabytes(d.Total)
-				systemStats.DiskUsed = bytesToGigabytes(d.Used)
-				systemStats.DiskPct = twoDecimals(d.UsedPercent)
+				diskStats.DiskTotal = bytesToGigabytes(d.Total)
+				diskStats.DiskUsed = bytesToGigabytes(d.Used)
+				diskStats.DiskPct = twoDecimals(d.UsedPercent)
 			}
 		} else {
 			// reset stats if error (likely unmounted)
 			slog.Error("Error getting disk stats", "name", stats.Mountpoint, "err", err)
+			errs = append(errs, fmt.Errorf("%s: %w", stats.Mountpoint, err))
 			stats.DiskTotal = 0
 			stats.DiskUsed = 0
 			stats.TotalRead = 0
 			stats.TotalWrite = 0
+			delete(hs.diskIo, name)
+		}
+		// copy extra filesystems so rates for this hub don't change other responses
+		if !stats.Root && stats.DiskTotal > 0 {
+			extraFs[name] = &system.FsStats{DiskTotal: stats.DiskTotal, DiskUsed: stats.DiskUsed}
 		}
 	}
 
 	// disk i/o
-	if ioCounters, err := disk.IOCounters(a.fsNames...); err == nil {
+	if ioCounters, err := disk.IOCountersWithContext(ctx, a.fsNames...); err == nil {
 		for _, d := range ioCounters {
 			stats := a.fsStats[d.Name]
 			if stats == nil {
 				continue
 			}
-			secondsElapsed := time.Since(stats.Time).Seconds()
-			readPerSecond := bytesToMegabytes(float64(d.ReadBytes-stats.TotalRead) / secondsElapsed)
-			writePerSecond := bytesToMegabytes(float64(d.WriteBytes-stats.TotalWrite) / secondsElapsed)
+			// take a baseline on the hub's first request, rates are reported from the next
+			prev, ok := hs.diskIo[d.Name]
+			if !ok {
+				hs.diskIo[d.Name] = diskIoState{time: time.Now(), read: d.ReadBytes, write: d.WriteBytes}
+				continue
+			}
+			secondsElapsed := time.Since(prev.time).Seconds()
+			readPerSecond := bytesToMegabytes(float64(d.ReadBytes-prev.read) / secondsElapsed)
+			writePerSecond := bytesToMegabytes(float64(d.WriteBytes-prev.write) / secondsElapsed)
 			// check for invalid values and reset stats if so
 			if readPerSecond < 0 || writePerSecond < 0 || readPerSecond > 50_000 || writePerSecond > 50_000 {
 				slog.Warn("Invalid disk I/O. Resetting.", "name", d.Name, "read", readPerSecond, "write", writePerSecond)
 				a.initializeDiskIoStats(ioCounters)
+				clear(hs.diskIo)
 				break
 			}
-			stats.Time = time.Now()
-			stats.DiskReadPs = readPerSecond
-			stats.DiskWritePs = writePerSecond
-			stats.TotalRead = d.ReadBytes
-			stats.TotalWrite = d.WriteBytes
+			hs.diskIo[d.Name] = diskIoState{time: time.Now(), read: d.ReadBytes, write: d.WriteBytes}
 			// if root filesystem, update system stats
 			if stats.Root {
-				systemStats.DiskReadPs = stats.DiskReadPs
-				systemStats.DiskWritePs = stats.DiskWritePs
+				diskStats.DiskReadPs = readPerSecond
+				diskStats.DiskWritePs = writePerSecond
+			} else if fs, ok := extraFs[d.Name]; ok {
+				fs.DiskReadPs = readPerSecond
+				fs.DiskWritePs = writePerSecond
 			}
 		}
+	} else if len(a.fsNames) > 0 {
+		errs = append(errs, err)
 	}
 
-	// network stats
-	if len(a.netInterfaces) == 0 {
+	return func(data *system.CombinedData) {
+		data.Stats.DiskTotal = diskStats.DiskTotal
+		data.Stats.DiskUsed = diskStats.DiskUsed
+		data.Stats.DiskPct = diskStats.DiskPct
+		data.Stats.DiskReadPs = diskStats.DiskReadPs
+		data.Stats.DiskWritePs = diskStats.DiskWritePs
+		data.Stats.ExtraFs = extraFs
+	}, errors.Join(errs...)
+}
+
+// collectNetwork calculates bandwidth against the previous values stored in the hub's state
+func (a *Agent) collectNetwork(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	netInterfaces := a.getNetInterfaces()
+	if len(netInterfaces) == 0 {
 		// if no network interfaces, initialize again
 		// this is a fix if agent started before network is online (#466)
 		// maybe refactor this in the future to not cache interface names at all so we
 		// don't miss an interface that's been added after agent started in any circumstance
 		a.initializeNetIoStats()
+		netInterfaces = a.getNetInterfaces()
+	}
+	netIO, err := psutilNet.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	bytesSent := uint64(0)
+	bytesRecv := uint64(0)
+	// sum all bytes sent and received
+	for _, v := range netIO {
+		// skip if not in valid network interfaces list
+		if _, exists := netInterfaces[v.Name]; !exists {
+			continue
+		}
+		bytesSent += v.BytesSent
+		bytesRecv += v.BytesRecv
+	}
+	// take a baseline on the hub's first request, bandwidth is reported from the next
+	if hs.netIoStats.Time.IsZero() {
+		hs.netIoStats = system.NetIoStats{BytesSent: bytesSent, BytesRecv: bytesRecv, Time: time.Now()}
+		return nil, nil
 	}
-	if netIO, err := psutilNet.IOCounters(true); err == nil {
-		secondsElapsed := time.Since(a.netIoStats.Time).Seconds()
-		a.netIoStats.Time = time.Now()
-		bytesSent := uint64(0)
-		bytesRecv := uint64(0)
-		// sum all bytes sent and received
+	secondsElapsed := time.Since(hs.netIoStats.Time).Seconds()
+	hs.netIoStats.Time = time.Now()
+	// add to systemStats
+	sentPerSecond := float64(bytesSent-hs.netIoStats.BytesSent) / secondsElapsed
+	recvPerSecond := float64(bytesRecv-hs.netIoStats.BytesRecv) / secondsElapsed
+	networkSentPs := bytesToMegabytes(sentPerSecond)
+	networkRecvPs := bytesToMegabytes(recvPerSecond)
+	// add check for issue (#150) where sent is a massive number
+	if networkSentPs > 10_000 || networkRecvPs > 10_000 {
+		slog.Warn("Invalid net stats. Resetting.", "sent", networkSentPs, "recv", networkRecvPs)
 		for _, v := range netIO {
-			// skip if not in valid network interfaces list
-			if _, exists := a.netInterfaces[v.Name]; !exists {
+			if _, exists := netInterfaces[v.Name]; !exists {
 				continue
 			}
-			bytesSent += v.BytesSent
-			bytesRecv += v.BytesRecv
-		}
-		// add to systemStats
-		sentPerSecond := float64(bytesSent-a.netIoStats.BytesSent) / secondsElapsed
-		recvPerSecond := float64(bytesRecv-a.netIoStats.BytesRecv) / secondsElapsed
-		networkSentPs := bytesToMegabytes(sentPerSecond)
-		networkRecvPs := bytesToMegabytes(recvPerSecond)
-		// add check for issue (#150) where sent is a massive number
-		if networkSentPs > 10_000 || networkRecvPs > 10_000 {
-			slog.Warn("Invalid net stats. Resetting.", "sent", networkSentPs, "recv", networkRecvPs)
-			for _, v := range netIO {
-				if _, exists := a.netInterfaces[v.Name]; !exists {
-					continue
-				}
-				slog.Info(v.Name, "recv", v.BytesRecv, "sent", v.BytesSent)
-			}
-			// reset network I/O stats
-			a.initializeNetIoStats()
-		} else {
-			systemStats.NetworkSent = networkSentPs
-			systemStats.NetworkRecv = networkRecvPs
-			// update netIoStats
-			a.netIoStats.BytesSent = bytesSent
-			a.netIoStats.BytesRecv = bytesRecv
+			slog.Info(v.Name, "recv", v.BytesRecv, "sent", v.BytesSent)
 		}
+		// reset network I/O stats
+		a.initializeNetIoStats()
+		hs.netIoStats = a.netIoStats
+		return nil, nil
 	}
+	// update netIoStats
+	hs.netIoStats.BytesSent = bytesSent
+	hs.netIoStats.BytesRecv = bytesRecv
 
-	// temperatures
-	// TODO: maybe refactor to methods on systemStats
-	a.updateTemperatures(&systemStats)
+	return func(data *system.CombinedData) {
+		data.Stats.NetworkSent = networkSentPs
+		data.Stats.NetworkRecv = networkRecvPs
+	}, nil
+}
 
-	// GPU data
-	if a.gpuManager != nil {
-		// reset high gpu percent
-		a.systemInfo.GpuPct = 0
-		// get current GPU data
-		if gpuData := a.gpuManager.GetCurrentData(); len(gpuData) > 0 {
-			systemStats.GPUData = gpuData
+// collectGpu gets the GPU data averaged since the previous request
+func (a *Agent) collectGpu(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	gpuData := a.gpuManager.GetCurrentData()
+	if len(gpuData) == 0 {
+		return nil, nil
+	}
+	return func(data *system.CombinedData) {
+		data.Stats.GPUData = gpuData
 
-			// add temperatures
-			if systemStats.Temperatures == nil {
-				systemStats.Temperatures = make(map[string]float64, len(gpuData))
-			}
-			highestTemp := 0.0
-			for _, gpu := range gpuData {
-				if gpu.Temperature > 0 {
-					systemStats.Temperatures[gpu.Name] = gpu.Temperature
-					if a.sensorConfig.primarySensor == gpu.Name {
-						a.systemInfo.DashboardTemp = gpu.Temperature
-					}
-					if gpu.Temperature > highestTemp {
-						highestTemp = gpu.Temperature
-					}
+		// add temperatures
+		if data.Stats.Temperatures == nil {
+			data.Stats.Temperatures = make(map[string]float64, len(gpuData))
+		}
+		highestTemp := 0.0
+		for _, gpu := range gpuData {
+			if gpu.Temperature > 0 {
+				data.Stats.Temperatures[gpu.Name] = gpu.Temperature
+				if a.sensorConfig.primarySensor == gpu.Name {
+					data.Info.DashboardTemp = gpu.Temperature
+				}
+				if gpu.Temperature > highestTemp {
+					highestTemp = gpu.Temperature
 				}
-				// update high gpu percent for dashboard
-				a.systemInfo.GpuPct = max(a.systemInfo.GpuPct, gpu.Usage)
-			}
-			// use highest temp for dashboard temp if dashboard temp is unset
-			if a.systemInfo.DashboardTemp == 0 {
-				a.systemInfo.DashboardTemp = highestTemp
 			}
+			// update high gpu percent for dashboard
+			data.Info.GpuPct = max(data.Info.GpuPct, gpu.Usage)
 		}
-	}
-
-	// update base system info
-	a.systemInfo.Cpu = systemStats.Cpu
-	a.systemInfo.MemPct = systemStats.MemPct
-	a.systemInfo.DiskPct = systemStats.DiskPct
-	a.systemInfo.Uptime, _ = host.Uptime()
-	a.systemInfo.Bandwidth = twoDecimals(systemStats.NetworkSent + systemStats.NetworkRecv)
-	slog.Debug("sysinfo", "data", a.systemInfo)
+		// use highest temp for dashboard temp if dashboard temp is unset
+		if data.Info.DashboardTemp == 0 {
+			data.Info.DashboardTemp = highestTemp
+		}
+	}, nil
+}
 
-	return systemStats
+// updateSystemInfo sets the dashboard values in the payload's system info from its stats
+func updateSystemInfo(data *system.CombinedData) {
+	data.Info.Cpu = data.Stats.Cpu
+	data.Info.MemPct = data.Stats.MemPct
+	data.Info.DiskPct = data.Stats.DiskPct
+	data.Info.Uptime, _ = host.Uptime()
+	data.Info.Bandwidth = twoDecimals(data.Stats.NetworkSent + data.Stats.NetworkRecv)
 }
 
 // Returns the size of the ZFS ARC memory cache in bytes
 func getARCSize() (uint64, error) {
-	file, err := os.Open("/proc/spl/kstat/zfs/arcstats")
+	arcstats, err := readArcStats("/proc/spl/kstat/zfs/arcstats")
 	if err != nil {
 		return 0, err
 	}
-	defer file.Close()
-
-	// Scan the lines
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "size") {
-			// Example line: size 4 15032385536
-			fields := strings.Fields(line)
-			if len(fields) < 3 {
-				return 0, err
-			}
-			// Return the size as uint64
-			return strconv.ParseUint(fields[2], 10, 64)
-		}
+	size, ok := arcstats["size"]
+	if !ok {
+		return 0, fmt.Errorf("failed to parse size field")
 	}
-
-	return 0, fmt.Errorf("failed to parse size field")
+	return size, nil
 }
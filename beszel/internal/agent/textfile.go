@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// textfileMetrics reads *.prom files in the node_exporter textfile format from
+// the TEXTFILE_DIR env var and forwards gauges and counters as custom metrics.
+type textfileMetrics struct {
+	dir string
+}
+
+// newTextfileMetrics creates textfile metrics from the TEXTFILE_DIR env var.
+// Returns nil if not set.
+func newTextfileMetrics() *textfileMetrics {
+	dir, _ := GetEnv("TEXTFILE_DIR")
+	if dir == "" {
+		return nil
+	}
+	slog.Info("TEXTFILE_DIR", "path", dir)
+	return &textfileMetrics{dir: dir}
+}
+
+// collectTextfileMetrics reads all *.prom files in the textfile directory.
+// Counters are converted to per second rates between the file updates seen by each hub.
+func (a *Agent) collectTextfileMetrics(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	tm := a.textfiles
+	paths, err := filepath.Glob(filepath.Join(tm.dir, "*.prom"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .prom files in %s", tm.dir)
+	}
+
+	values := make(map[string]float64)
+	var errs []error
+	for _, path := range paths {
+		if err := tm.readFile(path, &hs.textfileRates, values); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+		}
+	}
+	hs.textfileRates.prune()
+
+	return func(data *system.CombinedData) {
+		addCustomMetrics(data, values)
+	}, errors.Join(errs...)
+}
+
+// readFile adds the values from a single file. Values that could be parsed are
+// added even if the file contains invalid lines.
+func (tm *textfileMetrics) readFile(path string, rates *counterRates, values map[string]float64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	samples, err := parsePromText(f)
+	for _, sample := range samples {
+		if value, ok := promValue(sample, rates, info.ModTime()); ok {
+			values[sample.series] = value
+		}
+	}
+	return err
+}
@@ -0,0 +1,249 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Default time between checks for package updates
+	defaultUpdatesInterval = 6 * time.Hour
+	// Time the package manager may take to list updates
+	updatesTimeout = 2 * time.Minute
+	// Exit code of dnf / yum check-update when updates are available
+	checkUpdateAvailable = 100
+	// Exit code of needs-restarting -r when a reboot is required
+	rebootNeeded = 1
+)
+
+// updateChecker lists pending package updates on the UPDATES_INTERVAL.
+//
+// The package manager only reads its local cache, so results depend on the
+// system refreshing its package lists (apt timers, dnf-makecache, etc).
+type updateChecker struct {
+	sync.Mutex
+	interval   time.Duration
+	manager    string              // apt, dnf or yum
+	rebootPath string              // Created by Debian / Ubuntu when a reboot is required. Empty for dnf / yum
+	status     system.UpdateStatus // Result of the last check
+}
+
+// newUpdateChecker creates an updateChecker for the system package manager.
+// Returns nil if disabled with an interval of 0 or no supported package manager is found.
+func newUpdateChecker() *updateChecker {
+	interval := getEnvDuration("UPDATES_INTERVAL", defaultUpdatesInterval)
+	if interval <= 0 {
+		return nil
+	}
+	uc := &updateChecker{interval: interval}
+	for _, manager := range []string{"apt-get", "dnf", "yum"} {
+		if _, err := exec.LookPath(manager); err == nil {
+			uc.manager = strings.TrimSuffix(manager, "-get")
+			break
+		}
+	}
+	switch uc.manager {
+	case "":
+		slog.Debug("Updates", "err", "no supported package manager")
+		return nil
+	case "apt":
+		uc.rebootPath = "/var/run/reboot-required"
+	}
+	return uc
+}
+
+// start checks for updates on the interval. Blocks forever.
+func (uc *updateChecker) start() {
+	for {
+		uc.check()
+		time.Sleep(uc.interval)
+	}
+}
+
+// check lists pending updates and stores the result
+func (uc *updateChecker) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), updatesTimeout)
+	defer cancel()
+
+	var pending int
+	var security []string
+	var reboot bool
+	var err error
+	switch uc.manager {
+	case "apt":
+		var output []byte
+		if output, err = runPackageManager(ctx, "apt-get", "-s", "-o", "Debug::NoLocking=true", "dist-upgrade"); err == nil {
+			pending, security = parseAptUpgrade(output)
+		}
+	default:
+		var output []byte
+		if output, err = runPackageManager(ctx, uc.manager, "-q", "-C", "check-update"); err == nil {
+			pending = parseCheckUpdate(output)
+		}
+		if err == nil {
+			if output, err = runPackageManager(ctx, uc.manager, "-q", "-C", "updateinfo", "list", "--security"); err == nil {
+				security = parseUpdateInfo(output)
+			}
+		}
+		if err == nil {
+			reboot = needsRestarting(ctx, uc.manager)
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", updatesTimeout)
+		}
+		slog.Debug("Updates", "manager", uc.manager, "err", err)
+	}
+
+	uc.Lock()
+	defer uc.Unlock()
+	uc.status = system.UpdateStatus{Manager: uc.manager, Checked: time.Now().Unix()}
+	if err != nil {
+		uc.status.Error = err.Error()
+		return
+	}
+	uc.status.Pending = pending
+	uc.status.Security = len(security)
+	uc.status.SecurityPackages = security
+	uc.status.Reboot = reboot
+}
+
+// needsRestarting reports whether needs-restarting -r, from yum-utils or the dnf
+// plugins, says a reboot is required. A reboot is not reported if it isn't installed.
+func needsRestarting(ctx context.Context, manager string) bool {
+	name, args := manager, []string{"needs-restarting", "-r"}
+	if _, err := exec.LookPath("needs-restarting"); err == nil {
+		name, args = "needs-restarting", []string{"-r"}
+	}
+	err := exec.CommandContext(ctx, name, args...).Run()
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == rebootNeeded
+}
+
+// runPackageManager runs a package manager command in the C locale. The check-update
+// exit code for available updates is not treated as an error.
+func runPackageManager(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), "LANG=C", "LC_ALL=C")
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == checkUpdateAvailable {
+		return output, nil
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+	}
+	return output, err
+}
+
+// parseAptUpgrade parses simulated apt-get upgrade output, returning the number of
+// packages to upgrade and the names of packages with security updates. Security updates come from a
+// "-security" suite, e.g. "Inst openssl [3.0.11-1] (3.0.13-1 Debian-Security:12/stable-security [amd64])".
+// Ubuntu lists every suite with the version, separated by commas. Packages without the
+// installed version in brackets are new dependencies rather than updates.
+func parseAptUpgrade(output []byte) (pending int, security []string) {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] != "Inst" || !strings.HasPrefix(fields[2], "[") {
+			continue
+		}
+		pending++
+		line := scanner.Text()
+		start, end := strings.Index(line, "("), strings.LastIndex(line, ")")
+		if start < 0 || end < start {
+			continue
+		}
+		_, suites, _ := strings.Cut(line[start+1:end], " ")
+		if strings.Contains(strings.ToLower(suites), "-security") {
+			security = append(security, fields[1])
+		}
+	}
+	return pending, security
+}
+
+// parseCheckUpdate counts the packages in dnf / yum check-update output.
+// Lines are "name.arch version repo", wrapped after the name if it is long.
+func parseCheckUpdate(output []byte) (pending int) {
+	var carry string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		// obsoleted packages are listed again after the updates
+		if strings.HasPrefix(line, "Obsoleting") {
+			break
+		}
+		fields := strings.Fields(carry + " " + line)
+		carry = ""
+		switch {
+		case len(fields) == 1 && !strings.HasPrefix(line, " "):
+			carry = line
+		case len(fields) == 3 && strings.Contains(fields[0], "."):
+			pending++
+		}
+	}
+	return pending
+}
+
+// parseUpdateInfo returns the names of packages in dnf / yum "updateinfo list --security"
+// output. Lines are "advisory severity/Sec. name-version-release.arch".
+func parseUpdateInfo(output []byte) (security []string) {
+	seen := make(map[string]struct{})
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || !strings.Contains(fields[1], "Sec") {
+			continue
+		}
+		// strip the release and version from the end of the name
+		name := fields[2]
+		for range 2 {
+			if i := strings.LastIndex(name, "-"); i > 0 {
+				name = name[:i]
+			}
+		}
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			security = append(security, name)
+		}
+	}
+	return security
+}
+
+// collectUpdates gets the result of the last update check along with whether
+// a reboot is currently required. dnf / yum reboots are from the last check.
+func (a *Agent) collectUpdates(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	a.updates.Lock()
+	status := a.updates.status
+	a.updates.Unlock()
+	if status.Checked == 0 {
+		return nil, nil
+	}
+	if a.updates.rebootPath != "" {
+		_, err := os.Stat(a.updates.rebootPath)
+		status.Reboot = err == nil
+	}
+
+	var checkErr error
+	if status.Error != "" {
+		checkErr = errors.New(status.Error)
+	}
+	return func(data *system.CombinedData) {
+		data.Updates = &status
+	}, checkErr
+}
@@ -0,0 +1,99 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAptUpgrade(t *testing.T) {
+	output := []byte(`NOTE: This is only a simulation!
+Reading package lists...
+Building dependency tree...
+The following NEW packages will be installed:
+  linux-image-6.1.0-18-amd64
+The following packages will be upgraded:
+  curl libssl3 linux-image-amd64 openssl
+4 upgraded, 1 newly installed, 0 to remove and 0 not upgraded.
+Inst linux-image-6.1.0-18-amd64 (6.1.76-1 Debian-Security:12/stable-security [amd64])
+Inst linux-image-amd64 [6.1.69-1] (6.1.76-1 Debian-Security:12/stable-security [amd64])
+Inst openssl [3.0.11-1~deb12u1] (3.0.13-1~deb12u1 Debian-Security:12/stable-security [amd64])
+Inst libssl3 [3.0.2-0ubuntu1.14] (3.0.2-0ubuntu1.15 Ubuntu:22.04/jammy-updates, Ubuntu:22.04/jammy-security [amd64])
+Inst curl [7.88.1-10] (7.88.1-10+deb12u5 Debian:12.5/stable [amd64])
+Conf openssl (3.0.13-1~deb12u1 Debian-Security:12/stable-security [amd64])
+Conf libssl3 (3.0.2-0ubuntu1.15 Ubuntu:22.04/jammy-updates, Ubuntu:22.04/jammy-security [amd64])
+Conf curl (7.88.1-10+deb12u5 Debian:12.5/stable [amd64])
+`)
+	pending, security := parseAptUpgrade(output)
+	assert.Equal(t, 4, pending, "Expected new dependencies to not be counted")
+	assert.Equal(t, []string{"linux-image-amd64", "openssl", "libssl3"}, security)
+
+	pending, security = parseAptUpgrade([]byte("0 upgraded, 0 newly installed, 0 to remove and 0 not upgraded.\n"))
+	assert.Zero(t, pending)
+	assert.Empty(t, security)
+}
+
+func TestParseCheckUpdate(t *testing.T) {
+	output := []byte(`
+kernel-core.x86_64                      5.14.0-427.el9           baseos
+openssl.x86_64                          1:3.0.7-27.el9           baseos
+python3-some-really-long-package-name.noarch
+                                        1.2.3-1.el9              appstream
+Obsoleting Packages
+grub2-tools.x86_64                      1:2.06-80.el9            baseos
+    grub2-tools.x86_64                  1:2.06-77.el9            @baseos
+`)
+	assert.Equal(t, 3, parseCheckUpdate(output))
+	assert.Zero(t, parseCheckUpdate(nil))
+}
+
+func TestParseUpdateInfo(t *testing.T) {
+	output := []byte(`RHSA-2024:1234 Important/Sec. kernel-core-5.14.0-427.el9.x86_64
+RHSA-2024:1234 Important/Sec. kernel-modules-5.14.0-427.el9.x86_64
+RHSA-2024:2222 Moderate/Sec.  openssl-1:3.0.7-27.el9.x86_64
+RHSA-2024:3333 Moderate/Sec.  openssl-1:3.0.7-27.el9.x86_64
+RHBA-2024:4444 bugfix         tzdata-2024a-1.el9.noarch
+`)
+	assert.Equal(t, []string{"kernel-core", "kernel-modules", "openssl"}, parseUpdateInfo(output))
+}
+
+func TestCollectUpdates(t *testing.T) {
+	rebootPath := filepath.Join(t.TempDir(), "reboot-required")
+	a := &Agent{updates: &updateChecker{rebootPath: rebootPath}}
+
+	apply, err := a.collectUpdates(context.Background(), &hubState{})
+	assert.NoError(t, err)
+	assert.Nil(t, apply, "Expected nothing before the first check")
+
+	a.updates.status = system.UpdateStatus{Manager: "apt", Pending: 5, Security: 2, SecurityPackages: []string{"openssl", "libssl3"}, Checked: 1_700_086_400}
+	apply, err = a.collectUpdates(context.Background(), &hubState{})
+	require.NoError(t, err)
+	data := &system.CombinedData{}
+	apply(data)
+	assert.Equal(t, &system.UpdateStatus{Manager: "apt", Pending: 5, Security: 2, SecurityPackages: []string{"openssl", "libssl3"}, Checked: 1_700_086_400}, data.Updates)
+
+	require.NoError(t, os.WriteFile(rebootPath, []byte("*** System restart required ***\n"), 0o644))
+	apply, _ = a.collectUpdates(context.Background(), &hubState{})
+	apply(data)
+	assert.True(t, data.Updates.Reboot)
+	assert.False(t, a.updates.status.Reboot, "Reboot should not be stored on the checker")
+
+	a.updates.status = system.UpdateStatus{Manager: "dnf", Checked: 1_700_086_400, Error: "exit status 1: Cache-only enabled but no cache"}
+	_, err = a.collectUpdates(context.Background(), &hubState{})
+	assert.EqualError(t, err, "exit status 1: Cache-only enabled but no cache")
+
+	// dnf / yum reboots come from the last check
+	a.updates = &updateChecker{status: system.UpdateStatus{Manager: "dnf", Checked: 1_700_086_400, Reboot: true}}
+	apply, err = a.collectUpdates(context.Background(), &hubState{})
+	require.NoError(t, err)
+	apply(data)
+	assert.True(t, data.Updates.Reboot)
+}
@@ -0,0 +1,255 @@
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// zfsReader reads ARC efficiency from arcstats and pool health from zpool
+type zfsReader struct {
+	arcPath string // Path of the arcstats kstat file
+	zpool   string // Path of the zpool command, empty if not installed
+}
+
+// newZfsReader creates a zfsReader if the system has arcstats or the zpool command
+func newZfsReader() *zfsReader {
+	zr := &zfsReader{arcPath: "/proc/spl/kstat/zfs/arcstats"}
+	zr.zpool, _ = exec.LookPath("zpool")
+	if _, err := os.Stat(zr.arcPath); err != nil && zr.zpool == "" {
+		return nil
+	}
+	return zr
+}
+
+// collectZfs gets the ARC hit ratios and the status of each pool. The ARC hit
+// ratios are still sent if zpool fails.
+func (a *Agent) collectZfs(ctx context.Context, hs *hubState) (func(*system.CombinedData), error) {
+	zr := a.zfsStats
+	var arc *system.ArcStats
+	if arcstats, err := readArcStats(zr.arcPath); err == nil {
+		arc = arcStats(arcstats, &hs.arcRates, time.Now())
+	}
+	var pools []system.ZfsPool
+	var err error
+	if zr.zpool != "" {
+		pools, err = zr.readPools(ctx)
+	}
+	return func(data *system.CombinedData) {
+		data.Stats.Arc = arc
+		data.ZfsPools = pools
+	}, err
+}
+
+// readArcStats reads the named values of the arcstats kstat file
+func readArcStats(path string) (map[string]uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stats := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Example line: size 4 15032385536
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		if value, err := strconv.ParseUint(fields[2], 10, 64); err == nil {
+			stats[fields[0]] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, fmt.Errorf("no values in %s", path)
+	}
+	return stats, nil
+}
+
+// arcStats calculates the ARC and L2ARC hit ratios since the hub's previous collection.
+// Ratios are zero until there are two samples or if there were no reads.
+func arcStats(arcstats map[string]uint64, rates *counterRates, now time.Time) *system.ArcStats {
+	arc := &system.ArcStats{L2Size: bytesToGigabytes(arcstats["l2_size"])}
+	arc.Hit = hitRatio(rates, "arc", arcstats["hits"], arcstats["misses"], now)
+	if arc.L2Size > 0 {
+		arc.L2Hit = hitRatio(rates, "l2", arcstats["l2_hits"], arcstats["l2_misses"], now)
+	}
+	return arc
+}
+
+// hitRatio returns the percent of hits since the previous collection
+func hitRatio(rates *counterRates, series string, hits, misses uint64, now time.Time) float64 {
+	hitRate, ok1 := rates.rate(series+"_hits", float64(hits), now)
+	missRate, ok2 := rates.rate(series+"_misses", float64(misses), now)
+	if !ok1 || !ok2 || hitRate+missRate == 0 {
+		return 0
+	}
+	return twoDecimals(hitRate / (hitRate + missRate) * 100)
+}
+
+// readPools lists the pools with zpool list and adds their scan and device
+// errors from zpool status
+func (zr *zfsReader) readPools(ctx context.Context) ([]system.ZfsPool, error) {
+	list, err := runZpool(ctx, zr.zpool, "list", "-Hp", "-o", "name,health,size,alloc,frag,cap")
+	if err != nil {
+		return nil, err
+	}
+	pools := parseZpoolList(list)
+	if len(pools) == 0 {
+		return nil, nil
+	}
+	status, err := runZpool(ctx, zr.zpool, "status", "-p")
+	if err != nil {
+		return nil, err
+	}
+	parseZpoolStatus(status, pools)
+	return pools, nil
+}
+
+// runZpool runs a zpool command, adding its stderr to the error
+func runZpool(ctx context.Context, zpool string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, zpool, args...)
+	cmd.Env = append(os.Environ(), "LANG=C", "LC_ALL=C")
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+	}
+	return output, err
+}
+
+// parseZpoolList parses the tab separated output of zpool list -Hp
+func parseZpoolList(output []byte) []system.ZfsPool {
+	var pools []system.ZfsPool
+	for line := range strings.Lines(string(output)) {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) != 6 {
+			continue
+		}
+		pool := system.ZfsPool{Name: fields[0], Health: fields[1]}
+		size, _ := strconv.ParseUint(fields[2], 10, 64)
+		alloc, _ := strconv.ParseUint(fields[3], 10, 64)
+		pool.Size = bytesToGigabytes(size)
+		pool.Alloc = bytesToGigabytes(alloc)
+		// fragmentation is "-" for pools without spacemap histograms
+		pool.Frag, _ = strconv.ParseFloat(strings.TrimSuffix(fields[4], "%"), 64)
+		pool.Cap, _ = strconv.ParseFloat(strings.TrimSuffix(fields[5], "%"), 64)
+		pools = append(pools, pool)
+	}
+	return pools
+}
+
+// scrubErrorsRe matches the errors found by the last scrub in a scan line
+var scrubErrorsRe = regexp.MustCompile(`with (\d+) errors`)
+
+// parseZpoolStatus sets the scan results and device errors of pools from zpool status.
+// Error counts are summed over leaf devices, since vdev and pool rows repeat them.
+func parseZpoolStatus(output []byte, pools []system.ZfsPool) {
+	var pool *system.ZfsPool
+	var rows []zpoolRow
+	finish := func() {
+		if pool != nil {
+			addDeviceErrors(pool, rows)
+		}
+		pool, rows = nil, nil
+	}
+	inConfig := false
+	for line := range strings.Lines(string(output)) {
+		line = strings.TrimRight(line, "\n")
+		key, value, _ := strings.Cut(strings.TrimSpace(line), ":")
+		switch {
+		case key == "pool":
+			finish()
+			inConfig = false
+			name := strings.TrimSpace(value)
+			for i := range pools {
+				if pools[i].Name == name {
+					pool = &pools[i]
+				}
+			}
+		case pool == nil:
+			continue
+		case key == "scan":
+			pool.Scan = strings.TrimSpace(value)
+			if m := scrubErrorsRe.FindStringSubmatch(pool.Scan); m != nil {
+				pool.ScrubErrors, _ = strconv.Atoi(m[1])
+			}
+		case key == "config":
+			inConfig = true
+		case key == "errors":
+			inConfig = false
+			pool.DataErrors = !strings.HasPrefix(strings.TrimSpace(value), "No known data errors")
+		case inConfig:
+			if row, ok := parseZpoolRow(line); ok {
+				rows = append(rows, row)
+			}
+		}
+	}
+	finish()
+}
+
+// zpoolRow is a device row in the config section of zpool status
+type zpoolRow struct {
+	indent int
+	device system.ZfsDevice
+}
+
+// parseZpoolRow parses a config row such as "    sdb  FAULTED  3  0  12  too many errors".
+// Headers and rows without error counts, such as spares, are skipped.
+func parseZpoolRow(line string) (zpoolRow, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return zpoolRow{}, false
+	}
+	counts := [3]uint64{}
+	for i := range counts {
+		value, err := strconv.ParseUint(fields[i+2], 10, 64)
+		if err != nil {
+			return zpoolRow{}, false
+		}
+		counts[i] = value
+	}
+	return zpoolRow{
+		indent: len(line) - len(strings.TrimLeft(line, " \t")),
+		device: system.ZfsDevice{
+			Name:     fields[0],
+			State:    fields[1],
+			Read:     counts[0],
+			Write:    counts[1],
+			Checksum: counts[2],
+		},
+	}, true
+}
+
+// addDeviceErrors sums the errors of leaf devices and keeps devices which are
+// not online or have errors
+func addDeviceErrors(pool *system.ZfsPool, rows []zpoolRow) {
+	for i, row := range rows {
+		// leaf devices are not followed by a more indented row
+		leaf := i == len(rows)-1 || rows[i+1].indent <= row.indent
+		d := row.device
+		if leaf {
+			pool.ReadErrors += d.Read
+			pool.WriteErrors += d.Write
+			pool.ChecksumErrors += d.Checksum
+		}
+		if i > 0 && (d.State != "ONLINE" || d.Read+d.Write+d.Checksum > 0) {
+			pool.Devices = append(pool.Devices, d)
+		}
+	}
+}
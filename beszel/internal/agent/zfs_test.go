@@ -0,0 +1,118 @@
+//go:build testing
+// +build testing
+
+package agent
+
+import (
+	"beszel/internal/entities/system"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const zpoolStatusOutput = `  pool: backup
+ state: ONLINE
+  scan: scrub repaired 0B in 00:10:23 with 0 errors on Sun Oct 13 00:34:24 2024
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	backup      ONLINE       0     0     0
+	  sdc       ONLINE       0     0     0
+
+errors: No known data errors
+
+  pool: tank
+ state: DEGRADED
+status: One or more devices are faulted in response to persistent errors.
+action: Replace the faulted device, or use 'zpool clear' to mark the device
+	repaired.
+  scan: scrub repaired 128K in 01:02:03 with 2 errors on Sun Oct 13 01:02:03 2024
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	tank        DEGRADED     0     0     0
+	  mirror-0  DEGRADED     0     0     0
+	    sda     ONLINE       0     0     1
+	    sdb     FAULTED      3     0    12  too many errors
+	logs
+	  nvme0n1   ONLINE       0     0     0
+	spares
+	  sdd       AVAIL
+
+errors: 2 data errors, use '-v' for a list
+`
+
+func TestParseZpool(t *testing.T) {
+	pools := parseZpoolList([]byte("backup\tONLINE\t1000000000\t250000000\t-\t25\n" +
+		"tank\tDEGRADED\t4000000000\t3000000000\t12\t75\n"))
+	require.Len(t, pools, 2)
+	assert.Equal(t, "tank", pools[1].Name)
+	assert.Equal(t, 12.0, pools[1].Frag)
+	assert.Equal(t, 75.0, pools[1].Cap)
+	assert.Zero(t, pools[0].Frag, "Expected no fragmentation for -")
+
+	parseZpoolStatus([]byte(zpoolStatusOutput), pools)
+	assert.Equal(t, "scrub repaired 0B in 00:10:23 with 0 errors on Sun Oct 13 00:34:24 2024", pools[0].Scan)
+	assert.Zero(t, pools[0].ChecksumErrors)
+	assert.Empty(t, pools[0].Devices)
+	assert.False(t, pools[0].DataErrors)
+
+	tank := pools[1]
+	assert.Equal(t, 2, tank.ScrubErrors)
+	assert.Equal(t, uint64(13), tank.ChecksumErrors, "Expected errors to be summed over leaf devices")
+	assert.Equal(t, uint64(3), tank.ReadErrors)
+	assert.True(t, tank.DataErrors)
+	assert.Equal(t, []system.ZfsDevice{
+		{Name: "mirror-0", State: "DEGRADED"},
+		{Name: "sda", State: "ONLINE", Checksum: 1},
+		{Name: "sdb", State: "FAULTED", Read: 3, Checksum: 12},
+	}, tank.Devices)
+}
+
+func TestArcStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arcstats")
+	write := func(hits, misses string) {
+		content := "13 1 0x01 123 33456 1234 5678\nname type data\nhits 4 " + hits + "\nmisses 4 " + misses +
+			"\nsize 4 1073741824\nl2_hits 4 0\nl2_misses 4 0\nl2_size 4 0\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("1000", "100")
+	rates := &counterRates{}
+	start := time.Now()
+
+	arcstats, err := readArcStats(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1073741824), arcstats["size"])
+	arc := arcStats(arcstats, rates, start)
+	assert.Zero(t, arc.Hit, "Expected no ratio from the first sample")
+
+	write("1900", "200")
+	arcstats, err = readArcStats(path)
+	require.NoError(t, err)
+	arc = arcStats(arcstats, rates, start.Add(time.Minute))
+	assert.Equal(t, 90.0, arc.Hit)
+	assert.Zero(t, arc.L2Hit, "Expected no L2ARC ratio without an L2ARC")
+}
+
+func TestCollectZfsPoolError(t *testing.T) {
+	zpool, err := exec.LookPath("false")
+	if err != nil {
+		t.Skip("false command not found")
+	}
+	path := filepath.Join(t.TempDir(), "arcstats")
+	require.NoError(t, os.WriteFile(path, []byte("name type data\nhits 4 1000\nmisses 4 100\n"), 0o644))
+	a := &Agent{zfsStats: &zfsReader{arcPath: path, zpool: zpool}}
+
+	apply, err := a.collectZfs(t.Context(), &hubState{})
+	assert.Error(t, err)
+	require.NotNil(t, apply, "Expected the ARC stats to be sent when zpool fails")
+	data := &system.CombinedData{}
+	apply(data)
+	assert.NotNil(t, data.Stats.Arc)
+	assert.Nil(t, data.ZfsPools)
+}
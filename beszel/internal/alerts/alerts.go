@@ -2,6 +2,7 @@
 package alerts
 
 import (
+	"beszel/internal/entities/system"
 	"fmt"
 	"net/mail"
 	"net/url"
@@ -31,17 +32,25 @@ type AlertMessageData struct {
 }
 
 type UserNotificationSettings struct {
-	Emails   []string `json:"emails"`
-	Webhooks []string `json:"webhooks"`
+	Emails        []string `json:"emails"`
+	Webhooks      []string `json:"webhooks"`
+	LoginNetworks []string `json:"loginNetworks"` // Known login source networks (CIDR or address) for Login alerts
+	LoginUsers    []string `json:"loginUsers"`    // Users whose logins always trigger Login alerts
 }
 
 type SystemAlertStats struct {
-	Cpu          float64            `json:"cpu"`
-	Mem          float64            `json:"mp"`
-	Disk         float64            `json:"dp"`
-	NetSent      float64            `json:"ns"`
-	NetRecv      float64            `json:"nr"`
-	Temperatures map[string]float32 `json:"t"`
+	Cpu          float64                      `json:"cpu"`
+	Mem          float64                      `json:"mp"`
+	Disk         float64                      `json:"dp"`
+	NetSent      float64                      `json:"ns"`
+	NetRecv      float64                      `json:"nr"`
+	Temperatures map[string]float32           `json:"t"`
+	Custom       map[string]float32           `json:"cm"`
+	Probes       map[string]system.ProbeStats `json:"pr"`
+	TcpStates    map[string]float64           `json:"tcp"`
+	Fans         map[string]float32           `json:"fan"`
+	VoltageAlarm float64                      `json:"va"`
+	Throttles    float64                      `json:"thr"`
 }
 
 type SystemAlertData struct {
@@ -51,12 +60,14 @@ type SystemAlertData struct {
 	unit         string
 	val          float64
 	threshold    float64
+	invert       bool // triggers when the value falls below the threshold rather than above
 	triggered    bool
 	time         time.Time
 	count        uint8
 	min          uint8
 	mapSums      map[string]float32
 	descriptor   string // override descriptor in notification body (for temp sensor, disk partition, etc)
+	metric       string // custom metric name or glob the alert applies to
 }
 
 // notification services that support title param
@@ -0,0 +1,86 @@
+//go:build testing
+// +build testing
+
+package alerts_test
+
+import (
+	"beszel/internal/entities/system"
+	"beszel/internal/tests"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createRecord saves a record with the given fields to a collection
+func createRecord(t *testing.T, hub *tests.TestHub, collectionName string, fields map[string]any) *core.Record {
+	collection, err := hub.FindCachedCollectionByNameOrId(collectionName)
+	require.NoError(t, err)
+	record := core.NewRecord(collection)
+	for key, value := range fields {
+		record.Set(key, value)
+	}
+	require.NoError(t, hub.Save(record))
+	return record
+}
+
+func TestCustomMetricAlerts(t *testing.T) {
+	hub, err := tests.NewTestHub()
+	require.NoError(t, err)
+	defer hub.Cleanup()
+
+	users, err := hub.FindAllRecords("users", dbx.NewExp("id != ''"))
+	require.NoError(t, err)
+	require.NotEmpty(t, users)
+	user := users[0]
+	systemRecord := createRecord(t, hub, "systems", map[string]any{
+		"name":   "custom-metrics",
+		"host":   "custom-metrics.example.com",
+		"port":   "45876",
+		"status": "up",
+		"users":  []string{user.Id},
+	})
+	newAlert := func(metric string, value float64) *core.Record {
+		return createRecord(t, hub, "alerts", map[string]any{
+			"system": systemRecord.Id,
+			"user":   user.Id,
+			"name":   "Custom",
+			"metric": metric,
+			"value":  value,
+			"min":    1,
+		})
+	}
+	queue := newAlert("queue_depth", 10)
+	lag := newAlert("replication_lag{*}", 5)
+	temp := newAlert("room_temp", 30)
+	missing := newAlert("", 1)
+
+	data := &system.CombinedData{Stats: system.Stats{CustomMetrics: map[string]float64{
+		"queue_depth":                    20,
+		`replication_lag{replica="db1"}`: 2,
+		`replication_lag{replica="db2"}`: 7,
+		"room_temp":                      24,
+	}}}
+	require.NoError(t, hub.HandleSystemAlerts(systemRecord, data))
+
+	triggered := func(alert *core.Record) bool {
+		record, err := hub.FindRecordById("alerts", alert.Id)
+		require.NoError(t, err)
+		return record.GetBool("triggered")
+	}
+	assert.Eventually(t, func() bool { return triggered(queue) && triggered(lag) }, 5*time.Second, 10*time.Millisecond,
+		"Expected alerts to trigger on their own metrics")
+	assert.False(t, triggered(temp), "Expected a metric below its threshold to not trigger from other metrics")
+	assert.False(t, triggered(missing), "Expected an alert without a metric to not trigger")
+
+	// lag alert resolves once the matching series recover, even if other metrics stay high
+	data.Stats.CustomMetrics[`replication_lag{replica="db2"}`] = 1
+	lag, err = hub.FindRecordById("alerts", lag.Id)
+	require.NoError(t, err)
+	require.NoError(t, hub.HandleSystemAlerts(systemRecord, data))
+	assert.Eventually(t, func() bool { return !triggered(lag) }, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, triggered(queue))
+}
@@ -0,0 +1,159 @@
+//go:build testing
+// +build testing
+
+package alerts_test
+
+import (
+	"beszel/internal/entities/system"
+	"beszel/internal/tests"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDeviceAlert creates a system with an alert of the given name for a user who
+// is notified by email
+func newDeviceAlert(t *testing.T, hub *tests.TestHub, name string) *core.Record {
+	users, err := hub.FindAllRecords("users", dbx.NewExp("id != ''"))
+	require.NoError(t, err)
+	require.NotEmpty(t, users)
+	user := users[0]
+	settings, err := hub.FindFirstRecordByFilter("user_settings", "user={:user}", dbx.Params{"user": user.Id})
+	if err != nil {
+		settings = createRecord(t, hub, "user_settings", map[string]any{"user": user.Id})
+	}
+	settings.Set("settings", map[string]any{"emails": []string{"admin@example.com"}, "webhooks": []string{}})
+	require.NoError(t, hub.Save(settings))
+
+	systemRecord := createRecord(t, hub, "systems", map[string]any{
+		"name":   "storage",
+		"host":   "storage.example.com",
+		"port":   "45876",
+		"status": "up",
+		"users":  []string{user.Id},
+	})
+	createRecord(t, hub, "alerts", map[string]any{
+		"system": systemRecord.Id,
+		"user":   user.Id,
+		"name":   name,
+	})
+	return systemRecord
+}
+
+// handleDeviceAlerts handles the data for the system and waits for the alert's
+// emails to be sent
+func handleDeviceAlerts(t *testing.T, hub *tests.TestHub, systemRecord *core.Record, data *system.CombinedData, emails int) {
+	require.NoError(t, hub.HandleSystemAlerts(systemRecord, data))
+	assert.Eventually(t, func() bool { return hub.TestMailer.TotalSend() == emails }, 5*time.Second, 10*time.Millisecond,
+		"Expected %d emails", emails)
+	// let an unexpected email be sent before the next check
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, emails, hub.TestMailer.TotalSend())
+}
+
+func TestPoolAlertNewProblems(t *testing.T) {
+	hub, err := tests.NewTestHub()
+	require.NoError(t, err)
+	defer hub.Cleanup()
+	systemRecord := newDeviceAlert(t, hub, "Pool")
+
+	data := &system.CombinedData{ZfsPools: []system.ZfsPool{
+		{Name: "tank", Health: "DEGRADED"},
+		{Name: "backup", Health: "ONLINE"},
+	}}
+	handleDeviceAlerts(t, hub, systemRecord, data, 1)
+
+	// the same problem with more errors is not notified again
+	data.ZfsPools[0].ChecksumErrors = 3
+	handleDeviceAlerts(t, hub, systemRecord, data, 1)
+
+	// a second pool failing is notified while triggered
+	data.ZfsPools[1].Health = "FAULTED"
+	handleDeviceAlerts(t, hub, systemRecord, data, 2)
+
+	// a pool recovering and failing again is notified
+	data.ZfsPools[1].Health = "ONLINE"
+	handleDeviceAlerts(t, hub, systemRecord, data, 2)
+	data.ZfsPools[1].Health = "FAULTED"
+	handleDeviceAlerts(t, hub, systemRecord, data, 3)
+
+	// resolved once all pools are healthy
+	data.ZfsPools[0] = system.ZfsPool{Name: "tank", Health: "ONLINE"}
+	data.ZfsPools[1].Health = "ONLINE"
+	handleDeviceAlerts(t, hub, systemRecord, data, 4)
+}
+
+func TestRaidAlertNewProblems(t *testing.T) {
+	hub, err := tests.NewTestHub()
+	require.NoError(t, err)
+	defer hub.Cleanup()
+	systemRecord := newDeviceAlert(t, hub, "Raid")
+
+	data := &system.CombinedData{Raid: []system.RaidArray{
+		{Name: "md0", Level: "raid6", State: "active", Total: 6, Active: 5, Degraded: true, Failed: []string{"sdb"}},
+		{Name: "md1", Level: "raid1", State: "active", Total: 2, Active: 2},
+	}}
+	handleDeviceAlerts(t, hub, systemRecord, data, 1)
+
+	// recovery progress is not notified
+	data.Raid[0].Sync, data.Raid[0].SyncPct = "recovery", 12.5
+	handleDeviceAlerts(t, hub, systemRecord, data, 1)
+
+	// another device failing in the same array is notified
+	data.Raid[0].Active, data.Raid[0].Failed = 4, []string{"sdb", "sdc"}
+	handleDeviceAlerts(t, hub, systemRecord, data, 2)
+
+	// a second array degrading is notified
+	data.Raid[1].Active, data.Raid[1].Degraded = 1, true
+	handleDeviceAlerts(t, hub, systemRecord, data, 3)
+
+	data.Raid[0] = system.RaidArray{Name: "md0", Level: "raid6", State: "active", Total: 6, Active: 6}
+	data.Raid[1] = system.RaidArray{Name: "md1", Level: "raid1", State: "active", Total: 2, Active: 2}
+	handleDeviceAlerts(t, hub, systemRecord, data, 4)
+}
+
+func TestSmartAlertNewProblems(t *testing.T) {
+	hub, err := tests.NewTestHub()
+	require.NoError(t, err)
+	defer hub.Cleanup()
+	systemRecord := newDeviceAlert(t, hub, "Smart")
+
+	data := &system.CombinedData{Smart: []system.SmartDevice{
+		{Name: "/dev/sda", Health: "PASSED", Failing: []string{"End-to-End_Error (now)"}},
+		{Name: "/dev/sdb", Health: "PASSED"},
+	}}
+	handleDeviceAlerts(t, hub, systemRecord, data, 1)
+
+	// a second disk failing is notified while triggered
+	data.Smart[1].Health = "FAILED"
+	handleDeviceAlerts(t, hub, systemRecord, data, 2)
+	handleDeviceAlerts(t, hub, systemRecord, data, 2)
+
+	data.Smart[0].Failing, data.Smart[1].Health = nil, "PASSED"
+	handleDeviceAlerts(t, hub, systemRecord, data, 3)
+}
+
+func TestLinkAlertNewProblems(t *testing.T) {
+	hub, err := tests.NewTestHub()
+	require.NoError(t, err)
+	defer hub.Cleanup()
+	systemRecord := newDeviceAlert(t, hub, "Link")
+
+	data := &system.CombinedData{Links: []system.NetLink{
+		{Name: "eth0", State: "up", Speed: 1000, MaxSpeed: 10000},
+		{Name: "eth1", State: "up", Speed: 1000, MaxSpeed: 1000},
+	}}
+	handleDeviceAlerts(t, hub, systemRecord, data, 1)
+
+	// a second link going down is notified while triggered
+	data.Links[1] = system.NetLink{Name: "eth1", State: "down", MaxSpeed: 1000}
+	handleDeviceAlerts(t, hub, systemRecord, data, 2)
+
+	data.Links[0].Speed = 10000
+	data.Links[1] = system.NetLink{Name: "eth1", State: "up", Speed: 1000, MaxSpeed: 1000}
+	handleDeviceAlerts(t, hub, systemRecord, data, 3)
+}
@@ -0,0 +1,94 @@
+package alerts
+
+import (
+	"beszel/internal/entities/system"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// handleLoginAlert sends a notification for each new login from a source outside the
+// user's known networks or by one of the user's watched users. If neither is
+// configured, every new login is sent. Logins are events, so the alert is never
+// left triggered.
+func (am *AlertManager) handleLoginAlert(systemRecord, alertRecord *core.Record, logins []system.Session) {
+	var newLogins []system.Session
+	for _, l := range logins {
+		if l.New {
+			newLogins = append(newLogins, l)
+		}
+	}
+	if len(newLogins) == 0 {
+		return
+	}
+
+	userID := alertRecord.GetString("user")
+	settings := UserNotificationSettings{}
+	if record, err := am.app.FindFirstRecordByFilter("user_settings", "user={:user}", dbx.Params{"user": userID}); err == nil {
+		_ = record.UnmarshalJSONField("settings", &settings)
+	}
+	networks := parseLoginNetworks(settings.LoginNetworks)
+
+	systemName := systemRecord.GetString("name")
+	for _, l := range newLogins {
+		reason := loginAlertReason(l, networks, settings.LoginUsers)
+		if reason == "" {
+			continue
+		}
+		host := l.Host
+		if host == "" {
+			host = "local"
+		}
+		go am.SendAlert(AlertMessageData{
+			UserID:   userID,
+			Title:    fmt.Sprintf("%s login by %s", systemName, l.User),
+			Message:  fmt.Sprintf("%s logged in on %s from %s at %s (%s).", l.User, l.Line, host, time.Unix(l.Time, 0).UTC().Format(time.RFC3339), reason),
+			Link:     am.app.Settings().Meta.AppURL + "/system/" + url.PathEscape(systemName),
+			LinkText: "View " + systemName,
+		})
+	}
+}
+
+// loginAlertReason returns why a login should be sent, or an empty string if it should not
+func loginAlertReason(login system.Session, networks []netip.Prefix, users []string) string {
+	if slices.Contains(users, login.User) {
+		return "watched user"
+	}
+	if len(networks) == 0 {
+		if len(users) == 0 {
+			return "new login"
+		}
+		return ""
+	}
+	// local logins and hostnames can't be matched to a network
+	addr, err := netip.ParseAddr(login.Host)
+	if err != nil {
+		return ""
+	}
+	for _, network := range networks {
+		if network.Contains(addr.Unmap()) {
+			return ""
+		}
+	}
+	return "unknown source network"
+}
+
+// parseLoginNetworks parses CIDR networks or single addresses, skipping invalid entries
+func parseLoginNetworks(values []string) []netip.Prefix {
+	var networks []netip.Prefix
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			networks = append(networks, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(value); err == nil {
+			networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	return networks
+}
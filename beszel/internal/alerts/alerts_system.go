@@ -4,6 +4,8 @@ import (
 	"beszel/internal/entities/system"
 	"fmt"
 	"net/url"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -30,9 +32,56 @@ func (am *AlertManager) HandleSystemAlerts(systemRecord *core.Record, data *syst
 	for _, alertRecord := range alertRecords {
 		name := alertRecord.GetString("name")
 		var val float64
+		var descriptor string
+		var invert bool
+		var metric string
 		unit := "%"
 
 		switch name {
+		case "Collector":
+			// not threshold based, evaluated from the failure times stored on the system
+			am.handleCollectorAlert(systemRecord, alertRecord, now)
+			continue
+		case "Certificate":
+			// threshold is days remaining, so it triggers below the value rather than above
+			am.handleCertificateAlert(systemRecord, alertRecord, data.Certificates)
+			continue
+		case "Listener":
+			// not threshold based, evaluated from the first seen times stored on the system
+			am.handleListenerAlert(systemRecord, alertRecord, now)
+			continue
+		case "Login":
+			// not threshold based, sent for each new login matching the user's login rules
+			am.handleLoginAlert(systemRecord, alertRecord, data.Logins)
+			continue
+		case "Updates":
+			// threshold is days security updates have been pending
+			am.handleUpdatesAlert(systemRecord, alertRecord, data.Updates, now)
+			continue
+		case "Battery":
+			// not threshold based, triggers as soon as the system is running on battery
+			am.handleBatteryAlert(systemRecord, alertRecord, data.Power)
+			continue
+		case "Runtime":
+			// threshold is minutes of battery runtime, so it triggers below the value
+			am.handleRuntimeAlert(systemRecord, alertRecord, data.Power)
+			continue
+		case "Link":
+			// not threshold based, triggers on links which are down or slower than before
+			am.handleLinkAlert(systemRecord, alertRecord, data.Links)
+			continue
+		case "Raid":
+			// not threshold based, triggers on degraded arrays or failed devices
+			am.handleRaidAlert(systemRecord, alertRecord, data.Raid)
+			continue
+		case "Smart":
+			// not threshold based, triggers on failed SMART health or attributes
+			am.handleSmartAlert(systemRecord, alertRecord, data.Smart)
+			continue
+		case "Pool":
+			// not threshold based, triggers on unhealthy pools or device errors
+			am.handlePoolAlert(systemRecord, alertRecord, data.ZfsPools)
+			continue
 		case "CPU":
 			val = data.Info.Cpu
 		case "Memory":
@@ -55,15 +104,58 @@ func (am *AlertManager) HandleSystemAlerts(systemRecord *core.Record, data *syst
 			}
 			val = data.Info.DashboardTemp
 			unit = "°C"
+		case "Custom":
+			// only the series matching the alert's metric are compared to its threshold
+			metric = alertRecord.GetString("metric")
+			var ok bool
+			if val, descriptor, ok = highestCustomMetric(data.Stats.CustomMetrics, metric); !ok {
+				continue
+			}
+			unit = ""
+		case "Probe":
+			if len(data.Stats.Probes) == 0 {
+				continue
+			}
+			val, descriptor = highestProbeFailure(data.Stats.Probes, data.Info.ProbeErrors)
+		case "CloseWait":
+			if data.Stats.TcpStates == nil {
+				continue
+			}
+			val = data.Stats.TcpStates["CLOSE_WAIT"]
+			unit = ""
+		case "TimeWait":
+			if data.Stats.TcpStates == nil {
+				continue
+			}
+			val = data.Stats.TcpStates["TIME_WAIT"]
+			unit = ""
+		case "Fan":
+			if len(data.Stats.Fans) == 0 {
+				continue
+			}
+			val, descriptor = lowestFanSpeed(data.Stats.Fans)
+			unit = " RPM"
+			invert = true
+		case "Voltage":
+			if data.Stats.Voltages == nil {
+				continue
+			}
+			val = data.Stats.VoltageAlarms
+			descriptor = "Voltages out of range"
+			unit = ""
+		case "Throttle":
+			val = data.Stats.Throttles
+			descriptor = "Thermal throttling"
+			unit = " events/min"
 		}
 
 		triggered := alertRecord.GetBool("triggered")
 		threshold := alertRecord.GetFloat("value")
 
 		// CONTINUE
-		// IF alert is not triggered and curValue is less than threshold
-		// OR alert is triggered and curValue is greater than threshold
-		if (!triggered && val <= threshold) || (triggered && val > threshold) {
+		// IF alert is not triggered and curValue does not exceed threshold
+		// OR alert is triggered and curValue still exceeds threshold
+		if triggered == exceedsThreshold(val, threshold, invert) {
 			// log.Printf("Skipping alert %s: val %f | threshold %f | triggered %v\n", name, val, threshold, triggered)
 			continue
 		}
@@ -76,14 +168,17 @@ func (am *AlertManager) HandleSystemAlerts(systemRecord *core.Record, data *syst
 			name:         name,
 			unit:         unit,
 			val:          val,
+			descriptor:   descriptor,
 			threshold:    threshold,
+			invert:       invert,
 			triggered:    triggered,
 			min:          min,
+			metric:       metric,
 		}
 
 		// send alert immediately if min is 1 - no need to sum up values.
 		if min == 1 {
-			alert.triggered = val > threshold
+			alert.triggered = exceedsThreshold(val, threshold, invert)
 			go am.sendSystemAlert(alert)
 			continue
 		}
@@ -143,6 +238,8 @@ func (am *AlertManager) HandleSystemAlerts(systemRecord *core.Record, data *syst
 		stat := systemStats[i]
 		// subtract 10 seconds to give a small time buffer
 		systemStatsCreation := stat.Created.Time().Add(-time.Second * 10)
+		// reset so map values from the previous record are not carried over
+		stats = SystemAlertStats{}
 		if err := json.Unmarshal(stat.Stats, &stats); err != nil {
 			return err
 		}
@@ -191,6 +288,37 @@ func (am *AlertManager) HandleSystemAlerts(systemRecord *core.Record, data *syst
 					}
 					alert.mapSums[key] += temp
 				}
+			case "Custom":
+				if alert.mapSums == nil {
+					alert.mapSums = make(map[string]float32, len(stats.Custom))
+				}
+				for key, value := range stats.Custom {
+					if metricMatches(alert.metric, key) {
+						alert.mapSums[key] += value
+					}
+				}
+			case "Probe":
+				if alert.mapSums == nil {
+					alert.mapSums = make(map[string]float32, len(stats.Probes))
+				}
+				for name, probe := range stats.Probes {
+					alert.mapSums[name] += float32(100 - probe.Success)
+				}
+			case "CloseWait":
+				alert.val += stats.TcpStates["CLOSE_WAIT"]
+			case "TimeWait":
+				alert.val += stats.TcpStates["TIME_WAIT"]
+			case "Fan":
+				if alert.mapSums == nil {
+					alert.mapSums = make(map[string]float32, len(stats.Fans))
+				}
+				for key, rpm := range stats.Fans {
+					alert.mapSums[key] += rpm
+				}
+			case "Voltage":
+				alert.val += stats.VoltageAlarm
+			case "Throttle":
+				alert.val += stats.Throttles
 			default:
 				continue
 			}
@@ -220,6 +348,43 @@ func (am *AlertManager) HandleSystemAlerts(systemRecord *core.Record, data *syst
 				}
 			}
 			alert.val = float64(maxTemp)
+		case "Custom":
+			if len(alert.mapSums) == 0 {
+				continue
+			}
+			maxVal := float32(0)
+			alert.descriptor = ""
+			for key, value := range alert.mapSums {
+				avg := value / float32(alert.count)
+				if alert.descriptor == "" || avg > maxVal {
+					maxVal = avg
+					alert.descriptor = fmt.Sprintf("Metric %s", key)
+				}
+			}
+			alert.val = float64(maxVal)
+		case "Probe":
+			var info system.Info
+			_ = alert.systemRecord.UnmarshalJSONField("info", &info)
+			maxFailure := float32(0)
+			for name, value := range alert.mapSums {
+				failure := value / float32(alert.count)
+				if failure > maxFailure {
+					maxFailure = failure
+					alert.descriptor = probeDescriptor(name, info.ProbeErrors)
+				}
+			}
+			alert.val = float64(maxFailure)
+		case "Fan":
+			minRpm := float32(0)
+			alert.descriptor = ""
+			for key, value := range alert.mapSums {
+				avg := value / float32(alert.count)
+				if alert.descriptor == "" || avg < minRpm {
+					minRpm = avg
+					alert.descriptor = fmt.Sprintf("Lowest fan %s", key)
+				}
+			}
+			alert.val = float64(minRpm)
 		default:
 			alert.val = alert.val / float64(alert.count)
 		}
@@ -228,10 +393,11 @@ func (am *AlertManager) HandleSystemAlerts(systemRecord *core.Record, data *syst
 		// log.Printf("%s: val %f | count %d | min-count %f | threshold %f\n", alert.name, alert.val, alert.count, minCount, alert.threshold)
 		// pass through alert if count is greater than or equal to minCount
 		if float32(alert.count) >= minCount {
-			if !alert.triggered && alert.val > alert.threshold {
+			exceeds := exceedsThreshold(alert.val, alert.threshold, alert.invert)
+			if !alert.triggered && exceeds {
 				alert.triggered = true
 				go am.sendSystemAlert(alert)
-			} else if alert.triggered && alert.val <= alert.threshold {
+			} else if alert.triggered && !exceeds {
 				alert.triggered = false
 				go am.sendSystemAlert(alert)
 			}
@@ -248,15 +414,35 @@ func (am *AlertManager) sendSystemAlert(alert SystemAlertData) {
 	if alert.name == "Disk" {
 		alert.name += " usage"
 	}
+	// change Custom to Custom metric
+	if alert.name == "Custom" {
+		alert.name += " metric"
+	}
+	// change Probe to Probe failure
+	if alert.name == "Probe" {
+		alert.name += " failure"
+	}
+	// change CloseWait and TimeWait to connection states
+	switch alert.name {
+	case "CloseWait":
+		alert.name = "Close wait connections"
+	case "TimeWait":
+		alert.name = "Time wait connections"
+	case "Fan":
+		alert.name = "Fan speed"
+	case "Throttle":
+		alert.name = "CPU throttling"
+	}
 
-	// make title alert name lowercase if not CPU
+	// make title alert name lowercase unless it starts with CPU
 	titleAlertName := alert.name
-	if titleAlertName != "CPU" {
+	if !strings.HasPrefix(titleAlertName, "CPU") {
 		titleAlertName = strings.ToLower(titleAlertName)
 	}
 
+	// inverted alerts trigger below the threshold
 	var subject string
-	if alert.triggered {
+	if alert.triggered != alert.invert {
 		subject = fmt.Sprintf("%s %s above threshold", systemName, titleAlertName)
 	} else {
 		subject = fmt.Sprintf("%s %s below threshold", systemName, titleAlertName)
@@ -270,17 +456,22 @@ func (am *AlertManager) sendSystemAlert(alert SystemAlertData) {
 	}
 	body := fmt.Sprintf("%s averaged %.2f%s for the previous %v %s.", alert.descriptor, alert.val, alert.unit, alert.min, minutesLabel)
 
-	alert.alertRecord.Set("triggered", alert.triggered)
-	if err := am.app.Save(alert.alertRecord); err != nil {
+	am.saveAndSendAlert(alert.alertRecord, alert.triggered, systemName, subject, body)
+}
+
+// saveAndSendAlert saves the triggered state of the alert and sends the message to the alert's user
+func (am *AlertManager) saveAndSendAlert(alertRecord *core.Record, triggered bool, systemName, subject, body string) {
+	alertRecord.Set("triggered", triggered)
+	if err := am.app.Save(alertRecord); err != nil {
 		// app.Logger().Error("failed to save alert record", "err", err.Error())
 		return
 	}
 	// expand the user relation and send the alert
-	if errs := am.app.ExpandRecord(alert.alertRecord, []string{"user"}, nil); len(errs) > 0 {
+	if errs := am.app.ExpandRecord(alertRecord, []string{"user"}, nil); len(errs) > 0 {
 		// app.Logger().Error("failed to expand user relation", "errs", errs)
 		return
 	}
-	if user := alert.alertRecord.ExpandedOne("user"); user != nil {
+	if user := alertRecord.ExpandedOne("user"); user != nil {
 		am.SendAlert(AlertMessageData{
 			UserID:   user.Id,
 			Title:    subject,
@@ -290,3 +481,492 @@ func (am *AlertManager) sendSystemAlert(alert SystemAlertData) {
 		})
 	}
 }
+
+// handleCollectorAlert triggers when any collector on the system has been failing for
+// at least the alert's min minutes, and resolves once no collectors are failing.
+func (am *AlertManager) handleCollectorAlert(systemRecord, alertRecord *core.Record, now time.Time) {
+	var agentStats system.AgentStats
+	if err := systemRecord.UnmarshalJSONField("agent", &agentStats); err != nil {
+		return
+	}
+	min := max(1, cast.ToUint8(alertRecord.Get("min")))
+	cutoff := now.Add(-time.Duration(min) * time.Minute).UnixMilli()
+
+	var failing []string
+	anyFailing := false
+	for _, c := range agentStats.Collectors {
+		if c.Since == 0 {
+			continue
+		}
+		anyFailing = true
+		if c.Since <= cutoff {
+			failing = append(failing, fmt.Sprintf("%s: %s", c.Name, c.Error))
+		}
+	}
+
+	triggered := alertRecord.GetBool("triggered")
+	systemName := systemRecord.GetString("name")
+	minutesLabel := "minute"
+	if min > 1 {
+		minutesLabel += "s"
+	}
+	switch {
+	case !triggered && len(failing) > 0:
+		subject := fmt.Sprintf("%s collector failing", systemName)
+		body := fmt.Sprintf("Collectors failing for the previous %v %s:\n%s", min, minutesLabel, strings.Join(failing, "\n"))
+		go am.saveAndSendAlert(alertRecord, true, systemName, subject, body)
+	case triggered && !anyFailing:
+		subject := fmt.Sprintf("%s collectors recovered", systemName)
+		body := "All collectors are reporting data again."
+		go am.saveAndSendAlert(alertRecord, false, systemName, subject, body)
+	}
+}
+
+// handleCertificateAlert triggers when any certificate on the system expires within the
+// alert's threshold in days, is not valid for its hostname, or can't be loaded, and
+// resolves once all certificates are valid.
+func (am *AlertManager) handleCertificateAlert(systemRecord, alertRecord *core.Record, certs []system.CertStatus) {
+	if len(certs) == 0 {
+		return
+	}
+	threshold := alertRecord.GetFloat("value")
+	var problems []string
+	for _, cert := range certs {
+		switch {
+		case cert.Error != "":
+			problems = append(problems, fmt.Sprintf("%s could not be checked: %s", cert.Target, cert.Error))
+		case cert.Days < threshold:
+			problems = append(problems, fmt.Sprintf("%s expires in %.0f days (%s)", cert.Target, cert.Days,
+				time.Unix(cert.Expires, 0).UTC().Format(time.DateOnly)))
+		}
+		if cert.Mismatch {
+			problems = append(problems, fmt.Sprintf("%s is not valid for the hostname (%s)", cert.Target, cert.Subject))
+		}
+	}
+
+	triggered := alertRecord.GetBool("triggered")
+	systemName := systemRecord.GetString("name")
+	switch {
+	case !triggered && len(problems) > 0:
+		subject := fmt.Sprintf("%s certificate problem", systemName)
+		body := fmt.Sprintf("Certificates expiring within %v days, mismatched or failing checks:\n%s", threshold, strings.Join(problems, "\n"))
+		go am.saveAndSendAlert(alertRecord, true, systemName, subject, body)
+	case triggered && len(problems) == 0:
+		subject := fmt.Sprintf("%s certificates valid", systemName)
+		body := fmt.Sprintf("All certificates match their hostnames and none expire within %v days.", threshold)
+		go am.saveAndSendAlert(alertRecord, false, systemName, subject, body)
+	}
+}
+
+// exceedsThreshold reports whether val is past the threshold, which is below it for inverted alerts
+func exceedsThreshold(val, threshold float64, invert bool) bool {
+	if invert {
+		return val < threshold
+	}
+	return val > threshold
+}
+
+// lowestFanSpeed returns the lowest fan speed and a descriptor naming the fan
+func lowestFanSpeed(fans map[string]float64) (val float64, descriptor string) {
+	for key, rpm := range fans {
+		if descriptor == "" || rpm < val {
+			val = rpm
+			descriptor = fmt.Sprintf("Lowest fan %s", key)
+		}
+	}
+	return val, descriptor
+}
+
+// highestCustomMetric returns the highest value of the custom metrics matching metric
+// and a descriptor naming it. ok is false if no metrics match.
+func highestCustomMetric(metrics map[string]float64, metric string) (val float64, descriptor string, ok bool) {
+	for key, value := range metrics {
+		if !metricMatches(metric, key) {
+			continue
+		}
+		if !ok || value > val {
+			val = value
+			descriptor = fmt.Sprintf("Metric %s", key)
+			ok = true
+		}
+	}
+	return val, descriptor, ok
+}
+
+// metricMatches reports whether a custom metric series matches the alert's metric,
+// which is a series name or a pattern where * matches any characters. An empty
+// metric matches nothing, since unrelated metrics can't share a threshold.
+func metricMatches(metric, series string) bool {
+	if metric == "" {
+		return false
+	}
+	if !strings.Contains(metric, "*") {
+		return metric == series
+	}
+	parts := strings.Split(metric, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	matched, _ := regexp.MatchString("^"+strings.Join(parts, ".*")+"$", series)
+	return matched
+}
+
+// highestProbeFailure returns the highest failure percent of any probe and a descriptor
+// naming the probe and its last error
+func highestProbeFailure(probes map[string]system.ProbeStats, probeErrors map[string]string) (val float64, descriptor string) {
+	for name, probe := range probes {
+		if failure := 100 - probe.Success; descriptor == "" || failure > val {
+			val = failure
+			descriptor = probeDescriptor(name, probeErrors)
+		}
+	}
+	return val, descriptor
+}
+
+// probeDescriptor names a probe in a notification along with its last error, if any
+func probeDescriptor(name string, probeErrors map[string]string) string {
+	if err := probeErrors[name]; err != "" {
+		return fmt.Sprintf("Failures of probe %s (%s)", name, err)
+	}
+	return fmt.Sprintf("Failures of probe %s", name)
+}
+
+// handleUpdatesAlert triggers when security updates have been pending for at least the
+// alert's value in days, and resolves once they are installed.
+func (am *AlertManager) handleUpdatesAlert(systemRecord, alertRecord *core.Record, updates *system.UpdateStatus, now time.Time) {
+	if updates == nil || updates.Error != "" {
+		return
+	}
+	threshold := alertRecord.GetFloat("value")
+	var days float64
+	if updates.Security > 0 && updates.SecuritySince > 0 {
+		days = now.Sub(time.Unix(updates.SecuritySince, 0)).Hours() / 24
+	}
+	overdue := updates.Security > 0 && days >= threshold
+
+	triggered := alertRecord.GetBool("triggered")
+	systemName := systemRecord.GetString("name")
+	switch {
+	case !triggered && overdue:
+		subject := fmt.Sprintf("%s has pending security updates", systemName)
+		body := fmt.Sprintf("%d security updates (%d total) have been pending for %.0f days.", updates.Security, updates.Pending, days)
+		if updates.Reboot {
+			body += "\nA reboot is required."
+		}
+		go am.saveAndSendAlert(alertRecord, true, systemName, subject, body)
+	case triggered && !overdue:
+		subject := fmt.Sprintf("%s security updates installed", systemName)
+		body := fmt.Sprintf("No security updates have been pending for %v days.", threshold)
+		go am.saveAndSendAlert(alertRecord, false, systemName, subject, body)
+	}
+}
+
+// listenerNewPeriod is how long a listening socket is considered new after it is first seen
+const listenerNewPeriod = 24 * time.Hour
+
+// handleListenerAlert triggers when a listening socket that was not present when the
+// system was first seen has been open for at least the alert's min minutes. Resolves
+// once no listeners are new.
+func (am *AlertManager) handleListenerAlert(systemRecord, alertRecord *core.Record, now time.Time) {
+	var listeners []system.Listener
+	if err := systemRecord.UnmarshalJSONField("listeners", &listeners); err != nil {
+		return
+	}
+	min := max(1, cast.ToUint8(alertRecord.Get("min")))
+	cutoff := now.Add(-time.Duration(min) * time.Minute).UnixMilli()
+	newSince := now.Add(-listenerNewPeriod).UnixMilli()
+
+	var opened []string
+	for _, l := range listeners {
+		if l.Since > newSince && l.Since <= cutoff {
+			desc := fmt.Sprintf("%s %s port %d", l.Proto, l.Address, l.Port)
+			if l.Process != "" {
+				desc += fmt.Sprintf(" (%s, pid %d)", l.Process, l.Pid)
+			}
+			opened = append(opened, desc)
+		}
+	}
+
+	triggered := alertRecord.GetBool("triggered")
+	systemName := systemRecord.GetString("name")
+	switch {
+	case !triggered && len(opened) > 0:
+		subject := fmt.Sprintf("%s new listening port", systemName)
+		body := fmt.Sprintf("New listening ports:\n%s", strings.Join(opened, "\n"))
+		go am.saveAndSendAlert(alertRecord, true, systemName, subject, body)
+	case triggered && len(opened) == 0:
+		subject := fmt.Sprintf("%s no new listening ports", systemName)
+		body := fmt.Sprintf("No listening ports opened in the last %.0f hours are still open.", listenerNewPeriod.Hours())
+		go am.saveAndSendAlert(alertRecord, false, systemName, subject, body)
+	}
+}
+
+// onBattery returns descriptions of the UPS devices and batteries the system is running on.
+// Laptop batteries only count when the system has an AC adapter which is offline.
+func onBattery(power *system.PowerStatus) (sources []string) {
+	for _, ups := range power.UPS {
+		if ups.OnBattery {
+			sources = append(sources, fmt.Sprintf("UPS %s (%.0f%% charge)", ups.Name, ups.Charge))
+		}
+	}
+	if power.AC != nil && !*power.AC {
+		for _, battery := range power.Batteries {
+			if battery.Status == "Discharging" {
+				sources = append(sources, fmt.Sprintf("Battery %s (%.0f%% charge)", battery.Name, battery.Capacity))
+			}
+		}
+	}
+	return sources
+}
+
+// handleBatteryAlert triggers when the system loses AC power and is running on a UPS or
+// battery, and resolves once power is restored.
+func (am *AlertManager) handleBatteryAlert(systemRecord, alertRecord *core.Record, power *system.PowerStatus) {
+	if power == nil {
+		return
+	}
+	sources := onBattery(power)
+
+	triggered := alertRecord.GetBool("triggered")
+	systemName := systemRecord.GetString("name")
+	switch {
+	case !triggered && len(sources) > 0:
+		subject := fmt.Sprintf("%s is on battery", systemName)
+		body := fmt.Sprintf("Running on battery power:\n%s", strings.Join(sources, "\n"))
+		go am.saveAndSendAlert(alertRecord, true, systemName, subject, body)
+	case triggered && len(sources) == 0:
+		subject := fmt.Sprintf("%s power restored", systemName)
+		body := "The system is no longer running on battery power."
+		go am.saveAndSendAlert(alertRecord, false, systemName, subject, body)
+	}
+}
+
+// handleRuntimeAlert triggers when a UPS or battery the system is running on has less
+// than the alert's threshold in minutes of runtime left, and resolves once power is
+// restored or the runtime recovers.
+func (am *AlertManager) handleRuntimeAlert(systemRecord, alertRecord *core.Record, power *system.PowerStatus) {
+	if power == nil {
+		return
+	}
+	threshold := alertRecord.GetFloat("value")
+	var low []string
+	for _, ups := range power.UPS {
+		if minutes := float64(ups.Runtime) / 60; ups.OnBattery && ups.Runtime > 0 && minutes < threshold {
+			low = append(low, fmt.Sprintf("UPS %s has %.0f minutes left", ups.Name, minutes))
+		}
+	}
+	for _, battery := range power.Batteries {
+		if minutes := float64(battery.Runtime) / 60; battery.Runtime > 0 && minutes < threshold {
+			low = append(low, fmt.Sprintf("Battery %s has %.0f minutes left", battery.Name, minutes))
+		}
+	}
+
+	triggered := alertRecord.GetBool("triggered")
+	systemName := systemRecord.GetString("name")
+	switch {
+	case !triggered && len(low) > 0:
+		subject := fmt.Sprintf("%s battery runtime low", systemName)
+		body := fmt.Sprintf("Battery runtime below %v minutes:\n%s", threshold, strings.Join(low, "\n"))
+		go am.saveAndSendAlert(alertRecord, true, systemName, subject, body)
+	case triggered && len(low) == 0:
+		subject := fmt.Sprintf("%s battery runtime recovered", systemName)
+		body := fmt.Sprintf("No battery has less than %v minutes of runtime left.", threshold)
+		go am.saveAndSendAlert(alertRecord, false, systemName, subject, body)
+	}
+}
+
+// poolProblems returns descriptions and names of ZFS pools which are not healthy or
+// have checksum, device or data errors
+func poolProblems(pools []system.ZfsPool) (problems, names []string) {
+	for _, pool := range pools {
+		var issues []string
+		if pool.Health != "ONLINE" {
+			issues = append(issues, pool.Health)
+		}
+		if pool.ChecksumErrors > 0 {
+			issues = append(issues, fmt.Sprintf("%d checksum errors", pool.ChecksumErrors))
+		}
+		if ioErrors := pool.ReadErrors + pool.WriteErrors; ioErrors > 0 {
+			issues = append(issues, fmt.Sprintf("%d read / write errors", ioErrors))
+		}
+		if pool.DataErrors {
+			issues = append(issues, "permanent data errors")
+		}
+		if len(issues) == 0 {
+			continue
+		}
+		problem := fmt.Sprintf("%s: %s", pool.Name, strings.Join(issues, ", "))
+		for _, device := range pool.Devices {
+			problem += fmt.Sprintf("\n  %s %s (read %d, write %d, checksum %d)", device.Name, device.State, device.Read, device.Write, device.Checksum)
+		}
+		problems = append(problems, problem)
+		names = append(names, pool.Name)
+	}
+	return problems, names
+}
+
+// handlePoolAlert triggers when a ZFS pool is degraded or has device errors, again
+// when another pool has problems, and resolves once all pools are healthy and their
+// errors are cleared.
+func (am *AlertManager) handlePoolAlert(systemRecord, alertRecord *core.Record, pools []system.ZfsPool) {
+	if pools == nil {
+		return
+	}
+	problems, names := poolProblems(pools)
+
+	added, changed := trackProblems(alertRecord, names)
+	triggered := alertRecord.GetBool("triggered")
+	systemName := systemRecord.GetString("name")
+	switch {
+	case added:
+		subject := fmt.Sprintf("%s ZFS pool unhealthy", systemName)
+		body := fmt.Sprintf("ZFS pools with problems:\n%s", strings.Join(problems, "\n"))
+		go am.saveAndSendAlert(alertRecord, true, systemName, subject, body)
+	case triggered && len(problems) == 0:
+		subject := fmt.Sprintf("%s ZFS pools healthy", systemName)
+		body := "All ZFS pools are online without errors."
+		go am.saveAndSendAlert(alertRecord, false, systemName, subject, body)
+	case changed:
+		_ = am.app.Save(alertRecord)
+	}
+}
+
+// trackProblems stores the names of the devices with problems on the alert record, and
+// reports whether any device is new so a second failing device is notified while the
+// alert is already triggered. changed is true if the stored names were updated.
+func trackProblems(alertRecord *core.Record, names []string) (added, changed bool) {
+	var prev []string
+	_ = alertRecord.UnmarshalJSONField("problems", &prev)
+	for _, name := range names {
+		if !slices.Contains(prev, name) {
+			added = true
+		}
+	}
+	if !added && len(names) == len(prev) {
+		return false, false
+	}
+	alertRecord.Set("problems", names)
+	return added, true
+}
+
+// handleRaidAlert triggers when an md array is degraded or has failed devices, again
+// when another array or device fails, and resolves once all arrays are in sync.
+func (am *AlertManager) handleRaidAlert(systemRecord, alertRecord *core.Record, arrays []system.RaidArray) {
+	if arrays == nil {
+		return
+	}
+	var problems, names []string
+	for _, array := range arrays {
+		if !array.Degraded && len(array.Failed) == 0 {
+			continue
+		}
+		names = append(names, array.Name)
+		for _, device := range array.Failed {
+			names = append(names, array.Name+"/"+device)
+		}
+		problem := fmt.Sprintf("%s (%s) has %d of %d devices", array.Name, array.Level, array.Active, array.Total)
+		if len(array.Failed) > 0 {
+			problem += fmt.Sprintf(", failed: %s", strings.Join(array.Failed, ", "))
+		}
+		if array.Sync != "" {
+			problem += fmt.Sprintf(", %s %.1f%%", array.Sync, array.SyncPct)
+		}
+		problems = append(problems, problem)
+	}
+
+	added, changed := trackProblems(alertRecord, names)
+	triggered := alertRecord.GetBool("triggered")
+	systemName := systemRecord.GetString("name")
+	switch {
+	case added:
+		subject := fmt.Sprintf("%s RAID array degraded", systemName)
+		body := fmt.Sprintf("Degraded RAID arrays:\n%s", strings.Join(problems, "\n"))
+		go am.saveAndSendAlert(alertRecord, true, systemName, subject, body)
+	case triggered && len(problems) == 0:
+		subject := fmt.Sprintf("%s RAID arrays healthy", systemName)
+		body := "All RAID arrays are in sync without failed devices."
+		go am.saveAndSendAlert(alertRecord, false, systemName, subject, body)
+	case changed:
+		_ = am.app.Save(alertRecord)
+	}
+}
+
+// handleSmartAlert triggers when a disk fails its SMART health check or has failing
+// attributes, again when another disk fails, and resolves once no disks are failing.
+func (am *AlertManager) handleSmartAlert(systemRecord, alertRecord *core.Record, devices []system.SmartDevice) {
+	if devices == nil {
+		return
+	}
+	var failing, names []string
+	for _, device := range devices {
+		if device.Health != "FAILED" && len(device.Failing) == 0 {
+			continue
+		}
+		names = append(names, device.Name)
+		desc := fmt.Sprintf("%s %s: SMART health %s", device.Name, device.Model, device.Health)
+		if len(device.Failing) > 0 {
+			desc += fmt.Sprintf(", failing %s", strings.Join(device.Failing, ", "))
+		}
+		failing = append(failing, desc)
+	}
+
+	added, changed := trackProblems(alertRecord, names)
+	triggered := alertRecord.GetBool("triggered")
+	systemName := systemRecord.GetString("name")
+	switch {
+	case added:
+		subject := fmt.Sprintf("%s disk failing SMART checks", systemName)
+		body := fmt.Sprintf("Disks failing SMART checks:\n%s", strings.Join(failing, "\n"))
+		go am.saveAndSendAlert(alertRecord, true, systemName, subject, body)
+	case triggered && len(failing) == 0:
+		subject := fmt.Sprintf("%s disks passing SMART checks", systemName)
+		body := "All disks pass their SMART checks."
+		go am.saveAndSendAlert(alertRecord, false, systemName, subject, body)
+	case changed:
+		_ = am.app.Save(alertRecord)
+	}
+}
+
+// handleLinkAlert triggers when a network interface is down or has negotiated a lower
+// speed than it has before, e.g. a 10G link at 1G, again when another link has a
+// problem, and resolves once all links are up at full speed.
+func (am *AlertManager) handleLinkAlert(systemRecord, alertRecord *core.Record, links []system.NetLink) {
+	if links == nil {
+		return
+	}
+	var problems, names []string
+	for _, link := range links {
+		switch {
+		case link.State == "down" || link.State == "lowerlayerdown":
+			problems = append(problems, fmt.Sprintf("%s is %s", link.Name, link.State))
+			names = append(names, link.Name)
+		case link.Speed > 0 && link.Speed < link.MaxSpeed:
+			problems = append(problems, fmt.Sprintf("%s is at %s, down from %s", link.Name, formatLinkSpeed(link.Speed), formatLinkSpeed(link.MaxSpeed)))
+			names = append(names, link.Name)
+		}
+	}
+
+	added, changed := trackProblems(alertRecord, names)
+	triggered := alertRecord.GetBool("triggered")
+	systemName := systemRecord.GetString("name")
+	switch {
+	case added:
+		subject := fmt.Sprintf("%s network link problem", systemName)
+		body := fmt.Sprintf("Network link problems:\n%s", strings.Join(problems, "\n"))
+		go am.saveAndSendAlert(alertRecord, true, systemName, subject, body)
+	case triggered && len(problems) == 0:
+		subject := fmt.Sprintf("%s network links restored", systemName)
+		body := "All network links are up at full speed."
+		go am.saveAndSendAlert(alertRecord, false, systemName, subject, body)
+	case changed:
+		_ = am.app.Save(alertRecord)
+	}
+}
+
+// formatLinkSpeed formats a link speed in Mbps, using Gbps for 1000 and above
+func formatLinkSpeed(mbps float64) string {
+	if mbps >= 1000 {
+		return fmt.Sprintf("%gG", mbps/1000)
+	}
+	return fmt.Sprintf("%gM", mbps)
+}
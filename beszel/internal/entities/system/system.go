@@ -4,33 +4,90 @@ package system
 
 import (
 	"beszel/internal/entities/container"
+	"math"
 	"time"
 )
 
 type Stats struct {
-	Cpu            float64             `json:"cpu"`
-	MaxCpu         float64             `json:"cpum,omitempty"`
-	Mem            float64             `json:"m"`
-	MemUsed        float64             `json:"mu"`
-	MemPct         float64             `json:"mp"`
-	MemBuffCache   float64             `json:"mb"`
-	MemZfsArc      float64             `json:"mz,omitempty"` // ZFS ARC memory
-	Swap           float64             `json:"s,omitempty"`
-	SwapUsed       float64             `json:"su,omitempty"`
-	DiskTotal      float64             `json:"d"`
-	DiskUsed       float64             `json:"du"`
-	DiskPct        float64             `json:"dp"`
-	DiskReadPs     float64             `json:"dr"`
-	DiskWritePs    float64             `json:"dw"`
-	MaxDiskReadPs  float64             `json:"drm,omitempty"`
-	MaxDiskWritePs float64             `json:"dwm,omitempty"`
-	NetworkSent    float64             `json:"ns"`
-	NetworkRecv    float64             `json:"nr"`
-	MaxNetworkSent float64             `json:"nsm,omitempty"`
-	MaxNetworkRecv float64             `json:"nrm,omitempty"`
-	Temperatures   map[string]float64  `json:"t,omitempty"`
-	ExtraFs        map[string]*FsStats `json:"efs,omitempty"`
-	GPUData        map[string]GPUData  `json:"g,omitempty"`
+	Cpu            float64               `json:"cpu"`
+	MaxCpu         float64               `json:"cpum,omitempty"`
+	P95Cpu         float64               `json:"cpu95,omitempty"`
+	Mem            float64               `json:"m"`
+	MemUsed        float64               `json:"mu"`
+	MemPct         float64               `json:"mp"`
+	MemBuffCache   float64               `json:"mb"`
+	MemZfsArc      float64               `json:"mz,omitempty"` // ZFS ARC memory
+	Swap           float64               `json:"s,omitempty"`
+	SwapUsed       float64               `json:"su,omitempty"`
+	DiskTotal      float64               `json:"d"`
+	DiskUsed       float64               `json:"du"`
+	DiskPct        float64               `json:"dp"`
+	DiskReadPs     float64               `json:"dr"`
+	DiskWritePs    float64               `json:"dw"`
+	MaxDiskReadPs  float64               `json:"drm,omitempty"`
+	MaxDiskWritePs float64               `json:"dwm,omitempty"`
+	P95DiskReadPs  float64               `json:"dr95,omitempty"`
+	P95DiskWritePs float64               `json:"dw95,omitempty"`
+	NetworkSent    float64               `json:"ns"`
+	NetworkRecv    float64               `json:"nr"`
+	MaxNetworkSent float64               `json:"nsm,omitempty"`
+	MaxNetworkRecv float64               `json:"nrm,omitempty"`
+	P95NetworkSent float64               `json:"ns95,omitempty"`
+	P95NetworkRecv float64               `json:"nr95,omitempty"`
+	Temperatures   map[string]float64    `json:"t,omitempty"`
+	ExtraFs        map[string]*FsStats   `json:"efs,omitempty"`
+	GPUData        map[string]GPUData    `json:"g,omitempty"`
+	CustomMetrics  map[string]float64    `json:"cm,omitempty"` // Named values from exec metrics, textfiles, etc.
+	Probes         map[string]ProbeStats `json:"pr,omitempty"`
+	TcpStates      map[string]float64    `json:"tcp,omitempty"` // Connections per TCP state, excluding LISTEN
+	TcpRetrans     float64               `json:"trs,omitempty"` // Retransmitted TCP segments per second
+	Fans           map[string]float64    `json:"fan,omitempty"` // RPM
+	Voltages       map[string]float64    `json:"vol,omitempty"` // Volts
+	Currents       map[string]float64    `json:"cur,omitempty"` // Amps
+	Power          map[string]float64    `json:"pwr,omitempty"` // Watts
+	VoltageAlarms  float64               `json:"va,omitempty"`  // Voltages outside the chip's limits
+	CpuFreqs       map[string]CpuFreq    `json:"cf,omitempty"`  // Keyed by cpu, e.g. cpu0
+	Throttles      float64               `json:"thr,omitempty"` // Thermal throttle events per minute
+	Rapl           map[string]float64    `json:"rp,omitempty"`  // Watts by RAPL domain, e.g. package-0
+	Energy         float64               `json:"kwh,omitempty"` // kWh used during the record's period, set by the hub
+	Arc            *ArcStats             `json:"arc,omitempty"`
+	NetUtil        map[string]float64    `json:"nu,omitempty"` // Percent of link speed used by interface
+}
+
+// ArcStats is the efficiency of the ZFS ARC and L2ARC
+type ArcStats struct {
+	Hit    float64 `json:"h,omitempty"`   // Percent of reads served by the ARC
+	L2Hit  float64 `json:"l2h,omitempty"` // Percent of ARC misses served by the L2ARC
+	L2Size float64 `json:"l2,omitempty"`  // GB
+}
+
+// SetEnergy sets the energy used over a period from the RAPL and GPU power. The psys
+// domain covers the whole platform, so package and DRAM are only used without it.
+func (s *Stats) SetEnergy(period time.Duration) {
+	watts, ok := s.Rapl["psys"]
+	if !ok {
+		for _, value := range s.Rapl {
+			watts += value
+		}
+	}
+	for _, gpu := range s.GPUData {
+		watts += gpu.Power
+	}
+	s.Energy = math.Round(watts*period.Hours()/1000*1e6) / 1e6
+}
+
+// CpuFreq is the frequency of a cpu in MHz
+type CpuFreq struct {
+	Cur   float64 `json:"c"`
+	Min   float64 `json:"n"`
+	Max   float64 `json:"x"` // Lowest of the hardware, policy and firmware limits
+	HwMax float64 `json:"h"` // Hardware maximum
+}
+
+// ProbeStats is the result of an agent probe
+type ProbeStats struct {
+	Latency float64 `json:"l"` // Milliseconds
+	Success float64 `json:"s"` // Percent of successful runs (100 or 0 before averaging)
 }
 
 type GPUData struct {
@@ -74,26 +131,238 @@ const (
 )
 
 type Info struct {
-	Hostname      string  `json:"h"`
-	KernelVersion string  `json:"k,omitempty"`
-	Cores         int     `json:"c"`
-	Threads       int     `json:"t,omitempty"`
-	CpuModel      string  `json:"m"`
-	Uptime        uint64  `json:"u"`
-	Cpu           float64 `json:"cpu"`
-	MemPct        float64 `json:"mp"`
-	DiskPct       float64 `json:"dp"`
-	Bandwidth     float64 `json:"b"`
-	AgentVersion  string  `json:"v"`
-	Podman        bool    `json:"p,omitempty"`
-	GpuPct        float64 `json:"g,omitempty"`
-	DashboardTemp float64 `json:"dt,omitempty"`
-	Os            Os      `json:"os"`
+	Hostname      string            `json:"h"`
+	KernelVersion string            `json:"k,omitempty"`
+	Cores         int               `json:"c"`
+	Threads       int               `json:"t,omitempty"`
+	CpuModel      string            `json:"m"`
+	Uptime        uint64            `json:"u"`
+	Cpu           float64           `json:"cpu"`
+	MemPct        float64           `json:"mp"`
+	DiskPct       float64           `json:"dp"`
+	Bandwidth     float64           `json:"b"`
+	AgentVersion  string            `json:"v"`
+	Podman        bool              `json:"p,omitempty"`
+	GpuPct        float64           `json:"g,omitempty"`
+	DashboardTemp float64           `json:"dt,omitempty"`
+	Os            Os                `json:"os"`
+	ProbeErrors   map[string]string `json:"pe,omitempty"` // Error from the last run of each failing probe
 }
 
 // Final data structure to return to the hub
 type CombinedData struct {
-	Stats      Stats              `json:"stats"`
-	Info       Info               `json:"info"`
-	Containers []*container.Stats `json:"container"`
+	Stats        Stats              `json:"stats"`
+	Info         Info               `json:"info"`
+	Containers   []*container.Stats `json:"container"`
+	Timestamp    int64              `json:"ts,omitempty"` // Unix milliseconds when collection started
+	Baseline     bool               `json:"bl,omitempty"` // First request from the hub, so rates are left out
+	Agent        *AgentStats        `json:"agent,omitempty"`
+	Certificates []CertStatus       `json:"certs,omitempty"`
+	Inventory    *Inventory         `json:"inv,omitempty"` // Only sent every INVENTORY_INTERVAL
+	Updates      *UpdateStatus      `json:"upd,omitempty"`
+	Listeners    []Listener         `json:"ls,omitempty"`
+	Sessions     []Session          `json:"ses,omitempty"` // Logged in users
+	Logins       []Session          `json:"lg,omitempty"`  // Recent logins, newest first
+	SensorGroups map[string]string  `json:"sg,omitempty"`  // Group of each sensor with an alias group
+	Power        *PowerStatus       `json:"pw,omitempty"`  // Batteries, AC adapters and UPS devices
+	ZfsPools     []ZfsPool          `json:"zp,omitempty"`
+	Raid         []RaidArray        `json:"md,omitempty"`
+	Links        []NetLink          `json:"nl,omitempty"`
+	Smart        []SmartDevice      `json:"smart,omitempty"` // Only updated every SMART_INTERVAL
+}
+
+// NetLink is the link state of a network interface from sysfs
+type NetLink struct {
+	Name     string  `json:"n"`
+	State    string  `json:"s"`            // operstate, e.g. up, down or lowerlayerdown
+	Speed    float64 `json:"sp,omitempty"` // Negotiated speed in Mbps
+	MaxSpeed float64 `json:"ms,omitempty"` // Highest speed negotiated, kept by the hub
+	Duplex   string  `json:"dx,omitempty"` // full or half
+}
+
+// RaidArray is an md software RAID array from /proc/mdstat
+type RaidArray struct {
+	Name       string   `json:"n"`
+	Level      string   `json:"l,omitempty"` // raid1, raid5, etc. Empty for inactive arrays
+	State      string   `json:"s"`           // active or inactive
+	Total      int      `json:"t,omitempty"` // Devices in the array
+	Active     int      `json:"a,omitempty"` // Devices in sync
+	Degraded   bool     `json:"dg,omitempty"`
+	Failed     []string `json:"f,omitempty"`
+	Spare      []string `json:"sa,omitempty"`
+	Sync       string   `json:"sy,omitempty"` // resync, recovery, reshape, check or repair
+	SyncPct    float64  `json:"sp,omitempty"` // Percent complete
+	SyncFinish string   `json:"sf,omitempty"` // Estimated time left, e.g. 12.5min
+}
+
+// SmartDevice is the SMART health of a disk from smartctl
+type SmartDevice struct {
+	Name        string   `json:"n"`
+	Model       string   `json:"m,omitempty"`
+	Serial      string   `json:"sn,omitempty"`
+	Protocol    string   `json:"p,omitempty"`  // ATA, SCSI or NVMe
+	Health      string   `json:"h,omitempty"`  // PASSED or FAILED
+	Temp        float64  `json:"t,omitempty"`  // Celsius
+	Hours       uint64   `json:"hr,omitempty"` // Power on hours
+	Reallocated uint64   `json:"ra,omitempty"` // Reallocated sectors
+	Pending     uint64   `json:"pe,omitempty"` // Sectors pending reallocation
+	Wear        float64  `json:"w,omitempty"`  // Percent of rated life used
+	MediaErrors uint64   `json:"me,omitempty"` // NVMe media and data integrity errors
+	Failing     []string `json:"fa,omitempty"` // Failed attributes and NVMe warnings
+	Error       string   `json:"e,omitempty"`
+}
+
+// ZfsPool is the health and usage of a ZFS pool
+type ZfsPool struct {
+	Name           string      `json:"n"`
+	Health         string      `json:"h"`            // ONLINE, DEGRADED, FAULTED, etc.
+	Size           float64     `json:"s"`            // GB
+	Alloc          float64     `json:"a"`            // GB
+	Frag           float64     `json:"f"`            // Percent
+	Cap            float64     `json:"c"`            // Percent
+	Scan           string      `json:"sc,omitempty"` // Last or current scrub or resilver
+	ScrubErrors    int         `json:"se,omitempty"` // Errors found by the last scrub
+	ReadErrors     uint64      `json:"re,omitempty"` // Summed over devices
+	WriteErrors    uint64      `json:"we,omitempty"` // Summed over devices
+	ChecksumErrors uint64      `json:"ce,omitempty"` // Summed over devices
+	DataErrors     bool        `json:"de,omitempty"` // Permanent errors in files
+	Devices        []ZfsDevice `json:"d,omitempty"`  // Devices which are not online or have errors
+}
+
+// ZfsDevice is a device of a ZFS pool from zpool status
+type ZfsDevice struct {
+	Name     string `json:"n"`
+	State    string `json:"s"`
+	Read     uint64 `json:"r,omitempty"`
+	Write    uint64 `json:"w,omitempty"`
+	Checksum uint64 `json:"c,omitempty"`
+}
+
+// PowerStatus is the state of the AC adapter, batteries and UPS devices of a system
+type PowerStatus struct {
+	AC        *bool     `json:"ac,omitempty"` // Nil if the system has no AC adapter
+	Batteries []Battery `json:"b,omitempty"`
+	UPS       []UPS     `json:"u,omitempty"`
+}
+
+// Battery is a system battery from the power_supply class
+type Battery struct {
+	Name     string  `json:"n"`
+	Capacity float64 `json:"c"`           // Percent
+	Status   string  `json:"s"`           // Charging, Discharging, Full or Not charging
+	Runtime  int64   `json:"r,omitempty"` // Estimated seconds left while discharging
+}
+
+// UPS is a UPS device from a Network UPS Tools server
+type UPS struct {
+	Name       string  `json:"n"`
+	Model      string  `json:"m,omitempty"`
+	Status     string  `json:"s,omitempty"` // NUT status flags, e.g. "OL CHRG"
+	OnBattery  bool    `json:"ob,omitempty"`
+	LowBattery bool    `json:"lb,omitempty"`
+	Load       float64 `json:"l,omitempty"` // Percent
+	Charge     float64 `json:"c,omitempty"` // Percent
+	Runtime    int64   `json:"r,omitempty"` // Seconds left on battery
+	Error      string  `json:"e,omitempty"`
+}
+
+// Session is a user login from utmp or wtmp
+type Session struct {
+	User string `json:"u"`
+	Line string `json:"l"`           // Terminal, e.g. pts/0
+	Host string `json:"h,omitempty"` // Source address or hostname, empty for local logins
+	Time int64  `json:"t"`           // Unix seconds of the login
+	Pid  int32  `json:"pid,omitempty"`
+	New  bool   `json:"-"` // true if not in the previous report (set by hub for alerts)
+}
+
+// Listener is a listening TCP socket or unconnected UDP socket
+type Listener struct {
+	Proto   string `json:"p"` // tcp or udp
+	Address string `json:"a"`
+	Port    uint16 `json:"pt"`
+	Process string `json:"c,omitempty"`   // Owning process name, empty if it can't be read
+	Pid     int32  `json:"pid,omitempty"` // Owning process id
+	Since   int64  `json:"s,omitempty"`   // Unix milliseconds the listener was first seen, 0 if present at the first report (set by hub)
+}
+
+// UpdateStatus is the pending package updates and reboot state of a system
+type UpdateStatus struct {
+	Manager          string           `json:"pm"`           // Package manager: apt, dnf or yum
+	Pending          int              `json:"p"`            // Number of packages with updates available
+	Security         int              `json:"s"`            // Number of security updates
+	SecurityPackages []string         `json:"sp,omitempty"` // Names of packages with security updates
+	SecuritySince    int64            `json:"ss,omitempty"` // Unix seconds the oldest pending security update was first seen (set by the hub)
+	FirstSeen        map[string]int64 `json:"fs,omitempty"` // Unix seconds each package in SecurityPackages was first seen (set by the hub)
+	Reboot           bool             `json:"r,omitempty"`  // true if a reboot is required
+	Checked          int64            `json:"c"`            // Unix seconds of the last check
+	Error            string           `json:"e,omitempty"`  // Error from the last check
+}
+
+// CertStatus is the expiry and hostname validity of a TLS certificate
+type CertStatus struct {
+	Target   string  `json:"n"`             // host:port or file path
+	Subject  string  `json:"sub,omitempty"` // Subject common name
+	Issuer   string  `json:"i,omitempty"`   // Issuer common name or organization
+	Expires  int64   `json:"x,omitempty"`   // Unix seconds
+	Days     float64 `json:"d"`             // Days until expiry, negative if expired
+	Mismatch bool    `json:"sm,omitempty"`  // true if the certificate is not valid for the target hostname
+	Error    string  `json:"e,omitempty"`   // Error loading the certificate
+}
+
+// AgentStats holds the agent's self-metrics and the result of each collector
+type AgentStats struct {
+	Rss            float64           `json:"m"`  // Resident memory in MB
+	Goroutines     int               `json:"g"`  // Number of goroutines
+	CollectionTime float64           `json:"ct"` // Time to collect the payload in milliseconds
+	Collectors     []CollectorStatus `json:"c"`
+}
+
+// CollectorStatus is the result of a single collector run
+type CollectorStatus struct {
+	Name     string  `json:"n"`
+	Duration float64 `json:"d"`            // Milliseconds
+	Error    string  `json:"e,omitempty"`  // Empty if ok
+	TimedOut bool    `json:"to,omitempty"` // true if the collector did not finish before its deadline
+	Since    int64   `json:"s,omitempty"`  // Unix milliseconds the collector started failing (set by hub)
+}
+
+// Inventory is the hardware and OS inventory of a system
+type Inventory struct {
+	OsName      string        `json:"os,omitempty"`   // Distribution name, e.g. "Debian GNU/Linux 12 (bookworm)"
+	OsId        string        `json:"osid,omitempty"` // Distribution id, e.g. "debian"
+	OsVersion   string        `json:"osv,omitempty"`
+	Vendor      string        `json:"sv,omitempty"` // System vendor
+	Model       string        `json:"pm,omitempty"` // Product name
+	Serial      string        `json:"sn,omitempty"` // Product serial (only readable as root)
+	BiosVendor  string        `json:"bv,omitempty"`
+	BiosVersion string        `json:"bver,omitempty"`
+	BiosDate    string        `json:"bd,omitempty"`
+	MemoryTotal float64       `json:"m"` // GB
+	Dimms       []DimmInfo    `json:"dimms,omitempty"`
+	Nics        []NicInfo     `json:"nics,omitempty"`
+	Disks       []BlockDevice `json:"disks,omitempty"`
+}
+
+// DimmInfo is a memory module reported by the EDAC driver
+type DimmInfo struct {
+	Name string  `json:"n"`
+	Size float64 `json:"s"` // GB
+}
+
+// NicInfo is a physical network interface
+type NicInfo struct {
+	Name   string `json:"n"`
+	Mac    string `json:"mac,omitempty"`
+	Driver string `json:"drv,omitempty"`
+	Model  string `json:"m,omitempty"` // PCI device name, or vendor:device ids if pci.ids is not available
+}
+
+// BlockDevice is a physical disk
+type BlockDevice struct {
+	Name       string  `json:"n"`
+	Model      string  `json:"m,omitempty"`
+	Serial     string  `json:"sn,omitempty"`
+	Size       float64 `json:"s"` // GB
+	Rotational bool    `json:"rot,omitempty"`
 }
@@ -0,0 +1,45 @@
+//go:build testing
+// +build testing
+
+package systems
+
+import (
+	"beszel/internal/entities/system"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackCollectorFailures(t *testing.T) {
+	collection := core.NewBaseCollection("systems")
+	collection.Fields.Add(&core.JSONField{Name: "agent"})
+	record := core.NewRecord(collection)
+
+	start := time.Now()
+	first := trackCollectorFailures(record, &system.AgentStats{Collectors: []system.CollectorStatus{
+		{Name: "cpu"},
+		{Name: "docker", Error: "connection refused"},
+	}}, start)
+	assert.Zero(t, first.Collectors[0].Since)
+	assert.Equal(t, start.UnixMilli(), first.Collectors[1].Since)
+	record.Set("agent", first)
+
+	// failure time is carried over while the collector keeps failing
+	second := trackCollectorFailures(record, &system.AgentStats{Collectors: []system.CollectorStatus{
+		{Name: "cpu", Error: "no cpu times"},
+		{Name: "docker", Error: "connection refused"},
+	}}, start.Add(time.Minute))
+	assert.Equal(t, start.Add(time.Minute).UnixMilli(), second.Collectors[0].Since)
+	assert.Equal(t, start.UnixMilli(), second.Collectors[1].Since)
+	record.Set("agent", second)
+
+	// recovered collectors are cleared
+	third := trackCollectorFailures(record, &system.AgentStats{Collectors: []system.CollectorStatus{
+		{Name: "cpu"},
+		{Name: "docker", Error: "connection refused"},
+	}}, start.Add(2*time.Minute))
+	assert.Zero(t, third.Collectors[0].Since)
+	assert.Equal(t, start.UnixMilli(), third.Collectors[1].Since)
+}
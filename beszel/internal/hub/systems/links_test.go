@@ -0,0 +1,34 @@
+//go:build testing
+// +build testing
+
+package systems
+
+import (
+	"beszel/internal/entities/system"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackLinkSpeeds(t *testing.T) {
+	collection := core.NewBaseCollection("systems")
+	collection.Fields.Add(&core.JSONField{Name: "links"})
+	record := core.NewRecord(collection)
+
+	first := trackLinkSpeeds(record, []system.NetLink{
+		{Name: "eth0", State: "up", Speed: 10000},
+		{Name: "eth1", State: "down"},
+	})
+	assert.Equal(t, 10000.0, first[0].MaxSpeed)
+	assert.Zero(t, first[1].MaxSpeed)
+	record.Set("links", first)
+
+	// the agent restarts after the link renegotiates at 1G
+	second := trackLinkSpeeds(record, []system.NetLink{
+		{Name: "eth0", State: "up", Speed: 1000},
+		{Name: "eth1", State: "up", Speed: 1000},
+	})
+	assert.Equal(t, 10000.0, second[0].MaxSpeed, "Expected the highest speed to be kept")
+	assert.Equal(t, 1000.0, second[1].MaxSpeed)
+}
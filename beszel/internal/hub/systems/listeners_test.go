@@ -0,0 +1,45 @@
+//go:build testing
+// +build testing
+
+package systems
+
+import (
+	"beszel/internal/entities/system"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackListeners(t *testing.T) {
+	collection := core.NewBaseCollection("systems")
+	collection.Fields.Add(&core.JSONField{Name: "listeners"})
+	record := core.NewRecord(collection)
+
+	start := time.Now()
+	// listeners in the first report are known
+	first := trackListeners(record, []system.Listener{
+		{Proto: "tcp", Address: "0.0.0.0", Port: 22, Process: "sshd"},
+	}, start)
+	assert.Zero(t, first[0].Since)
+	record.Set("listeners", first)
+
+	second := trackListeners(record, []system.Listener{
+		{Proto: "tcp", Address: "0.0.0.0", Port: 22, Process: "sshd"},
+		{Proto: "tcp", Address: "0.0.0.0", Port: 4444, Process: "nc"},
+	}, start.Add(time.Minute))
+	assert.Zero(t, second[0].Since)
+	assert.Equal(t, start.Add(time.Minute).UnixMilli(), second[1].Since)
+	record.Set("listeners", second)
+
+	// first seen time is carried over
+	third := trackListeners(record, []system.Listener{
+		{Proto: "tcp", Address: "0.0.0.0", Port: 22, Process: "sshd"},
+		{Proto: "tcp", Address: "0.0.0.0", Port: 4444, Process: "nc"},
+		{Proto: "udp", Address: "0.0.0.0", Port: 22},
+	}, start.Add(2*time.Minute))
+	assert.Zero(t, third[0].Since)
+	assert.Equal(t, start.Add(time.Minute).UnixMilli(), third[1].Since)
+	assert.Equal(t, start.Add(2*time.Minute).UnixMilli(), third[2].Since, "Expected a different protocol on the same port to be new")
+}
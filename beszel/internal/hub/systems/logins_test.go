@@ -0,0 +1,53 @@
+//go:build testing
+// +build testing
+
+package systems
+
+import (
+	"beszel/internal/entities/system"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkNewLogins(t *testing.T) {
+	collection := core.NewBaseCollection("systems")
+	collection.Fields.Add(&core.JSONField{Name: "logins"})
+	record := core.NewRecord(collection)
+
+	first := []system.Session{
+		{User: "alice", Line: "pts/0", Host: "10.0.0.5", Time: 200},
+		{User: "root", Line: "tty1", Time: 100},
+	}
+	markNewLogins(record, first)
+	assert.False(t, first[0].New, "Expected nothing new on the first report")
+	assert.False(t, first[1].New)
+	record.Set("logins", first)
+
+	second := []system.Session{
+		{User: "bob", Line: "pts/1", Host: "203.0.113.9", Time: 300},
+		{User: "alice", Line: "pts/0", Host: "10.0.0.5", Time: 200},
+		{User: "root", Line: "tty1", Time: 100},
+		{User: "carol", Line: "pts/2", Time: 50},
+	}
+	markNewLogins(record, second)
+	assert.True(t, second[0].New)
+	assert.False(t, second[1].New)
+	assert.False(t, second[2].New)
+	assert.False(t, second[3].New, "Expected logins older than the previous list to not be new")
+}
+
+func TestMarkNewLoginsAfterEmptyList(t *testing.T) {
+	collection := core.NewBaseCollection("systems")
+	collection.Fields.Add(&core.JSONField{Name: "logins"})
+	record := core.NewRecord(collection)
+
+	// first report with no logins in wtmp
+	markNewLogins(record, []system.Session{})
+	record.Set("logins", []system.Session{})
+
+	logins := []system.Session{{User: "bob", Line: "pts/1", Host: "203.0.113.9", Time: 300}}
+	markNewLogins(record, logins)
+	assert.True(t, logins[0].New, "Expected the first login after an empty list to be new")
+}
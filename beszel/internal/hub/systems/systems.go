@@ -249,39 +249,213 @@ func (sys *System) createRecords() (*core.Record, error) {
 		return nil, err
 	}
 	hub := sys.manager.hub
-	// add system_stats and container_stats records
+	// the agent's first response to this hub only sets baselines for rates, so it
+	// has no stats worth recording
+	if !sys.data.Baseline {
+		if err := sys.createStatsRecords(systemRecord); err != nil {
+			return nil, err
+		}
+	}
+	// update system record (do this last because it triggers alerts and we need above records to be inserted first)
+	systemRecord.Set("status", up)
+	systemRecord.Set("info", sys.data.Info)
+	if sys.data.Agent != nil {
+		systemRecord.Set("agent", trackCollectorFailures(systemRecord, sys.data.Agent, time.Now()))
+	}
+	if sys.data.Certificates != nil {
+		systemRecord.Set("certs", sys.data.Certificates)
+	}
+	if sys.data.Inventory != nil {
+		systemRecord.Set("inventory", sys.data.Inventory)
+	}
+	if sys.data.Updates != nil {
+		systemRecord.Set("updates", trackSecurityUpdates(systemRecord, sys.data.Updates, time.Now()))
+	}
+	if sys.data.Listeners != nil {
+		systemRecord.Set("listeners", trackListeners(systemRecord, sys.data.Listeners, time.Now()))
+	}
+	if sys.data.SensorGroups != nil {
+		systemRecord.Set("sensor_groups", sys.data.SensorGroups)
+	}
+	if sys.data.Power != nil {
+		systemRecord.Set("power", sys.data.Power)
+	}
+	if sys.data.ZfsPools != nil {
+		systemRecord.Set("zfs_pools", sys.data.ZfsPools)
+	}
+	if sys.data.Links != nil {
+		systemRecord.Set("links", trackLinkSpeeds(systemRecord, sys.data.Links))
+	}
+	if sys.data.Raid != nil {
+		systemRecord.Set("raid", sys.data.Raid)
+	}
+	if sys.data.Smart != nil {
+		systemRecord.Set("smart", sys.data.Smart)
+	}
+	if sys.data.Sessions != nil || sys.data.Logins != nil {
+		// store an empty list rather than null so the next report isn't treated as the first
+		if sys.data.Logins == nil {
+			sys.data.Logins = []system.Session{}
+		}
+		markNewLogins(systemRecord, sys.data.Logins)
+		systemRecord.Set("sessions", sys.data.Sessions)
+		systemRecord.Set("logins", sys.data.Logins)
+	}
+	if err := hub.SaveNoValidate(systemRecord); err != nil {
+		return nil, err
+	}
+	return systemRecord, nil
+}
+
+// createStatsRecords adds the 1m system_stats and container_stats records
+func (sys *System) createStatsRecords(systemRecord *core.Record) error {
+	hub := sys.manager.hub
 	systemStats, err := hub.FindCachedCollectionByNameOrId("system_stats")
 	if err != nil {
-		return nil, err
+		return err
 	}
 	systemStatsRecord := core.NewRecord(systemStats)
 	systemStatsRecord.Set("system", systemRecord.Id)
+	// each record covers one update interval
+	sys.data.Stats.SetEnergy(time.Duration(interval) * time.Millisecond)
 	systemStatsRecord.Set("stats", sys.data.Stats)
 	systemStatsRecord.Set("type", "1m")
 	if err := hub.SaveNoValidate(systemStatsRecord); err != nil {
-		return nil, err
+		return err
 	}
 	// add new container_stats record
 	if len(sys.data.Containers) > 0 {
 		containerStats, err := hub.FindCachedCollectionByNameOrId("container_stats")
 		if err != nil {
-			return nil, err
+			return err
 		}
 		containerStatsRecord := core.NewRecord(containerStats)
 		containerStatsRecord.Set("system", systemRecord.Id)
 		containerStatsRecord.Set("stats", sys.data.Containers)
 		containerStatsRecord.Set("type", "1m")
 		if err := hub.SaveNoValidate(containerStatsRecord); err != nil {
-			return nil, err
+			return err
 		}
 	}
-	// update system record (do this last because it triggers alerts and we need above records to be inserted first)
-	systemRecord.Set("status", up)
-	systemRecord.Set("info", sys.data.Info)
-	if err := hub.SaveNoValidate(systemRecord); err != nil {
-		return nil, err
+	return nil
+}
+
+// trackCollectorFailures sets the time each failing collector started failing,
+// carrying it over from the status previously stored on the system record.
+func trackCollectorFailures(systemRecord *core.Record, agentStats *system.AgentStats, now time.Time) *system.AgentStats {
+	var prev system.AgentStats
+	_ = systemRecord.UnmarshalJSONField("agent", &prev)
+	failingSince := make(map[string]int64, len(prev.Collectors))
+	for _, c := range prev.Collectors {
+		if c.Since > 0 {
+			failingSince[c.Name] = c.Since
+		}
+	}
+	for i := range agentStats.Collectors {
+		c := &agentStats.Collectors[i]
+		if c.Error == "" {
+			c.Since = 0
+		} else if since, ok := failingSince[c.Name]; ok {
+			c.Since = since
+		} else {
+			c.Since = now.UnixMilli()
+		}
+	}
+	return agentStats
+}
+
+// trackListeners sets when each listener was first seen, carrying over the time from
+// the previous listeners on the system record. Listeners in the first report are
+// treated as known and left at 0, so only listeners that appear later are new.
+func trackListeners(systemRecord *core.Record, listeners []system.Listener, now time.Time) []system.Listener {
+	var prev []system.Listener
+	_ = systemRecord.UnmarshalJSONField("listeners", &prev)
+	if len(prev) == 0 {
+		for i := range listeners {
+			listeners[i].Since = 0
+		}
+		return listeners
+	}
+	firstSeen := make(map[string]int64, len(prev))
+	for _, l := range prev {
+		firstSeen[fmt.Sprintf("%s %s %d", l.Proto, l.Address, l.Port)] = l.Since
+	}
+	for i := range listeners {
+		l := &listeners[i]
+		if since, ok := firstSeen[fmt.Sprintf("%s %s %d", l.Proto, l.Address, l.Port)]; ok {
+			l.Since = since
+		} else {
+			l.Since = now.UnixMilli()
+		}
+	}
+	return listeners
+}
+
+// trackLinkSpeeds sets the highest speed each link has negotiated, carrying over the
+// speeds from the previous links on the system record so a link which renegotiates
+// at a lower speed after a reboot is still noticed.
+func trackLinkSpeeds(systemRecord *core.Record, links []system.NetLink) []system.NetLink {
+	var prev []system.NetLink
+	_ = systemRecord.UnmarshalJSONField("links", &prev)
+	maxSpeed := make(map[string]float64, len(prev))
+	for _, link := range prev {
+		maxSpeed[link.Name] = link.MaxSpeed
+	}
+	for i := range links {
+		links[i].MaxSpeed = max(links[i].Speed, maxSpeed[links[i].Name])
+	}
+	return links
+}
+
+// trackSecurityUpdates sets when each package with a security update was first seen,
+// carrying over the times from the previous updates on the system record so they
+// survive agent restarts. SecuritySince is set to the oldest time.
+func trackSecurityUpdates(systemRecord *core.Record, updates *system.UpdateStatus, now time.Time) *system.UpdateStatus {
+	var prev system.UpdateStatus
+	_ = systemRecord.UnmarshalJSONField("updates", &prev)
+	// keep the times through a failed check
+	if updates.Error != "" {
+		updates.FirstSeen = prev.FirstSeen
+		updates.SecuritySince = prev.SecuritySince
+		return updates
+	}
+	updates.FirstSeen = make(map[string]int64, len(updates.SecurityPackages))
+	updates.SecuritySince = 0
+	for _, name := range updates.SecurityPackages {
+		since, ok := prev.FirstSeen[name]
+		if !ok {
+			since = now.Unix()
+		}
+		updates.FirstSeen[name] = since
+		if updates.SecuritySince == 0 || since < updates.SecuritySince {
+			updates.SecuritySince = since
+		}
+	}
+	return updates
+}
+
+// markNewLogins flags logins which were not in the previous logins on the system
+// record. Nothing is flagged on the first report, but every login is new if the
+// previous list was empty.
+func markNewLogins(systemRecord *core.Record, logins []system.Session) {
+	var prev []system.Session
+	if err := systemRecord.UnmarshalJSONField("logins", &prev); err != nil || prev == nil {
+		return
+	}
+	seen := make(map[system.Session]struct{}, len(prev))
+	var oldest int64
+	if len(prev) > 0 {
+		oldest = prev[0].Time
+	}
+	for _, l := range prev {
+		seen[l] = struct{}{}
+		oldest = min(oldest, l.Time)
+	}
+	for i := range logins {
+		_, ok := seen[logins[i]]
+		// older logins may be new to the list if a previous one was removed from wtmp
+		logins[i].New = !ok && logins[i].Time >= oldest
 	}
-	return systemRecord, nil
 }
 
 // getRecord retrieves the system record from the database.
@@ -0,0 +1,46 @@
+//go:build testing
+// +build testing
+
+package systems
+
+import (
+	"beszel/internal/entities/system"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackSecurityUpdates(t *testing.T) {
+	collection := core.NewBaseCollection("systems")
+	collection.Fields.Add(&core.JSONField{Name: "updates"})
+	record := core.NewRecord(collection)
+
+	start := time.Unix(1_700_000_000, 0)
+	first := trackSecurityUpdates(record, &system.UpdateStatus{Security: 1, SecurityPackages: []string{"openssl"}}, start)
+	assert.Equal(t, map[string]int64{"openssl": start.Unix()}, first.FirstSeen)
+	assert.Equal(t, start.Unix(), first.SecuritySince)
+	record.Set("updates", first)
+
+	// a new candidate version of the same package keeps its time
+	later := start.Add(24 * time.Hour)
+	second := trackSecurityUpdates(record, &system.UpdateStatus{Security: 2, SecurityPackages: []string{"openssl", "curl"}}, later)
+	assert.Equal(t, map[string]int64{"openssl": start.Unix(), "curl": later.Unix()}, second.FirstSeen)
+	assert.Equal(t, start.Unix(), second.SecuritySince)
+	record.Set("updates", second)
+
+	// times are kept through a failed check
+	failed := trackSecurityUpdates(record, &system.UpdateStatus{Error: "timed out after 2m0s"}, later.Add(time.Hour))
+	assert.Equal(t, second.FirstSeen, failed.FirstSeen)
+	assert.Equal(t, start.Unix(), failed.SecuritySince)
+	record.Set("updates", failed)
+
+	// installed updates are forgotten
+	third := trackSecurityUpdates(record, &system.UpdateStatus{Security: 1, SecurityPackages: []string{"curl"}}, later.Add(2*time.Hour))
+	assert.Equal(t, map[string]int64{"curl": later.Unix()}, third.FirstSeen)
+	assert.Equal(t, later.Unix(), third.SecuritySince)
+
+	third = trackSecurityUpdates(record, &system.UpdateStatus{}, later.Add(3*time.Hour))
+	assert.Zero(t, third.SecuritySince)
+}
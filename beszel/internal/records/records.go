@@ -133,7 +133,9 @@ func (rm *RecordManager) CreateLongerRecords() {
 					longerRecord.Set("type", recordData.longerType)
 					switch collection.Name {
 					case "system_stats":
-						longerRecord.Set("stats", rm.AverageSystemStats(stats))
+						averaged := rm.AverageSystemStats(stats)
+						averaged.SetEnergy(-recordData.longerTimeDuration)
+						longerRecord.Set("stats", averaged)
 					case "container_stats":
 						longerRecord.Set("stats", rm.AverageContainerStats(stats))
 					}
@@ -155,6 +157,18 @@ func (rm *RecordManager) AverageSystemStats(records RecordStats) *system.Stats {
 	sum := &system.Stats{}
 	count := float64(len(records))
 	tempCount := float64(0)
+	// custom metrics may not be present in every record so each is averaged separately
+	customCounts := make(map[string]float64)
+	probeCounts := make(map[string]float64)
+	tcpCount := float64(0)
+	// hwmon sensors are counted separately since fans are only reported once they spin
+	fanCounts, voltageCounts := make(map[string]float64), make(map[string]float64)
+	currentCounts, powerCounts := make(map[string]float64), make(map[string]float64)
+	voltageCount := float64(0)
+	cpuFreqCounts := make(map[string]float64)
+	raplCounts := make(map[string]float64)
+	netUtilCounts := make(map[string]float64)
+	arcCount := float64(0)
 
 	// Temporary struct for unmarshaling
 	stats := &system.Stats{}
@@ -186,6 +200,12 @@ func (rm *RecordManager) AverageSystemStats(records RecordStats) *system.Stats {
 		sum.MaxNetworkRecv = max(sum.MaxNetworkRecv, stats.MaxNetworkRecv, stats.NetworkRecv)
 		sum.MaxDiskReadPs = max(sum.MaxDiskReadPs, stats.MaxDiskReadPs, stats.DiskReadPs)
 		sum.MaxDiskWritePs = max(sum.MaxDiskWritePs, stats.MaxDiskWritePs, stats.DiskWritePs)
+		// Use highest p95 of shorter records (percentiles can't be averaged)
+		sum.P95Cpu = max(sum.P95Cpu, stats.P95Cpu)
+		sum.P95NetworkSent = max(sum.P95NetworkSent, stats.P95NetworkSent)
+		sum.P95NetworkRecv = max(sum.P95NetworkRecv, stats.P95NetworkRecv)
+		sum.P95DiskReadPs = max(sum.P95DiskReadPs, stats.P95DiskReadPs)
+		sum.P95DiskWritePs = max(sum.P95DiskWritePs, stats.P95DiskWritePs)
 
 		// Accumulate temperatures
 		if stats.Temperatures != nil {
@@ -198,6 +218,86 @@ func (rm *RecordManager) AverageSystemStats(records RecordStats) *system.Stats {
 			}
 		}
 
+		// Accumulate custom metrics
+		if stats.CustomMetrics != nil {
+			if sum.CustomMetrics == nil {
+				sum.CustomMetrics = make(map[string]float64, len(stats.CustomMetrics))
+			}
+			for key, value := range stats.CustomMetrics {
+				sum.CustomMetrics[key] += value
+				customCounts[key]++
+			}
+		}
+
+		// Accumulate probe results
+		if stats.Probes != nil {
+			if sum.Probes == nil {
+				sum.Probes = make(map[string]system.ProbeStats, len(stats.Probes))
+			}
+			for name, value := range stats.Probes {
+				probe := sum.Probes[name]
+				probe.Latency += value.Latency
+				probe.Success += value.Success
+				sum.Probes[name] = probe
+				probeCounts[name]++
+			}
+		}
+
+		// Accumulate tcp connection states
+		if stats.TcpStates != nil {
+			if sum.TcpStates == nil {
+				sum.TcpStates = make(map[string]float64, len(stats.TcpStates))
+			}
+			tcpCount++
+			for state, value := range stats.TcpStates {
+				sum.TcpStates[state] += value
+			}
+			sum.TcpRetrans += stats.TcpRetrans
+		}
+
+		// Accumulate hwmon sensors
+		sum.Fans = addSensorValues(sum.Fans, stats.Fans, fanCounts)
+		sum.Voltages = addSensorValues(sum.Voltages, stats.Voltages, voltageCounts)
+		sum.Currents = addSensorValues(sum.Currents, stats.Currents, currentCounts)
+		sum.Power = addSensorValues(sum.Power, stats.Power, powerCounts)
+		sum.Rapl = addSensorValues(sum.Rapl, stats.Rapl, raplCounts)
+		sum.NetUtil = addSensorValues(sum.NetUtil, stats.NetUtil, netUtilCounts)
+		if stats.Voltages != nil {
+			voltageCount++
+			sum.VoltageAlarms += stats.VoltageAlarms
+		}
+
+		// Accumulate cpu frequencies, keeping the lowest limits
+		if stats.CpuFreqs != nil {
+			if sum.CpuFreqs == nil {
+				sum.CpuFreqs = make(map[string]system.CpuFreq, len(stats.CpuFreqs))
+			}
+			for cpu, value := range stats.CpuFreqs {
+				freq, ok := sum.CpuFreqs[cpu]
+				if !ok {
+					freq = value
+					freq.Cur = 0
+				}
+				freq.Cur += value.Cur
+				freq.Max = min(freq.Max, value.Max)
+				freq.HwMax = max(freq.HwMax, value.HwMax)
+				sum.CpuFreqs[cpu] = freq
+				cpuFreqCounts[cpu]++
+			}
+		}
+		sum.Throttles += stats.Throttles
+
+		// Accumulate ARC efficiency
+		if stats.Arc != nil {
+			if sum.Arc == nil {
+				sum.Arc = &system.ArcStats{}
+			}
+			sum.Arc.Hit += stats.Arc.Hit
+			sum.Arc.L2Hit += stats.Arc.L2Hit
+			sum.Arc.L2Size += stats.Arc.L2Size
+			arcCount++
+		}
+
 		// Accumulate extra filesystem stats
 		if stats.ExtraFs != nil {
 			if sum.ExtraFs == nil {
@@ -263,6 +363,51 @@ func (rm *RecordManager) AverageSystemStats(records RecordStats) *system.Stats {
 			}
 		}
 
+		// Average custom metrics (not rounded since they can be any scale)
+		for key, value := range sum.CustomMetrics {
+			sum.CustomMetrics[key] = value / customCounts[key]
+		}
+
+		// Average probe results (success becomes the percent of successful runs)
+		for name, probe := range sum.Probes {
+			probe.Latency = twoDecimals(probe.Latency / probeCounts[name])
+			probe.Success = twoDecimals(probe.Success / probeCounts[name])
+			sum.Probes[name] = probe
+		}
+
+		// Average tcp connection states (states missing from a record had no connections)
+		if tcpCount > 0 {
+			for state, value := range sum.TcpStates {
+				sum.TcpStates[state] = twoDecimals(value / tcpCount)
+			}
+			sum.TcpRetrans = twoDecimals(sum.TcpRetrans / tcpCount)
+		}
+
+		// Average hwmon sensors
+		averageSensorValues(sum.Fans, fanCounts)
+		averageSensorValues(sum.Voltages, voltageCounts)
+		averageSensorValues(sum.Currents, currentCounts)
+		averageSensorValues(sum.Power, powerCounts)
+		averageSensorValues(sum.Rapl, raplCounts)
+		averageSensorValues(sum.NetUtil, netUtilCounts)
+		if voltageCount > 0 {
+			sum.VoltageAlarms = twoDecimals(sum.VoltageAlarms / voltageCount)
+		}
+
+		// Average cpu frequencies and throttling
+		for cpu, freq := range sum.CpuFreqs {
+			freq.Cur = twoDecimals(freq.Cur / cpuFreqCounts[cpu])
+			sum.CpuFreqs[cpu] = freq
+		}
+		sum.Throttles = twoDecimals(sum.Throttles / count)
+
+		// Average ARC efficiency
+		if sum.Arc != nil {
+			sum.Arc.Hit = twoDecimals(sum.Arc.Hit / arcCount)
+			sum.Arc.L2Hit = twoDecimals(sum.Arc.L2Hit / arcCount)
+			sum.Arc.L2Size = twoDecimals(sum.Arc.L2Size / arcCount)
+		}
+
 		// Average extra filesystem stats
 		if sum.ExtraFs != nil {
 			for key := range sum.ExtraFs {
@@ -292,6 +437,28 @@ func (rm *RecordManager) AverageSystemStats(records RecordStats) *system.Stats {
 	return sum
 }
 
+// addSensorValues adds sensor values to sum, counting the records each sensor appears in
+func addSensorValues(sum, values, counts map[string]float64) map[string]float64 {
+	if values == nil {
+		return sum
+	}
+	if sum == nil {
+		sum = make(map[string]float64, len(values))
+	}
+	for key, value := range values {
+		sum[key] += value
+		counts[key]++
+	}
+	return sum
+}
+
+// averageSensorValues divides each summed sensor value by the records it appeared in
+func averageSensorValues(sum, counts map[string]float64) {
+	for key, value := range sum {
+		sum[key] = twoDecimals(value / counts[key])
+	}
+}
+
 // Calculate the average stats of a list of container_stats records
 func (rm *RecordManager) AverageContainerStats(records RecordStats) []container.Stats {
 	sums := make(map[string]*container.Stats)
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds agent self-metrics / collector status to systems and the Collector alert
+func init() {
+	m.Register(func(app core.App) error {
+		if err := addJSONFields(app, "systems", "agent"); err != nil {
+			return err
+		}
+		return addAlertNames(app, "Collector")
+	}, func(app core.App) error {
+		if err := removeAlertNames(app, "Collector"); err != nil {
+			return err
+		}
+		return removeFields(app, "systems", "agent")
+	})
+}
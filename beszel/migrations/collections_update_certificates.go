@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds certificate status to systems and the Certificate alert
+func init() {
+	m.Register(func(app core.App) error {
+		if err := addJSONFields(app, "systems", "certs"); err != nil {
+			return err
+		}
+		return addAlertNames(app, "Certificate")
+	}, func(app core.App) error {
+		if err := removeAlertNames(app, "Certificate"); err != nil {
+			return err
+		}
+		return removeFields(app, "systems", "certs")
+	})
+}
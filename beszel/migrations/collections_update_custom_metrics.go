@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds the Custom alert for custom metrics sent by agents. Each Custom alert
+// applies to the metrics matching its metric field, so a system may have one
+// for each metric.
+func init() {
+	m.Register(func(app core.App) error {
+		if err := addAlertNames(app, "Custom"); err != nil {
+			return err
+		}
+		collection, err := app.FindCollectionByNameOrId("alerts")
+		if err != nil {
+			return err
+		}
+		if collection.Fields.GetByName("metric") == nil {
+			collection.Fields.Add(&core.TextField{Name: "metric", Max: 200})
+		}
+		collection.AddIndex("idx_MnhEt21L5r", true, "`user`, `system`, `name`, `metric`", "")
+		return app.Save(collection)
+	}, func(app core.App) error {
+		if err := removeAlertNames(app, "Custom"); err != nil {
+			return err
+		}
+		collection, err := app.FindCollectionByNameOrId("alerts")
+		if err != nil {
+			return err
+		}
+		collection.AddIndex("idx_MnhEt21L5r", true, "`user`, `system`, `name`", "")
+		collection.Fields.RemoveByName("metric")
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,15 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds the Fan and Voltage alerts for hwmon sensors
+func init() {
+	m.Register(func(app core.App) error {
+		return addAlertNames(app, "Fan", "Voltage")
+	}, func(app core.App) error {
+		return removeAlertNames(app, "Fan", "Voltage")
+	})
+}
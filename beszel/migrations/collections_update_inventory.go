@@ -0,0 +1,15 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds hardware and OS inventory to systems
+func init() {
+	m.Register(func(app core.App) error {
+		return addJSONFields(app, "systems", "inventory")
+	}, func(app core.App) error {
+		return removeFields(app, "systems", "inventory")
+	})
+}
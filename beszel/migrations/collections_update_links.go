@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds network link state to systems and the Link alert
+func init() {
+	m.Register(func(app core.App) error {
+		if err := addJSONFields(app, "systems", "links"); err != nil {
+			return err
+		}
+		return addAlertNames(app, "Link")
+	}, func(app core.App) error {
+		if err := removeAlertNames(app, "Link"); err != nil {
+			return err
+		}
+		return removeFields(app, "systems", "links")
+	})
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds listening sockets to systems and the Listener, CloseWait and TimeWait alerts
+func init() {
+	m.Register(func(app core.App) error {
+		if err := addJSONFields(app, "systems", "listeners"); err != nil {
+			return err
+		}
+		return addAlertNames(app, "Listener", "CloseWait", "TimeWait")
+	}, func(app core.App) error {
+		if err := removeAlertNames(app, "Listener", "CloseWait", "TimeWait"); err != nil {
+			return err
+		}
+		return removeFields(app, "systems", "listeners")
+	})
+}
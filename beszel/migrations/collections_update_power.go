@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds battery and UPS status to systems and the Battery and Runtime alerts
+func init() {
+	m.Register(func(app core.App) error {
+		if err := addJSONFields(app, "systems", "power"); err != nil {
+			return err
+		}
+		return addAlertNames(app, "Battery", "Runtime")
+	}, func(app core.App) error {
+		if err := removeAlertNames(app, "Battery", "Runtime"); err != nil {
+			return err
+		}
+		return removeFields(app, "systems", "power")
+	})
+}
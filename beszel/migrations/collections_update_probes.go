@@ -0,0 +1,15 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds the Probe alert for failing agent probes
+func init() {
+	m.Register(func(app core.App) error {
+		return addAlertNames(app, "Probe")
+	}, func(app core.App) error {
+		return removeAlertNames(app, "Probe")
+	})
+}
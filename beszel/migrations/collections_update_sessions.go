@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds logged in users and recent logins to systems and the Login alert
+func init() {
+	m.Register(func(app core.App) error {
+		if err := addJSONFields(app, "systems", "sessions", "logins"); err != nil {
+			return err
+		}
+		return addAlertNames(app, "Login")
+	}, func(app core.App) error {
+		if err := removeAlertNames(app, "Login"); err != nil {
+			return err
+		}
+		return removeFields(app, "systems", "sessions", "logins")
+	})
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds md RAID and SMART status to systems and the Raid and Smart alerts
+func init() {
+	m.Register(func(app core.App) error {
+		if err := addJSONFields(app, "systems", "raid", "smart"); err != nil {
+			return err
+		}
+		return addAlertNames(app, "Raid", "Smart")
+	}, func(app core.App) error {
+		if err := removeAlertNames(app, "Raid", "Smart"); err != nil {
+			return err
+		}
+		return removeFields(app, "systems", "raid", "smart")
+	})
+}
@@ -0,0 +1,15 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds the Throttle alert for cpu thermal throttling
+func init() {
+	m.Register(func(app core.App) error {
+		return addAlertNames(app, "Throttle")
+	}, func(app core.App) error {
+		return removeAlertNames(app, "Throttle")
+	})
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds pending package updates to systems and the Updates alert
+func init() {
+	m.Register(func(app core.App) error {
+		if err := addJSONFields(app, "systems", "updates"); err != nil {
+			return err
+		}
+		return addAlertNames(app, "Updates")
+	}, func(app core.App) error {
+		if err := removeAlertNames(app, "Updates"); err != nil {
+			return err
+		}
+		return removeFields(app, "systems", "updates")
+	})
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds ZFS pool status to systems and the Pool alert. Alerts store the names of
+// the devices with problems so a new failure is notified while triggered.
+func init() {
+	m.Register(func(app core.App) error {
+		if err := addJSONFields(app, "systems", "zfs_pools"); err != nil {
+			return err
+		}
+		if err := addJSONFields(app, "alerts", "problems"); err != nil {
+			return err
+		}
+		return addAlertNames(app, "Pool")
+	}, func(app core.App) error {
+		if err := removeAlertNames(app, "Pool"); err != nil {
+			return err
+		}
+		if err := removeFields(app, "alerts", "problems"); err != nil {
+			return err
+		}
+		return removeFields(app, "systems", "zfs_pools")
+	})
+}
@@ -0,0 +1,81 @@
+package migrations
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// addAlertNames adds values to the alerts collection's name select field
+func addAlertNames(app core.App, names ...string) error {
+	collection, err := app.FindCollectionByNameOrId("alerts")
+	if err != nil {
+		return err
+	}
+	field, ok := collection.Fields.GetByName("name").(*core.SelectField)
+	if !ok {
+		return fmt.Errorf("alerts name field is not a select field")
+	}
+	for _, name := range names {
+		if !slices.Contains(field.Values, name) {
+			field.Values = append(field.Values, name)
+		}
+	}
+	return app.Save(collection)
+}
+
+// removeAlertNames deletes alerts with the given names and removes the names
+// from the alerts collection's name select field
+func removeAlertNames(app core.App, names ...string) error {
+	collection, err := app.FindCollectionByNameOrId("alerts")
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		records, err := app.FindAllRecords(collection, dbx.HashExp{"name": name})
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := app.Delete(record); err != nil {
+				return err
+			}
+		}
+	}
+	field, ok := collection.Fields.GetByName("name").(*core.SelectField)
+	if !ok {
+		return fmt.Errorf("alerts name field is not a select field")
+	}
+	field.Values = slices.DeleteFunc(field.Values, func(value string) bool {
+		return slices.Contains(names, value)
+	})
+	return app.Save(collection)
+}
+
+// addJSONFields adds json fields to a collection if they don't exist
+func addJSONFields(app core.App, collectionName string, names ...string) error {
+	collection, err := app.FindCollectionByNameOrId(collectionName)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if collection.Fields.GetByName(name) == nil {
+			collection.Fields.Add(&core.JSONField{Name: name})
+		}
+	}
+	return app.Save(collection)
+}
+
+// removeFields removes fields from a collection
+func removeFields(app core.App, collectionName string, names ...string) error {
+	collection, err := app.FindCollectionByNameOrId(collectionName)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		collection.Fields.RemoveByName(name)
+	}
+	return app.Save(collection)
+}
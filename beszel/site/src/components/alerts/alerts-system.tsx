@@ -3,6 +3,7 @@ import { Trans, Plural } from "@lingui/react/macro"
 import { $alerts, $systems, pb } from "@/lib/stores"
 import { alertInfo, cn } from "@/lib/utils"
 import { Switch } from "@/components/ui/switch"
+import { Input } from "@/components/ui/input"
 import { AlertInfo, AlertRecord, SystemRecord } from "@/types"
 import { lazy, Suspense, useMemo, useState } from "react"
 import { toast } from "../ui/use-toast"
@@ -13,7 +14,8 @@ interface AlertData {
 	checked?: boolean
 	val?: number
 	min?: number
-	updateAlert?: (checked: boolean, value: number, min: number) => void
+	metric?: string
+	updateAlert?: (checked: boolean, value: number, min: number, metric?: string) => void
 	name: keyof typeof alertInfo
 	alert: AlertInfo
 	system: SystemRecord
@@ -39,12 +41,12 @@ export function SystemAlert({
 }) {
 	const alert = systemAlerts.find((alert) => alert.name === data.name)
 
-	data.updateAlert = async (checked: boolean, value: number, min: number) => {
+	data.updateAlert = async (checked: boolean, value: number, min: number, metric?: string) => {
 		try {
 			if (alert && !checked) {
 				await pb.collection("alerts").delete(alert.id)
 			} else if (alert && checked) {
-				await pb.collection("alerts").update(alert.id, { value, min, triggered: false })
+				await pb.collection("alerts").update(alert.id, { value, min, metric, triggered: false })
 			} else if (checked) {
 				pb.collection("alerts").create({
 					system: system.id,
@@ -52,6 +54,7 @@ export function SystemAlert({
 					name: data.name,
 					value: value,
 					min: min,
+					metric,
 				})
 			}
 		} catch (e) {
@@ -63,6 +66,7 @@ export function SystemAlert({
 		data.checked = true
 		data.val = alert.value
 		data.min = alert.min || 1
+		data.metric = alert.metric
 	}
 
 	return <AlertContent data={data} />
@@ -84,7 +88,7 @@ export const SystemAlertGlobal = ({ data, overwrite }: { data: AlertData; overwr
 		return map
 	}, [])
 
-	data.updateAlert = async (checked: boolean, value: number, min: number) => {
+	data.updateAlert = async (checked: boolean, value: number, min: number, metric?: string) => {
 		const sem = getSemaphore("alerts")
 		await sem.acquire()
 		try {
@@ -96,6 +100,7 @@ export const SystemAlertGlobal = ({ data, overwrite }: { data: AlertData; overwr
 			const recordData: Partial<AlertRecord> = {
 				value,
 				min,
+				metric,
 				triggered: false,
 			}
 
@@ -217,7 +222,8 @@ function AlertContent({ data }: { data: AlertData }) {
 
 	const [checked, setChecked] = useState(data.checked || false)
 	const [min, setMin] = useState(data.min || 10)
-	const [value, setValue] = useState(data.val || (singleDescription ? 0 : 80))
+	const [value, setValue] = useState(data.val || (singleDescription ? 0 : data.alert.start ?? 80))
+	const [metric, setMetric] = useState(data.metric || "")
 
 	const Icon = alertInfo[name].icon
 
@@ -240,30 +246,62 @@ function AlertContent({ data }: { data: AlertData }) {
 					checked={checked}
 					onCheckedChange={(newChecked) => {
 						setChecked(newChecked)
-						data.updateAlert?.(newChecked, value, min)
+						data.updateAlert?.(newChecked, value, min, metric)
 					}}
 				/>
 			</label>
 			{checked && (
 				<div className="grid sm:grid-cols-2 mt-1.5 gap-5 px-4 pb-5 tabular-nums text-muted-foreground">
+					{data.alert.metric && (
+						<div className="col-span-full">
+							<p id={`m${name}`} className="text-sm block h-8">
+								<Trans>Metric name, or a pattern where * matches any characters</Trans>
+							</p>
+							<Input
+								aria-labelledby={`m${name}`}
+								placeholder="queue_depth"
+								value={metric}
+								onChange={(e) => setMetric(e.target.value)}
+								onBlur={() => data.updateAlert?.(true, value, min, metric)}
+							/>
+						</div>
+					)}
 					<Suspense fallback={<div className="h-10" />}>
 						{!singleDescription && (
 							<div>
 								<p id={`v${name}`} className="text-sm block h-8">
-									<Trans>
-										Average exceeds{" "}
-										<strong className="text-foreground">
-											{value}
-											{data.alert.unit}
-										</strong>
-									</Trans>
+									{data.alert.valueDesc ? (
+										<>
+											{data.alert.valueDesc()}{" "}
+											<strong className="text-foreground">
+												{value}
+												{data.alert.unit}
+											</strong>
+										</>
+									) : data.alert.invert ? (
+										<Trans>
+											Falls below{" "}
+											<strong className="text-foreground">
+												{value}
+												{data.alert.unit}
+											</strong>
+										</Trans>
+									) : (
+										<Trans>
+											Average exceeds{" "}
+											<strong className="text-foreground">
+												{value}
+												{data.alert.unit}
+											</strong>
+										</Trans>
+									)}
 								</p>
 								<div className="flex gap-3">
 									<Slider
 										aria-labelledby={`v${name}`}
 										defaultValue={[value]}
 										onValueCommit={(val) => {
-											data.updateAlert?.(true, val[0], min)
+											data.updateAlert?.(true, val[0], min, metric)
 										}}
 										onValueChange={(val) => {
 											setValue(val[0])
@@ -292,7 +330,7 @@ function AlertContent({ data }: { data: AlertData }) {
 									aria-labelledby={`v${name}`}
 									defaultValue={[min]}
 									onValueCommit={(min) => {
-										data.updateAlert?.(true, value, min[0])
+										data.updateAlert?.(true, value, min[0], metric)
 									}}
 									onValueChange={(val) => {
 										setMin(val[0])
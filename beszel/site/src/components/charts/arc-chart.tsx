@@ -0,0 +1,85 @@
+import { t } from "@lingui/core/macro"
+import { CartesianGrid, Line, LineChart, YAxis } from "recharts"
+
+import {
+	ChartContainer,
+	ChartLegend,
+	ChartLegendContent,
+	ChartTooltip,
+	ChartTooltipContent,
+	xAxis,
+} from "@/components/ui/chart"
+import { useYAxisWidth, cn, formatShortDate, decimalString, chartMargin } from "@/lib/utils"
+import { ChartData } from "@/types"
+import { memo, useMemo } from "react"
+
+/** Chart of the ZFS ARC and L2ARC hit ratios */
+export default memo(function ArcChart({ chartData }: { chartData: ChartData }) {
+	const { yAxisWidth, updateYAxisWidth } = useYAxisWidth()
+
+	if (chartData.systemStats.length === 0) {
+		return null
+	}
+
+	const data = useMemo(() => {
+		return chartData.systemStats.map(({ created, stats }) => ({
+			created,
+			arc: stats?.arc?.h,
+			l2arc: stats?.arc?.l2h,
+		}))
+	}, [chartData])
+
+	const hasL2 = chartData.systemStats.some(({ stats }) => stats?.arc?.l2)
+	const lines = [
+		{ key: "arc", name: t`ARC`, color: "hsl(var(--chart-1))" },
+		...(hasL2 ? [{ key: "l2arc", name: t`L2ARC`, color: "hsl(var(--chart-2))" }] : []),
+	]
+
+	return (
+		<div>
+			<ChartContainer
+				className={cn("h-full w-full absolute aspect-auto bg-card opacity-0 transition-opacity", {
+					"opacity-100": yAxisWidth,
+				})}
+			>
+				<LineChart accessibilityLayer data={data} margin={chartMargin}>
+					<CartesianGrid vertical={false} />
+					<YAxis
+						direction="ltr"
+						orientation={chartData.orientation}
+						className="tracking-tighter"
+						domain={[0, 100]}
+						width={yAxisWidth}
+						tickFormatter={(value) => updateYAxisWidth(value + "%")}
+						tickLine={false}
+						axisLine={false}
+					/>
+					{xAxis(chartData)}
+					<ChartTooltip
+						animationEasing="ease-out"
+						animationDuration={150}
+						content={
+							<ChartTooltipContent
+								labelFormatter={(_, data) => formatShortDate(data[0].payload.created)}
+								contentFormatter={(item) => decimalString(item.value) + "%"}
+							/>
+						}
+					/>
+					{lines.map(({ key, name, color }) => (
+						<Line
+							key={key}
+							dataKey={key}
+							name={name}
+							type="monotoneX"
+							dot={false}
+							strokeWidth={1.5}
+							stroke={color}
+							isAnimationActive={false}
+						/>
+					))}
+					<ChartLegend content={<ChartLegendContent />} />
+				</LineChart>
+			</ChartContainer>
+		</div>
+	)
+})
@@ -0,0 +1,130 @@
+import { CartesianGrid, Line, LineChart, YAxis } from "recharts"
+
+import {
+	ChartContainer,
+	ChartLegend,
+	ChartLegendContent,
+	ChartTooltip,
+	ChartTooltipContent,
+	xAxis,
+} from "@/components/ui/chart"
+import {
+	useYAxisWidth,
+	cn,
+	formatShortDate,
+	toFixedWithoutTrailingZeros,
+	decimalString,
+	chartMargin,
+} from "@/lib/utils"
+import { ChartData } from "@/types"
+import { memo, useMemo } from "react"
+
+/** Retransmits are plotted on the right axis since they are a rate rather than a count */
+const retransKey = "Retransmits/s"
+
+export default memo(function ConnectionsChart({ chartData }: { chartData: ChartData }) {
+	const { yAxisWidth, updateYAxisWidth } = useYAxisWidth()
+
+	if (chartData.systemStats.length === 0) {
+		return null
+	}
+
+	/** Format tcp states for chart and assign colors */
+	const newChartData = useMemo(() => {
+		const newChartData = { data: [], colors: {} } as {
+			data: Record<string, number | string>[]
+			colors: Record<string, string>
+		}
+		const stateSums = {} as Record<string, number>
+		for (let data of chartData.systemStats) {
+			let newData = { created: data.created } as Record<string, number | string>
+			for (let [state, count] of Object.entries(data.stats?.tcp ?? {})) {
+				newData[state] = count
+				stateSums[state] = (stateSums[state] ?? 0) + count
+			}
+			if (data.stats?.tcp) {
+				newData[retransKey] = data.stats.trs ?? 0
+			}
+			newChartData.data.push(newData)
+		}
+		const keys = Object.keys(stateSums).sort((a, b) => stateSums[b] - stateSums[a])
+		for (let key of keys) {
+			newChartData.colors[key] = `hsl(${((keys.indexOf(key) * 360) / keys.length) % 360}, 60%, 55%)`
+		}
+		return newChartData
+	}, [chartData])
+
+	const colors = Object.keys(newChartData.colors)
+
+	return (
+		<div>
+			<ChartContainer
+				className={cn("h-full w-full absolute aspect-auto bg-card opacity-0 transition-opacity", {
+					"opacity-100": yAxisWidth,
+				})}
+			>
+				<LineChart accessibilityLayer data={newChartData.data} margin={chartMargin}>
+					<CartesianGrid vertical={false} />
+					<YAxis
+						direction="ltr"
+						orientation={chartData.orientation}
+						className="tracking-tighter"
+						domain={[0, "auto"]}
+						width={yAxisWidth}
+						tickFormatter={(value) => updateYAxisWidth(toFixedWithoutTrailingZeros(value, 2))}
+						tickLine={false}
+						axisLine={false}
+					/>
+					<YAxis
+						yAxisId="retrans"
+						orientation={chartData.orientation === "left" ? "right" : "left"}
+						className="tracking-tighter"
+						domain={[0, "auto"]}
+						tickFormatter={(value) => toFixedWithoutTrailingZeros(value, 2) + "/s"}
+						tickLine={false}
+						axisLine={false}
+					/>
+					{xAxis(chartData)}
+					<ChartTooltip
+						animationEasing="ease-out"
+						animationDuration={150}
+						// @ts-ignore
+						itemSorter={(a, b) => b.value - a.value}
+						content={
+							<ChartTooltipContent
+								labelFormatter={(_, data) => formatShortDate(data[0].payload.created)}
+								contentFormatter={(item) =>
+									item.name === retransKey ? decimalString(item.value) + "/s" : decimalString(item.value, 0)
+								}
+							/>
+						}
+					/>
+					{colors.map((key) => (
+						<Line
+							key={key}
+							dataKey={key}
+							name={key}
+							type="monotoneX"
+							dot={false}
+							strokeWidth={1.5}
+							stroke={newChartData.colors[key]}
+							isAnimationActive={false}
+						/>
+					))}
+					<Line
+						yAxisId="retrans"
+						dataKey={retransKey}
+						name={retransKey}
+						type="monotoneX"
+						dot={false}
+						strokeWidth={1.5}
+						strokeDasharray="4 3"
+						stroke="hsl(var(--muted-foreground))"
+						isAnimationActive={false}
+					/>
+					<ChartLegend content={<ChartLegendContent />} />
+				</LineChart>
+			</ChartContainer>
+		</div>
+	)
+})
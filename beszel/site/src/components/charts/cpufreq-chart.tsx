@@ -0,0 +1,129 @@
+import { t } from "@lingui/core/macro"
+import { CartesianGrid, Line, LineChart, YAxis } from "recharts"
+
+import {
+	ChartContainer,
+	ChartLegend,
+	ChartLegendContent,
+	ChartTooltip,
+	ChartTooltipContent,
+	xAxis,
+} from "@/components/ui/chart"
+import {
+	useYAxisWidth,
+	cn,
+	formatShortDate,
+	toFixedWithoutTrailingZeros,
+	decimalString,
+	chartMargin,
+} from "@/lib/utils"
+import { ChartData } from "@/types"
+import { memo, useMemo } from "react"
+
+/** Throttle events are plotted on the right axis since they are a rate rather than a frequency */
+const throttleKey = "throttle"
+
+/** Chart of the average, lowest and highest cpu frequency, the frequency limit and throttle events */
+export default memo(function CpuFreqChart({ chartData }: { chartData: ChartData }) {
+	const { yAxisWidth, updateYAxisWidth } = useYAxisWidth()
+
+	if (chartData.systemStats.length === 0) {
+		return null
+	}
+
+	const data = useMemo(() => {
+		return chartData.systemStats.map(({ created, stats }) => {
+			const freqs = Object.values(stats?.cf ?? {})
+			if (freqs.length === 0) {
+				return { created }
+			}
+			return {
+				created,
+				avg: freqs.reduce((sum, f) => sum + f.c, 0) / freqs.length,
+				low: Math.min(...freqs.map((f) => f.c)),
+				high: Math.max(...freqs.map((f) => f.c)),
+				limit: Math.min(...freqs.map((f) => f.x)),
+				[throttleKey]: stats.thr ?? 0,
+			}
+		})
+	}, [chartData])
+
+	const lines = [
+		{ key: "high", name: t`Highest`, color: "hsl(var(--chart-1))" },
+		{ key: "avg", name: t`Average`, color: "hsl(var(--chart-2))" },
+		{ key: "low", name: t`Lowest`, color: "hsl(var(--chart-4))" },
+		{ key: "limit", name: t`Limit`, color: "hsl(var(--muted-foreground))", dash: "4 3" },
+	]
+
+	return (
+		<div>
+			<ChartContainer
+				className={cn("h-full w-full absolute aspect-auto bg-card opacity-0 transition-opacity", {
+					"opacity-100": yAxisWidth,
+				})}
+			>
+				<LineChart accessibilityLayer data={data} margin={chartMargin}>
+					<CartesianGrid vertical={false} />
+					<YAxis
+						direction="ltr"
+						orientation={chartData.orientation}
+						className="tracking-tighter"
+						domain={[0, "auto"]}
+						width={yAxisWidth}
+						tickFormatter={(value) => updateYAxisWidth(toFixedWithoutTrailingZeros(value / 1000, 2) + " GHz")}
+						tickLine={false}
+						axisLine={false}
+					/>
+					<YAxis
+						yAxisId={throttleKey}
+						orientation={chartData.orientation === "left" ? "right" : "left"}
+						className="tracking-tighter"
+						domain={[0, "auto"]}
+						tickFormatter={(value) => toFixedWithoutTrailingZeros(value, 2) + "/m"}
+						tickLine={false}
+						axisLine={false}
+					/>
+					{xAxis(chartData)}
+					<ChartTooltip
+						animationEasing="ease-out"
+						animationDuration={150}
+						content={
+							<ChartTooltipContent
+								labelFormatter={(_, data) => formatShortDate(data[0].payload.created)}
+								contentFormatter={(item) =>
+									item.dataKey === throttleKey
+										? decimalString(item.value) + " /min"
+										: decimalString(item.value / 1000) + " GHz"
+								}
+							/>
+						}
+					/>
+					{lines.map(({ key, name, color, dash }) => (
+						<Line
+							key={key}
+							dataKey={key}
+							name={name}
+							type="monotoneX"
+							dot={false}
+							strokeWidth={1.5}
+							strokeDasharray={dash}
+							stroke={color}
+							isAnimationActive={false}
+						/>
+					))}
+					<Line
+						yAxisId={throttleKey}
+						dataKey={throttleKey}
+						name={t`Throttle events`}
+						type="stepAfter"
+						dot={false}
+						strokeWidth={1.5}
+						stroke="hsl(var(--chart-5))"
+						isAnimationActive={false}
+					/>
+					<ChartLegend content={<ChartLegendContent />} />
+				</LineChart>
+			</ChartContainer>
+		</div>
+	)
+})
@@ -15,14 +15,26 @@ import {
 	toFixedWithoutTrailingZeros,
 	decimalString,
 	chartMargin,
+	convertTemperature,
 } from "@/lib/utils"
 import { ChartData } from "@/types"
 import { memo, useMemo } from "react"
-import { $temperatureFilter } from "@/lib/stores"
+import { $temperatureFilter, $userSettings } from "@/lib/stores"
 import { useStore } from "@nanostores/react"
 
-export default memo(function TemperatureChart({ chartData }: { chartData: ChartData }) {
+/** Chart of sensor temperatures. If group is set, only sensors in that alias group are shown. */
+export default memo(function TemperatureChart({
+	chartData,
+	sensorGroups,
+	group = "",
+}: {
+	chartData: ChartData
+	sensorGroups?: Record<string, string>
+	group?: string
+}) {
 	const filter = useStore($temperatureFilter)
+	const { temperatureUnit } = useStore($userSettings)
+	const unit = convertTemperature(0, temperatureUnit).unit
 	const { yAxisWidth, updateYAxisWidth } = useYAxisWidth()
 
 	if (chartData.systemStats.length === 0) {
@@ -41,7 +53,10 @@ export default memo(function TemperatureChart({ chartData }: { chartData: ChartD
 			let keys = Object.keys(data.stats?.t ?? {})
 			for (let i = 0; i < keys.length; i++) {
 				let key = keys[i]
-				newData[key] = data.stats.t![key]
+				if ((sensorGroups?.[key] ?? "") !== group) {
+					continue
+				}
+				newData[key] = convertTemperature(data.stats.t![key], temperatureUnit).value
 				tempSums[key] = (tempSums[key] ?? 0) + newData[key]
 			}
 			newChartData.data.push(newData)
@@ -51,7 +66,7 @@ export default memo(function TemperatureChart({ chartData }: { chartData: ChartD
 			newChartData.colors[key] = `hsl(${((keys.indexOf(key) * 360) / keys.length) % 360}, 60%, 55%)`
 		}
 		return newChartData
-	}, [chartData])
+	}, [chartData, sensorGroups, group, temperatureUnit])
 
 	const colors = Object.keys(newChartData.colors)
 
@@ -74,7 +89,7 @@ export default memo(function TemperatureChart({ chartData }: { chartData: ChartD
 						width={yAxisWidth}
 						tickFormatter={(value) => {
 							const val = toFixedWithoutTrailingZeros(value, 2)
-							return updateYAxisWidth(val + " °C")
+							return updateYAxisWidth(val + " " + unit)
 						}}
 						tickLine={false}
 						axisLine={false}
@@ -88,7 +103,7 @@ export default memo(function TemperatureChart({ chartData }: { chartData: ChartD
 						content={
 							<ChartTooltipContent
 								labelFormatter={(_, data) => formatShortDate(data[0].payload.created)}
-								contentFormatter={(item) => decimalString(item.value) + " °C"}
+								contentFormatter={(item) => decimalString(item.value) + " " + unit}
 								filter={filter}
 							/>
 						}
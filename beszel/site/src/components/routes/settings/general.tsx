@@ -1,5 +1,6 @@
 import { Trans } from "@lingui/react/macro"
 import { Button } from "@/components/ui/button"
+import { Input } from "@/components/ui/input"
 import { Label } from "@/components/ui/label"
 import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select"
 import { chartTimeData } from "@/lib/utils"
@@ -21,7 +22,9 @@ export default function SettingsProfilePage({ userSettings }: { userSettings: Us
 		e.preventDefault()
 		setIsLoading(true)
 		const formData = new FormData(e.target as HTMLFormElement)
-		const data = Object.fromEntries(formData) as Partial<UserSettings>
+		const data = Object.fromEntries(formData) as Record<string, any>
+		// number inputs are submitted as strings, and an empty value clears the cost
+		data.energyCost = data.energyCost ? Number(data.energyCost) : undefined
 		await saveSettings(data)
 		setIsLoading(false)
 	}
@@ -100,6 +103,48 @@ export default function SettingsProfilePage({ userSettings }: { userSettings: Us
 						<Trans>Sets the default time range for charts when a system is viewed.</Trans>
 					</p>
 				</div>
+				<div className="space-y-2">
+					<Label className="block" htmlFor="temperatureUnit">
+						<Trans>Temperature unit</Trans>
+					</Label>
+					<Select
+						name="temperatureUnit"
+						key={userSettings.temperatureUnit}
+						defaultValue={userSettings.temperatureUnit ?? "celsius"}
+					>
+						<SelectTrigger id="temperatureUnit">
+							<SelectValue />
+						</SelectTrigger>
+						<SelectContent>
+							<SelectItem value="celsius">
+								<Trans>Celsius (°C)</Trans>
+							</SelectItem>
+							<SelectItem value="fahrenheit">
+								<Trans>Fahrenheit (°F)</Trans>
+							</SelectItem>
+						</SelectContent>
+					</Select>
+					<p className="text-[0.8rem] text-muted-foreground">
+						<Trans>Alert thresholds are always set in Celsius.</Trans>
+					</p>
+				</div>
+				<div className="space-y-2">
+					<Label className="block" htmlFor="energyCost">
+						<Trans>Energy cost per kWh</Trans>
+					</Label>
+					<Input
+						id="energyCost"
+						name="energyCost"
+						type="number"
+						min={0}
+						step="any"
+						defaultValue={userSettings.energyCost}
+						placeholder="0.15"
+					/>
+					<p className="text-[0.8rem] text-muted-foreground">
+						<Trans>Optional. Shows the cost of energy used in the period on power charts.</Trans>
+					</p>
+				</div>
 				<Separator />
 				<Button type="submit" className="flex items-center gap-1.5 disabled:opacity-100" disabled={isLoading}>
 					{isLoading ? <LoaderCircleIcon className="h-4 w-4 animate-spin" /> : <SaveIcon className="h-4 w-4" />}
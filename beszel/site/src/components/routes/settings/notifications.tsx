@@ -25,17 +25,28 @@ interface ShoutrrrUrlCardProps {
 const NotificationSchema = v.object({
 	emails: v.array(v.pipe(v.string(), v.email())),
 	webhooks: v.array(v.pipe(v.string(), v.url())),
+	loginNetworks: v.array(
+		v.pipe(
+			v.string(),
+			v.regex(/^[0-9a-f.:]+(\/\d{1,3})?$/i, (issue) => t`Invalid network` + `: ${issue.input}`)
+		)
+	),
+	loginUsers: v.array(v.string()),
 })
 
 const SettingsNotificationsPage = ({ userSettings }: { userSettings: UserSettings }) => {
 	const [webhooks, setWebhooks] = useState(userSettings.webhooks ?? [])
 	const [emails, setEmails] = useState<string[]>(userSettings.emails ?? [])
+	const [loginNetworks, setLoginNetworks] = useState<string[]>(userSettings.loginNetworks ?? [])
+	const [loginUsers, setLoginUsers] = useState<string[]>(userSettings.loginUsers ?? [])
 	const [isLoading, setIsLoading] = useState(false)
 
 	// update values when userSettings changes
 	useEffect(() => {
 		setWebhooks(userSettings.webhooks ?? [])
 		setEmails(userSettings.emails ?? [])
+		setLoginNetworks(userSettings.loginNetworks ?? [])
+		setLoginUsers(userSettings.loginUsers ?? [])
 	}, [userSettings])
 
 	function addWebhook() {
@@ -57,7 +68,7 @@ const SettingsNotificationsPage = ({ userSettings }: { userSettings: UserSetting
 	async function updateSettings() {
 		setIsLoading(true)
 		try {
-			const parsedData = v.parse(NotificationSchema, { emails, webhooks })
+			const parsedData = v.parse(NotificationSchema, { emails, webhooks, loginNetworks, loginUsers })
 			await saveSettings(parsedData)
 		} catch (e: any) {
 			toast({
@@ -159,6 +170,34 @@ const SettingsNotificationsPage = ({ userSettings }: { userSettings: UserSetting
 					</Button>
 				</div>
 				<Separator />
+				<div className="space-y-2">
+					<div className="mb-4">
+						<h3 className="mb-1 text-lg font-medium">
+							<Trans>Login alerts</Trans>
+						</h3>
+						<p className="text-sm text-muted-foreground leading-relaxed">
+							<Trans>
+								Systems with a login alert notify you of logins from outside your known networks or by watched
+								users. If neither is set, every login is sent.
+							</Trans>
+						</p>
+					</div>
+					<Label className="block" htmlFor="login-networks">
+						<Trans>Known networks</Trans>
+					</Label>
+					<InputTags
+						value={loginNetworks}
+						onChange={setLoginNetworks}
+						placeholder="10.0.0.0/8, 203.0.113.7"
+						className="w-full"
+						id="login-networks"
+					/>
+					<Label className="block pt-2" htmlFor="login-users">
+						<Trans>Watched users</Trans>
+					</Label>
+					<InputTags value={loginUsers} onChange={setLoginUsers} placeholder="root" className="w-full" id="login-users" />
+				</div>
+				<Separator />
 				<Button
 					type="button"
 					className="flex items-center gap-1.5 disabled:opacity-100"
@@ -9,23 +9,37 @@ import {
 	$direction,
 	$maxValues,
 	$temperatureFilter,
+	$customMetricsFilter,
 } from "@/lib/stores"
 import { ChartData, ChartTimes, ContainerStatsRecord, GPUData, SystemRecord, SystemStatsRecord } from "@/types"
 import { ChartType, Os } from "@/lib/enums"
-import React, { lazy, memo, useCallback, useEffect, useMemo, useRef, useState } from "react"
+import React, { lazy, memo, Suspense, useCallback, useEffect, useMemo, useRef, useState } from "react"
 import { Card, CardHeader, CardTitle, CardDescription } from "../ui/card"
 import { useStore } from "@nanostores/react"
 import Spinner from "../spinner"
-import { ClockArrowUp, CpuIcon, GlobeIcon, LayoutGridIcon, MonitorIcon, XIcon } from "lucide-react"
+import {
+	ClockArrowUp,
+	BatteryWarningIcon,
+	CpuIcon,
+	GaugeIcon,
+	GlobeIcon,
+	LayoutGridIcon,
+	MonitorIcon,
+	PackageIcon,
+	RotateCcwIcon,
+	XIcon,
+} from "lucide-react"
 import ChartTimeSelect from "../charts/chart-time-select"
 import {
 	chartTimeData,
 	cn,
 	getHostDisplayValue,
 	getPbTimestamp,
+	getSensorGroups,
 	getSizeAndUnit,
 	listen,
 	toFixedFloat,
+	decimalString,
 	useLocalStorage,
 } from "@/lib/utils"
 import { Separator } from "../ui/separator"
@@ -46,10 +60,36 @@ const MemChart = lazy(() => import("../charts/mem-chart"))
 const DiskChart = lazy(() => import("../charts/disk-chart"))
 const SwapChart = lazy(() => import("../charts/swap-chart"))
 const TemperatureChart = lazy(() => import("../charts/temperature-chart"))
+const SensorChart = lazy(() => import("../charts/sensor-chart"))
 const GpuPowerChart = lazy(() => import("../charts/gpu-power-chart"))
+const CustomMetricsChart = lazy(() => import("../charts/custom-metrics-chart"))
+const ProbeChart = lazy(() => import("../charts/probe-chart"))
+const ConnectionsChart = lazy(() => import("../charts/connections-chart"))
+const CpuFreqChart = lazy(() => import("../charts/cpufreq-chart"))
+const ArcChart = lazy(() => import("../charts/arc-chart"))
+const CertificatesTable = lazy(() => import("../system-details/certificates"))
+const InventoryTable = lazy(() => import("../system-details/inventory"))
+const ListenersTable = lazy(() => import("../system-details/listeners"))
+const SessionsTable = lazy(() => import("../system-details/sessions"))
+const PowerTable = lazy(() => import("../system-details/power"))
+const ZfsPoolsTable = lazy(() => import("../system-details/zfs"))
+const LinksTable = lazy(() => import("../system-details/links"))
+const RaidTable = lazy(() => import("../system-details/raid"))
+const SmartTable = lazy(() => import("../system-details/smart"))
 
 const cache = new Map<string, any>()
 
+/** Charts of hwmon sensors other than temperatures */
+const sensorCharts = [
+	{ dataKey: "fan", title: () => t`Fans`, description: () => t`Speed of system fans`, unit: " RPM" },
+	{ dataKey: "vol", title: () => t`Voltages`, description: () => t`Voltages of system sensors`, unit: " V" },
+	{ dataKey: "cur", title: () => t`Current`, description: () => t`Current of system sensors`, unit: " A" },
+	{ dataKey: "pwr", title: () => t`Power`, description: () => t`Power reported by system sensors`, unit: " W" },
+] as const
+
+/** Adds the sensor alias group to a chart title */
+const groupTitle = (title: string, group: string) => (group ? `${title} (${group})` : title)
+
 // create ticks and domain for charts
 function getTimeData(chartTime: ChartTimes, lastCreated: number) {
 	const cached = cache.get("td")
@@ -121,6 +161,7 @@ export default function SystemDetail({ name }: { name: string }) {
 	const systems = useStore($systems)
 	const chartTime = useStore($chartTime)
 	const maxValues = useStore($maxValues)
+	const { energyCost } = useStore($userSettings)
 	const [grid, setGrid] = useLocalStorage("grid", true)
 	const [system, setSystem] = useState({} as SystemRecord)
 	const [systemStats, setSystemStats] = useState([] as SystemStatsRecord[])
@@ -289,6 +330,11 @@ export default function SystemDetail({ name }: { name: string }) {
 		} else {
 			uptime = <Plural value={Math.trunc(system.info?.u / 86400)} one="# day" other="# days" />
 		}
+
+		// cpus held below their hardware maximum by policy or firmware limits
+		const cpuFreqs = Object.values(systemStats.at(-1)?.stats.cf ?? {})
+		const cappedGhz = cpuFreqs.some((f) => f.x < f.h) ? Math.min(...cpuFreqs.map((f) => f.x)) / 1000 : 0
+		const onBattery = system.power?.u?.some((u) => u.ob) || system.power?.ac === false
 		return [
 			{ value: getHostDisplayValue(system), Icon: GlobeIcon },
 			{
@@ -305,13 +351,36 @@ export default function SystemDetail({ name }: { name: string }) {
 				Icon: CpuIcon,
 				hide: !system.info.m,
 			},
+			{
+				value: system.updates && <Plural value={system.updates.p} one="# update" other="# updates" />,
+				Icon: PackageIcon,
+				label: system.updates?.s ? t`${system.updates.s} security updates` : t`Pending updates`,
+				hide: !system.updates?.p,
+			},
+			{
+				value: t`Reboot required`,
+				Icon: RotateCcwIcon,
+				hide: !system.updates?.r,
+			},
+			{
+				value: t`CPU capped at ${toFixedFloat(cappedGhz, 2)} GHz`,
+				Icon: GaugeIcon,
+				label: t`Maximum frequency is limited below the hardware maximum`,
+				hide: !cappedGhz,
+			},
+			{
+				value: t`On battery`,
+				Icon: BatteryWarningIcon,
+				label: t`The system has lost AC power`,
+				hide: !onBattery,
+			},
 		] as {
-			value: string | number | undefined
+			value: React.ReactNode
 			label?: string
 			Icon: any
 			hide?: boolean
 		}[]
-	}, [system.info])
+	}, [system.info, system.updates, system.power, systemStats])
 
 	/** Space for tooltip if more than 12 containers */
 	useEffect(() => {
@@ -374,6 +443,11 @@ export default function SystemDetail({ name }: { name: string }) {
 	const lastGpuVals = Object.values(systemStats.at(-1)?.stats.g ?? {})
 	const hasGpuData = lastGpuVals.length > 0
 	const hasGpuPowerData = lastGpuVals.some((gpu) => gpu.p !== undefined)
+	// energy used over the chart period, summed from the energy of each record
+	const energyKwh = chartData.systemStats.reduce((sum, { stats }) => sum + (stats?.kwh ?? 0), 0)
+	const energyDesc = energyCost
+		? t`${decimalString(energyKwh, 2)} kWh used in this period, costing ${decimalString(energyKwh * energyCost, 2)}`
+		: t`${decimalString(energyKwh, 2)} kWh used in this period`
 
 	let translatedStatus: string = system.status
 	if (system.status === "up") {
@@ -532,6 +606,18 @@ export default function SystemDetail({ name }: { name: string }) {
 						<AreaChartDefault chartData={chartData} chartName="bw" maxToggled={maxValues} />
 					</ChartCard>
 
+					{/* Link utilization chart */}
+					{systemStats.at(-1)?.stats.nu && (
+						<ChartCard
+							empty={dataEmpty}
+							grid={grid}
+							title={t`Link Utilization`}
+							description={t`Percent of link speed used by network interfaces`}
+						>
+							<SensorChart chartData={chartData} dataKey="nu" unit="%" />
+						</ChartCard>
+					)}
+
 					{containerFilterBar && containerData.length > 0 && (
 						<div
 							ref={netCardRef}
@@ -563,17 +649,39 @@ export default function SystemDetail({ name }: { name: string }) {
 						</ChartCard>
 					)}
 
-					{/* Temperature chart */}
-					{systemStats.at(-1)?.stats.t && (
+					{/* Temperature charts, one per sensor alias group */}
+					{getSensorGroups(systemStats.at(-1)?.stats.t, system.sensor_groups).map((group) => (
 						<ChartCard
+							key={group}
 							empty={dataEmpty}
 							grid={grid}
-							title={t`Temperature`}
+							title={groupTitle(t`Temperature`, group)}
 							description={t`Temperatures of system sensors`}
 							cornerEl={<FilterBar store={$temperatureFilter} />}
 						>
-							<TemperatureChart chartData={chartData} />
+							<TemperatureChart chartData={chartData} sensorGroups={system.sensor_groups} group={group} />
 						</ChartCard>
+					))}
+
+					{/* Hardware sensor charts */}
+					{sensorCharts.map(({ dataKey, title, description, unit }) =>
+						getSensorGroups(systemStats.at(-1)?.stats[dataKey], system.sensor_groups).map((group) => (
+							<ChartCard
+								key={dataKey + group}
+								empty={dataEmpty}
+								grid={grid}
+								title={groupTitle(title(), group)}
+								description={description()}
+							>
+								<SensorChart
+									chartData={chartData}
+									dataKey={dataKey}
+									unit={unit}
+									sensorGroups={system.sensor_groups}
+									group={group}
+								/>
+							</ChartCard>
+						))
 					)}
 
 					{/* GPU power draw chart */}
@@ -587,6 +695,74 @@ export default function SystemDetail({ name }: { name: string }) {
 							<GpuPowerChart chartData={chartData} />
 						</ChartCard>
 					)}
+
+					{/* RAPL power chart */}
+					{systemStats.at(-1)?.stats.rp && (
+						<ChartCard empty={dataEmpty} grid={grid} title={t`CPU and Memory Power`} description={energyDesc}>
+							<SensorChart chartData={chartData} dataKey="rp" unit=" W" />
+						</ChartCard>
+					)}
+
+					{/* Custom metrics chart */}
+					{systemStats.at(-1)?.stats.cm && (
+						<ChartCard
+							empty={dataEmpty}
+							grid={grid}
+							title={t`Custom Metrics`}
+							description={t`Values from custom commands, metrics files and exporters`}
+							cornerEl={<FilterBar store={$customMetricsFilter} />}
+						>
+							<CustomMetricsChart chartData={chartData} />
+						</ChartCard>
+					)}
+
+					{/* Probe latency chart */}
+					{systemStats.at(-1)?.stats.pr && (
+						<ChartCard
+							empty={dataEmpty}
+							grid={grid}
+							title={t`Probe Latency`}
+							description={t`Response time of probes run by the agent`}
+						>
+							<ProbeChart chartData={chartData} />
+						</ChartCard>
+					)}
+
+					{/* CPU frequency chart */}
+					{systemStats.at(-1)?.stats.cf && (
+						<ChartCard
+							empty={dataEmpty}
+							grid={grid}
+							title={t`CPU Frequency`}
+							description={t`Frequency across cpus and thermal throttle events`}
+						>
+							<CpuFreqChart chartData={chartData} />
+						</ChartCard>
+					)}
+
+					{/* ZFS ARC chart */}
+					{systemStats.at(-1)?.stats.arc && (
+						<ChartCard
+							empty={dataEmpty}
+							grid={grid}
+							title={t`ZFS ARC`}
+							description={t`Percent of reads served from the ARC and L2ARC`}
+						>
+							<ArcChart chartData={chartData} />
+						</ChartCard>
+					)}
+
+					{/* TCP connections chart */}
+					{systemStats.at(-1)?.stats.tcp && (
+						<ChartCard
+							empty={dataEmpty}
+							grid={grid}
+							title={t`TCP Connections`}
+							description={t`Connections per state and retransmitted segments`}
+						>
+							<ConnectionsChart chartData={chartData} />
+						</ChartCard>
+					)}
 				</div>
 
 				{/* GPU charts */}
@@ -660,6 +836,69 @@ export default function SystemDetail({ name }: { name: string }) {
 						})}
 					</div>
 				)}
+
+				{/* certificates */}
+				{(system.certs?.length ?? 0) > 0 && (
+					<TableCard title={t`Certificates`} description={t`TLS certificates checked by the agent`}>
+						<CertificatesTable certs={system.certs!} />
+					</TableCard>
+				)}
+
+				{/* zfs pools */}
+				{(system.zfs_pools?.length ?? 0) > 0 && (
+					<TableCard title={t`ZFS Pools`} description={t`Health, usage and errors of ZFS pools`}>
+						<ZfsPoolsTable pools={system.zfs_pools!} />
+					</TableCard>
+				)}
+
+				{/* network links */}
+				{(system.links?.length ?? 0) > 0 && (
+					<TableCard title={t`Network Links`} description={t`State and speed of monitored network interfaces`}>
+						<LinksTable links={system.links!} />
+					</TableCard>
+				)}
+
+				{/* md raid arrays */}
+				{(system.raid?.length ?? 0) > 0 && (
+					<TableCard title={t`RAID Arrays`} description={t`State and sync progress of md software RAID arrays`}>
+						<RaidTable arrays={system.raid!} />
+					</TableCard>
+				)}
+
+				{/* smart health */}
+				{(system.smart?.length ?? 0) > 0 && (
+					<TableCard title={t`SMART Health`} description={t`Health, wear and errors reported by disks`}>
+						<SmartTable devices={system.smart!} />
+					</TableCard>
+				)}
+
+				{/* batteries and ups devices */}
+				{(system.power?.b?.length ?? 0) + (system.power?.u?.length ?? 0) > 0 && (
+					<TableCard title={t`Power`} description={t`Batteries and UPS devices`}>
+						<PowerTable power={system.power!} />
+					</TableCard>
+				)}
+
+				{/* logged in users and recent logins */}
+				{(system.sessions?.length ?? 0) + (system.logins?.length ?? 0) > 0 && (
+					<TableCard title={t`Logins`} description={t`Logged in users and recent logins`}>
+						<SessionsTable sessions={system.sessions ?? []} logins={system.logins ?? []} />
+					</TableCard>
+				)}
+
+				{/* listening sockets */}
+				{(system.listeners?.length ?? 0) > 0 && (
+					<TableCard title={t`Listening Ports`} description={t`TCP and UDP sockets accepting connections`}>
+						<ListenersTable listeners={system.listeners!} />
+					</TableCard>
+				)}
+
+				{/* inventory */}
+				{system.inventory && (
+					<TableCard title={t`Inventory`} description={t`Hardware and operating system details`}>
+						<InventoryTable inventory={system.inventory} />
+					</TableCard>
+				)}
 			</div>
 
 			{/* add space for tooltip if more than 12 containers */}
@@ -715,6 +954,18 @@ const SelectAvgMax = memo(({ max }: { max: boolean }) => {
 	)
 })
 
+function TableCard({ title, description, children }: { title: string; description: string; children: React.ReactNode }) {
+	return (
+		<Card className="pb-2">
+			<CardHeader className="pb-4 pt-4 space-y-1 max-sm:py-3 max-sm:px-4">
+				<CardTitle className="text-xl sm:text-2xl">{title}</CardTitle>
+				<CardDescription>{description}</CardDescription>
+			</CardHeader>
+			<Suspense fallback={<Spinner />}>{children}</Suspense>
+		</Card>
+	)
+}
+
 function ChartCard({
 	title,
 	description,
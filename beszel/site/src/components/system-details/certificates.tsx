@@ -0,0 +1,51 @@
+import { t } from "@lingui/core/macro"
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table"
+import { cn, decimalString } from "@/lib/utils"
+import { CertStatus } from "@/types"
+import { memo } from "react"
+
+/** Table of certificates checked by the agent */
+export default memo(function CertificatesTable({ certs }: { certs: CertStatus[] }) {
+	return (
+		<Table>
+			<TableHeader>
+				<TableRow>
+					<TableHead>{t`Target`}</TableHead>
+					<TableHead>{t`Subject`}</TableHead>
+					<TableHead>{t`Issuer`}</TableHead>
+					<TableHead>{t`Expires`}</TableHead>
+					<TableHead className="text-end">{t`Days Left`}</TableHead>
+				</TableRow>
+			</TableHeader>
+			<TableBody>
+				{certs.map((cert) => (
+					<TableRow key={cert.n}>
+						<TableCell className="font-medium">{cert.n}</TableCell>
+						{cert.e ? (
+							<TableCell colSpan={4} className="text-red-500">
+								{cert.e}
+							</TableCell>
+						) : (
+							<>
+								<TableCell>
+									{cert.sub}
+									{cert.sm && <span className="ms-2 text-yellow-600">{t`Hostname mismatch`}</span>}
+								</TableCell>
+								<TableCell>{cert.i}</TableCell>
+								<TableCell className="tabular-nums">{new Date((cert.x ?? 0) * 1000).toLocaleDateString()}</TableCell>
+								<TableCell
+									className={cn("text-end tabular-nums", {
+										"text-red-500": cert.d < 7,
+										"text-yellow-600": cert.d >= 7 && cert.d < 30,
+									})}
+								>
+									{decimalString(Math.floor(cert.d), 0)}
+								</TableCell>
+							</>
+						)}
+					</TableRow>
+				))}
+			</TableBody>
+		</Table>
+	)
+})
@@ -0,0 +1,51 @@
+import { t } from "@lingui/core/macro"
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table"
+import { decimalString, getSizeAndUnit } from "@/lib/utils"
+import { Inventory } from "@/types"
+import { memo } from "react"
+
+function formatSize(gb: number) {
+	const { v, u } = getSizeAndUnit(gb)
+	return decimalString(v, v >= 100 ? 0 : 1) + u
+}
+
+/** Hardware and OS inventory reported by the agent */
+export default memo(function InventoryTable({ inventory }: { inventory: Inventory }) {
+	const rows: [string, string][] = []
+	const add = (label: string, value?: string) => value && rows.push([label, value])
+
+	add(t`Operating System`, inventory.os ?? [inventory.osid, inventory.osv].filter(Boolean).join(" "))
+	add(t`Model`, [inventory.sv, inventory.pm].filter(Boolean).join(" "))
+	add(t`Serial Number`, inventory.sn)
+	add(t`BIOS`, [inventory.bv, inventory.bver, inventory.bd && `(${inventory.bd})`].filter(Boolean).join(" "))
+	add(t`Memory`, inventory.m ? formatSize(inventory.m) : undefined)
+	for (const dimm of inventory.dimms ?? []) {
+		add(dimm.n, formatSize(dimm.s))
+	}
+	for (const nic of inventory.nics ?? []) {
+		add(nic.n, [nic.m, nic.drv && `(${nic.drv})`, nic.mac].filter(Boolean).join(" "))
+	}
+	for (const disk of inventory.disks ?? []) {
+		const details = [disk.m, formatSize(disk.s), disk.rot ? t`HDD` : t`SSD`, disk.sn && `S/N ${disk.sn}`]
+		add(disk.n, details.filter(Boolean).join(" · "))
+	}
+
+	return (
+		<Table>
+			<TableHeader>
+				<TableRow>
+					<TableHead>{t`Component`}</TableHead>
+					<TableHead>{t`Details`}</TableHead>
+				</TableRow>
+			</TableHeader>
+			<TableBody>
+				{rows.map(([label, value]) => (
+					<TableRow key={label}>
+						<TableCell className="font-medium whitespace-nowrap">{label}</TableCell>
+						<TableCell>{value}</TableCell>
+					</TableRow>
+				))}
+			</TableBody>
+		</Table>
+	)
+})
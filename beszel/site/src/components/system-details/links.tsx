@@ -0,0 +1,45 @@
+import { t } from "@lingui/core/macro"
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table"
+import { cn } from "@/lib/utils"
+import { NetLink } from "@/types"
+import { memo } from "react"
+
+/** Formats a link speed in Mbps, using Gbps for 1000 and above */
+const formatSpeed = (mbps?: number) => {
+	if (!mbps) {
+		return ""
+	}
+	return mbps >= 1000 ? `${mbps / 1000} Gbps` : `${mbps} Mbps`
+}
+
+/** Table of network interfaces with their link state, speed and duplex */
+export default memo(function LinksTable({ links }: { links: NetLink[] }) {
+	return (
+		<Table>
+			<TableHeader>
+				<TableRow>
+					<TableHead>{t`Interface`}</TableHead>
+					<TableHead>{t`State`}</TableHead>
+					<TableHead className="text-end">{t`Speed`}</TableHead>
+					<TableHead>{t`Duplex`}</TableHead>
+				</TableRow>
+			</TableHeader>
+			<TableBody>
+				{links.map((link) => {
+					const down = link.s === "down" || link.s === "lowerlayerdown"
+					const slow = !!link.sp && !!link.ms && link.sp < link.ms
+					return (
+						<TableRow key={link.n}>
+							<TableCell className="font-medium">{link.n}</TableCell>
+							<TableCell className={cn({ "text-red-500": down })}>{link.s}</TableCell>
+							<TableCell className={cn("text-end tabular-nums", { "text-red-500": slow })}>
+								{slow ? t`${formatSpeed(link.sp)} (was ${formatSpeed(link.ms)})` : formatSpeed(link.sp)}
+							</TableCell>
+							<TableCell className={cn({ "text-yellow-500": link.dx === "half" })}>{link.dx}</TableCell>
+						</TableRow>
+					)
+				})}
+			</TableBody>
+		</Table>
+	)
+})
@@ -0,0 +1,45 @@
+import { t } from "@lingui/core/macro"
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table"
+import { cn } from "@/lib/utils"
+import { Listener } from "@/types"
+import { memo } from "react"
+
+/** Listeners first seen within this time are highlighted as new */
+const newPeriod = 24 * 60 * 60 * 1000
+
+/** Table of listening sockets reported by the agent */
+export default memo(function ListenersTable({ listeners }: { listeners: Listener[] }) {
+	const newSince = Date.now() - newPeriod
+	return (
+		<Table>
+			<TableHeader>
+				<TableRow>
+					<TableHead>{t`Protocol`}</TableHead>
+					<TableHead>{t`Address`}</TableHead>
+					<TableHead className="text-end">{t`Port`}</TableHead>
+					<TableHead>{t`Process`}</TableHead>
+					<TableHead>{t`First Seen`}</TableHead>
+				</TableRow>
+			</TableHeader>
+			<TableBody>
+				{listeners.map((l) => {
+					const isNew = (l.s ?? 0) > newSince
+					return (
+						<TableRow key={`${l.p} ${l.a} ${l.pt}`}>
+							<TableCell className="uppercase">{l.p}</TableCell>
+							<TableCell className="font-mono">{l.a}</TableCell>
+							<TableCell className="text-end tabular-nums font-medium">{l.pt}</TableCell>
+							<TableCell>
+								{l.c}
+								{l.pid ? <span className="ms-1.5 text-muted-foreground tabular-nums">{l.pid}</span> : null}
+							</TableCell>
+							<TableCell className={cn("tabular-nums", { "text-yellow-600": isNew })}>
+								{l.s ? new Date(l.s).toLocaleString() : t`Initial`}
+							</TableCell>
+						</TableRow>
+					)
+				})}
+			</TableBody>
+		</Table>
+	)
+})
@@ -0,0 +1,64 @@
+import { t } from "@lingui/core/macro"
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table"
+import { cn, decimalString } from "@/lib/utils"
+import { PowerStatus } from "@/types"
+import { memo } from "react"
+
+/** Formats seconds of runtime as minutes, or as hours and minutes if over an hour */
+const formatRuntime = (seconds?: number) => {
+	if (!seconds) {
+		return ""
+	}
+	const minutes = Math.round(seconds / 60)
+	return minutes < 60 ? `${minutes} min` : `${Math.floor(minutes / 60)} h ${minutes % 60} min`
+}
+
+/** Table of batteries and UPS devices */
+export default memo(function PowerTable({ power }: { power: PowerStatus }) {
+	return (
+		<Table>
+			<TableHeader>
+				<TableRow>
+					<TableHead>{t`Name`}</TableHead>
+					<TableHead>{t`Status`}</TableHead>
+					<TableHead className="text-end">{t`Charge`}</TableHead>
+					<TableHead className="text-end">{t`Load`}</TableHead>
+					<TableHead className="text-end">{t`Runtime`}</TableHead>
+				</TableRow>
+			</TableHeader>
+			<TableBody>
+				{power.u?.map((ups) => (
+					<TableRow key={`ups-${ups.n}`}>
+						<TableCell className="font-medium">
+							{ups.n}
+							{ups.m && <span className="ms-2 text-muted-foreground">{ups.m}</span>}
+						</TableCell>
+						{ups.e ? (
+							<TableCell colSpan={4} className="text-red-500">
+								{ups.e}
+							</TableCell>
+						) : (
+							<>
+								<TableCell className={cn({ "text-red-500": ups.lb, "text-yellow-600": ups.ob && !ups.lb })}>
+									{ups.lb ? t`Low battery` : ups.ob ? t`On battery` : t`Online`}
+								</TableCell>
+								<TableCell className="text-end tabular-nums">{decimalString(ups.c ?? 0, 0)}%</TableCell>
+								<TableCell className="text-end tabular-nums">{decimalString(ups.l ?? 0, 0)}%</TableCell>
+								<TableCell className="text-end tabular-nums">{formatRuntime(ups.r)}</TableCell>
+							</>
+						)}
+					</TableRow>
+				))}
+				{power.b?.map((battery) => (
+					<TableRow key={`battery-${battery.n}`}>
+						<TableCell className="font-medium">{battery.n}</TableCell>
+						<TableCell className={cn({ "text-yellow-600": battery.s === "Discharging" })}>{battery.s}</TableCell>
+						<TableCell className="text-end tabular-nums">{decimalString(battery.c, 0)}%</TableCell>
+						<TableCell />
+						<TableCell className="text-end tabular-nums">{formatRuntime(battery.r)}</TableCell>
+					</TableRow>
+				))}
+			</TableBody>
+		</Table>
+	)
+})
@@ -0,0 +1,51 @@
+import { t } from "@lingui/core/macro"
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table"
+import { cn, decimalString } from "@/lib/utils"
+import { RaidArray } from "@/types"
+import { memo } from "react"
+
+/** Table of md RAID arrays with their state, devices and sync progress */
+export default memo(function RaidTable({ arrays }: { arrays: RaidArray[] }) {
+	return (
+		<Table>
+			<TableHeader>
+				<TableRow>
+					<TableHead>{t`Array`}</TableHead>
+					<TableHead>{t`Level`}</TableHead>
+					<TableHead>{t`State`}</TableHead>
+					<TableHead className="text-end">{t`Devices`}</TableHead>
+					<TableHead>{t`Sync`}</TableHead>
+					<TableHead>{t`Problems`}</TableHead>
+				</TableRow>
+			</TableHeader>
+			<TableBody>
+				{arrays.map((array) => {
+					const problems = [
+						array.dg && t`Degraded`,
+						...(array.f ?? []).map((d) => t`${d} failed`),
+						...(array.sa ?? []).map((d) => t`${d} spare`),
+					].filter(Boolean)
+					let sync = ""
+					if (array.sy) {
+						sync = array.sp ? `${array.sy} ${decimalString(array.sp, 1)}%` : array.sy
+						if (array.sf) {
+							sync += ` (${array.sf})`
+						}
+					}
+					return (
+						<TableRow key={array.n}>
+							<TableCell className="font-medium">{array.n}</TableCell>
+							<TableCell>{array.l}</TableCell>
+							<TableCell>{array.s}</TableCell>
+							<TableCell className="text-end tabular-nums">{array.t ? `${array.a ?? 0} / ${array.t}` : ""}</TableCell>
+							<TableCell>{sync}</TableCell>
+							<TableCell className={cn({ "text-red-500": array.dg || array.f?.length })}>
+								{problems.length ? problems.join(", ") : t`None`}
+							</TableCell>
+						</TableRow>
+					)
+				})}
+			</TableBody>
+		</Table>
+	)
+})
@@ -0,0 +1,36 @@
+import { t } from "@lingui/core/macro"
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table"
+import { Session } from "@/types"
+import { memo } from "react"
+
+/** Table of logged in users followed by recent logins */
+export default memo(function SessionsTable({ sessions, logins }: { sessions: Session[]; logins: Session[] }) {
+	const isActive = (login: Session) => sessions.some((s) => s.pid === login.pid && s.t === login.t)
+	const rows = [...sessions.map((s) => ({ ...s, active: true })), ...logins.filter((l) => !isActive(l))]
+	return (
+		<Table>
+			<TableHeader>
+				<TableRow>
+					<TableHead>{t`User`}</TableHead>
+					<TableHead>{t`Terminal`}</TableHead>
+					<TableHead>{t`Source`}</TableHead>
+					<TableHead>{t`Login Time`}</TableHead>
+					<TableHead>{t`Status`}</TableHead>
+				</TableRow>
+			</TableHeader>
+			<TableBody>
+				{rows.map((s, i) => (
+					<TableRow key={i}>
+						<TableCell className="font-medium">{s.u}</TableCell>
+						<TableCell>{s.l}</TableCell>
+						<TableCell className="font-mono">{s.h || t`Local`}</TableCell>
+						<TableCell className="tabular-nums">{new Date(s.t * 1000).toLocaleString()}</TableCell>
+						<TableCell className={"active" in s ? "text-green-600" : "text-muted-foreground"}>
+							{"active" in s ? t`Logged in` : t`Ended`}
+						</TableCell>
+					</TableRow>
+				))}
+			</TableBody>
+		</Table>
+	)
+})
@@ -0,0 +1,56 @@
+import { t } from "@lingui/core/macro"
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table"
+import { cn, decimalString } from "@/lib/utils"
+import { SmartDevice } from "@/types"
+import { memo } from "react"
+
+/** Table of disks with their SMART health, wear and error counts */
+export default memo(function SmartTable({ devices }: { devices: SmartDevice[] }) {
+	return (
+		<Table>
+			<TableHeader>
+				<TableRow>
+					<TableHead>{t`Device`}</TableHead>
+					<TableHead>{t`Model`}</TableHead>
+					<TableHead>{t`Health`}</TableHead>
+					<TableHead className="text-end">{t`Temperature`}</TableHead>
+					<TableHead className="text-end">{t`Power On`}</TableHead>
+					<TableHead className="text-end">{t`Wear`}</TableHead>
+					<TableHead>{t`Problems`}</TableHead>
+				</TableRow>
+			</TableHeader>
+			<TableBody>
+				{devices.map((device) => {
+					const problems = [
+						device.ra && t`${device.ra} reallocated sectors`,
+						device.pe && t`${device.pe} pending sectors`,
+						device.me && t`${device.me} media errors`,
+						...(device.fa ?? []),
+					].filter(Boolean)
+					const failing = device.h === "FAILED" || (device.fa?.length ?? 0) > 0
+					return (
+						<TableRow key={device.n}>
+							<TableCell className="font-medium">{device.n}</TableCell>
+							<TableCell className="max-w-64 truncate" title={device.sn}>
+								{device.m}
+							</TableCell>
+							<TableCell className={cn({ "text-red-500": failing })} title={device.e}>
+								{device.e ? t`Unknown` : device.h}
+							</TableCell>
+							<TableCell className="text-end tabular-nums">
+								{device.t ? `${decimalString(device.t, 0)} °C` : ""}
+							</TableCell>
+							<TableCell className="text-end tabular-nums">{device.hr ? t`${device.hr} h` : ""}</TableCell>
+							<TableCell className="text-end tabular-nums">
+								{device.w ? `${decimalString(device.w, 0)}%` : ""}
+							</TableCell>
+							<TableCell className={cn({ "text-red-500": problems.length })}>
+								{problems.length ? problems.join(", ") : t`None`}
+							</TableCell>
+						</TableRow>
+					)
+				})}
+			</TableBody>
+		</Table>
+	)
+})
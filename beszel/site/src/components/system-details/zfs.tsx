@@ -0,0 +1,55 @@
+import { t } from "@lingui/core/macro"
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table"
+import { cn, decimalString, getSizeAndUnit } from "@/lib/utils"
+import { ZfsPool } from "@/types"
+import { memo } from "react"
+
+/** Formats a size in GB with its unit */
+const formatSize = (gb: number) => {
+	const { v, u } = getSizeAndUnit(gb)
+	return decimalString(v, 1) + u
+}
+
+/** Table of ZFS pools with their health, usage, last scan and device errors */
+export default memo(function ZfsPoolsTable({ pools }: { pools: ZfsPool[] }) {
+	return (
+		<Table>
+			<TableHeader>
+				<TableRow>
+					<TableHead>{t`Pool`}</TableHead>
+					<TableHead>{t`Health`}</TableHead>
+					<TableHead className="text-end">{t`Used`}</TableHead>
+					<TableHead className="text-end">{t`Fragmentation`}</TableHead>
+					<TableHead>{t`Last Scan`}</TableHead>
+					<TableHead>{t`Errors`}</TableHead>
+				</TableRow>
+			</TableHeader>
+			<TableBody>
+				{pools.map((pool) => {
+					const errors = (pool.re ?? 0) + (pool.we ?? 0) + (pool.ce ?? 0)
+					const problems = [
+						errors > 0 && t`${errors} device errors`,
+						pool.de && t`Permanent data errors`,
+						...(pool.d ?? []).map((d) => `${d.n} ${d.s}`),
+					].filter(Boolean)
+					return (
+						<TableRow key={pool.n}>
+							<TableCell className="font-medium">{pool.n}</TableCell>
+							<TableCell className={cn({ "text-red-500": pool.h !== "ONLINE" })}>{pool.h}</TableCell>
+							<TableCell className="text-end tabular-nums">
+								{formatSize(pool.a)} / {formatSize(pool.s)} ({decimalString(pool.c, 0)}%)
+							</TableCell>
+							<TableCell className="text-end tabular-nums">{decimalString(pool.f, 0)}%</TableCell>
+							<TableCell className={cn("max-w-96 truncate", { "text-red-500": pool.se })} title={pool.sc}>
+								{pool.sc}
+							</TableCell>
+							<TableCell className={cn({ "text-red-500": problems.length })}>
+								{problems.length ? problems.join(", ") : t`None`}
+							</TableCell>
+						</TableRow>
+					)
+				})}
+			</TableBody>
+		</Table>
+	)
+})
@@ -65,7 +65,7 @@ import {
 import { memo, useEffect, useMemo, useRef, useState } from "react"
 import { $systems, pb } from "@/lib/stores"
 import { useStore } from "@nanostores/react"
-import { cn, copyToClipboard, decimalString, isReadOnlyUser, useLocalStorage } from "@/lib/utils"
+import { cn, convertTemperature, copyToClipboard, decimalString, isReadOnlyUser, useLocalStorage } from "@/lib/utils"
 import AlertsButton from "../alerts/alert-button"
 import { $router, Link, navigate } from "../router"
 import { EthernetIcon, GpuIcon, ThermometerIcon } from "../ui/icons"
@@ -253,13 +253,14 @@ export default function SystemsTable() {
 					if (!val) {
 						return null
 					}
+					const temp = convertTemperature(val)
 					return (
 						<span
 							className={cn("tabular-nums whitespace-nowrap", {
 								"ps-1.5": viewMode === "table",
 							})}
 						>
-							{decimalString(val)} °C
+							{decimalString(temp.value)} {temp.unit}
 						</span>
 					)
 				},
@@ -8,7 +8,25 @@ import { RecordModel, RecordSubscription } from "pocketbase"
 import { WritableAtom } from "nanostores"
 import { timeDay, timeHour } from "d3-time"
 import { useEffect, useState } from "react"
-import { CpuIcon, HardDriveIcon, MemoryStickIcon, ServerIcon } from "lucide-react"
+import {
+	ActivityIcon,
+	BatteryWarningIcon,
+	CpuIcon,
+	DatabaseIcon,
+	FanIcon,
+	GaugeIcon,
+	HardDriveIcon,
+	HourglassIcon,
+	LogInIcon,
+	MemoryStickIcon,
+	NetworkIcon,
+	PackageIcon,
+	PlugZapIcon,
+	ServerIcon,
+	ShieldAlertIcon,
+	TriangleAlertIcon,
+	ZapIcon,
+} from "lucide-react"
 import { EthernetIcon, ThermometerIcon } from "@/components/ui/icons"
 import { prependBasePath } from "@/components/router"
 
@@ -237,6 +255,23 @@ export function toFixedFloat(num: number, digits: number) {
 	return parseFloat(num.toFixed(digits))
 }
 
+/** Converts a temperature from celsius to the user's preferred unit */
+export function convertTemperature(celsius: number, unit = $userSettings.get().temperatureUnit) {
+	if (unit === "fahrenheit") {
+		return { value: (celsius * 9) / 5 + 32, unit: "°F" }
+	}
+	return { value: celsius, unit: "°C" }
+}
+
+/** Returns the alias groups of the sensors in a stats map, with "" for ungrouped sensors first */
+export function getSensorGroups(sensors?: Record<string, number>, groups?: Record<string, string>) {
+	const names = new Set<string>()
+	for (const key of Object.keys(sensors ?? {})) {
+		names.add(groups?.[key] ?? "")
+	}
+	return [...names].sort()
+}
+
 let decimalFormatters: Map<number, Intl.NumberFormat> = new Map()
 /** Format number to x decimal places */
 export function decimalString(num: number, digits = 2) {
@@ -346,6 +381,143 @@ export const alertInfo: Record<string, AlertInfo> = {
 		icon: ThermometerIcon,
 		desc: () => t`Triggers when any sensor exceeds a threshold`,
 	},
+	Collector: {
+		name: () => t`Collector Errors`,
+		unit: "",
+		icon: TriangleAlertIcon,
+		desc: () => t`Triggers when an agent collector keeps failing`,
+		singleDesc: () => t`Collector failing`,
+	},
+	Custom: {
+		name: () => t`Custom Metrics`,
+		unit: "",
+		icon: GaugeIcon,
+		desc: () => t`Triggers when a custom metric exceeds a threshold`,
+		max: 1000,
+		metric: true,
+	},
+	Probe: {
+		name: () => t`Probe Failures`,
+		unit: "%",
+		icon: ActivityIcon,
+		desc: () => t`Triggers when failures of any probe exceed a threshold`,
+	},
+	Certificate: {
+		name: () => t`Certificate Expiry`,
+		unit: " " + t`days`,
+		icon: ShieldAlertIcon,
+		desc: () => t`Triggers when any certificate expires within a number of days, is not valid for its hostname, or can't be checked`,
+		max: 90,
+		start: 14,
+		invert: true,
+	},
+	Updates: {
+		name: () => t`Security Updates`,
+		unit: " " + t`days`,
+		icon: PackageIcon,
+		desc: () => t`Triggers when security updates are pending for a number of days`,
+		valueDesc: () => t`Pending for`,
+		max: 60,
+		start: 7,
+	},
+	Listener: {
+		name: () => t`New Listening Port`,
+		unit: "",
+		icon: NetworkIcon,
+		desc: () => t`Triggers when a new TCP or UDP port is opened for listening`,
+		singleDesc: () => t`New port open`,
+	},
+	Login: {
+		name: () => t`User Logins`,
+		unit: "",
+		icon: LogInIcon,
+		desc: () => t`Triggers on logins from unknown networks or by watched users (set in notification settings)`,
+		singleDesc: () => t`New login`,
+	},
+	CloseWait: {
+		name: () => t`Close Wait Connections`,
+		unit: "",
+		icon: PlugZapIcon,
+		desc: () => t`Triggers when TCP connections in CLOSE_WAIT exceed a threshold`,
+		max: 1000,
+		start: 100,
+	},
+	TimeWait: {
+		name: () => t`Time Wait Connections`,
+		unit: "",
+		icon: HourglassIcon,
+		desc: () => t`Triggers when TCP connections in TIME_WAIT exceed a threshold`,
+		max: 50000,
+		start: 10000,
+	},
+	Fan: {
+		name: () => t`Fan Speed`,
+		unit: " RPM",
+		icon: FanIcon,
+		desc: () => t`Triggers when any fan falls below a speed`,
+		max: 3000,
+		start: 300,
+		invert: true,
+	},
+	Voltage: {
+		name: () => t`Voltage`,
+		unit: "",
+		icon: ZapIcon,
+		desc: () => t`Triggers when a voltage is outside the sensor chip's limits`,
+		singleDesc: () => t`Voltage out of range`,
+	},
+	Throttle: {
+		name: () => t`CPU Throttling`,
+		unit: " /min",
+		icon: CpuIcon,
+		desc: () => t`Triggers when thermal throttle events exceed a rate`,
+		max: 100,
+		start: 1,
+	},
+	Pool: {
+		name: () => t`ZFS Pool Health`,
+		unit: "",
+		icon: DatabaseIcon,
+		desc: () => t`Triggers when a ZFS pool is degraded or has checksum or device errors`,
+		singleDesc: () => t`Pool unhealthy`,
+	},
+	Link: {
+		name: () => t`Network Link`,
+		unit: "",
+		icon: EthernetIcon,
+		desc: () => t`Triggers when a network interface goes down or negotiates a lower speed than before`,
+		singleDesc: () => t`Link down or slow`,
+	},
+	Raid: {
+		name: () => t`RAID Health`,
+		unit: "",
+		icon: HardDriveIcon,
+		desc: () => t`Triggers when an md RAID array is degraded or has failed devices`,
+		singleDesc: () => t`Array degraded`,
+	},
+	Smart: {
+		name: () => t`SMART Health`,
+		unit: "",
+		icon: ShieldAlertIcon,
+		desc: () => t`Triggers when a disk fails its SMART health check or has failing attributes`,
+		singleDesc: () => t`Disk failing`,
+	},
+	Battery: {
+		name: () => t`On Battery`,
+		unit: "",
+		icon: BatteryWarningIcon,
+		desc: () => t`Triggers when the system loses AC power and runs on a UPS or battery`,
+		singleDesc: () => t`Running on battery`,
+	},
+	Runtime: {
+		name: () => t`Battery Runtime`,
+		unit: " min",
+		icon: HourglassIcon,
+		desc: () => t`Triggers when battery runtime falls below a number of minutes`,
+		max: 120,
+		start: 10,
+		invert: true,
+	},
 }
 
 /**
@@ -15,9 +15,288 @@ export interface SystemRecord extends RecordModel {
 	status: "up" | "down" | "paused" | "pending"
 	port: string
 	info: SystemInfo
+	/** agent self-metrics and collector status */
+	agent?: AgentStats
+	/** tls certificates checked by the agent */
+	certs?: CertStatus[]
+	/** hardware and os inventory */
+	inventory?: Inventory
+	/** pending package updates */
+	updates?: UpdateStatus
+	/** listening sockets */
+	listeners?: Listener[]
+	/** logged in users */
+	sessions?: Session[]
+	/** recent logins, newest first */
+	logins?: Session[]
+	/** alias group of each grouped sensor */
+	sensor_groups?: Record<string, string>
+	/** batteries, ac adapters and ups devices */
+	power?: PowerStatus
+	/** zfs pool health and usage */
+	zfs_pools?: ZfsPool[]
+	/** link state of monitored network interfaces */
+	links?: NetLink[]
+	/** md software raid arrays */
+	raid?: RaidArray[]
+	/** smart health of disks */
+	smart?: SmartDevice[]
 	v: string
 }
 
+export interface NetLink {
+	/** name */
+	n: string
+	/** operstate (up, down, lowerlayerdown, etc) */
+	s: string
+	/** negotiated speed (Mbps) */
+	sp?: number
+	/** highest speed negotiated, kept by the hub (Mbps) */
+	ms?: number
+	/** duplex (full, half) */
+	dx?: string
+}
+
+export interface RaidArray {
+	/** name */
+	n: string
+	/** level (raid1, raid5, etc) */
+	l?: string
+	/** state (active, inactive) */
+	s: string
+	/** total devices */
+	t?: number
+	/** active devices */
+	a?: number
+	/** degraded */
+	dg?: boolean
+	/** failed devices */
+	f?: string[]
+	/** spare devices */
+	sa?: string[]
+	/** sync action in progress (resync, recovery, etc) */
+	sy?: string
+	/** sync percent complete */
+	sp?: number
+	/** estimated time left of sync */
+	sf?: string
+}
+
+export interface SmartDevice {
+	/** name */
+	n: string
+	/** model */
+	m?: string
+	/** serial number */
+	sn?: string
+	/** protocol (ATA, SCSI, NVMe) */
+	p?: string
+	/** health (PASSED, FAILED) */
+	h?: string
+	/** temperature (celsius) */
+	t?: number
+	/** power on hours */
+	hr?: number
+	/** reallocated sectors */
+	ra?: number
+	/** sectors pending reallocation */
+	pe?: number
+	/** percent of rated life used */
+	w?: number
+	/** nvme media errors */
+	me?: number
+	/** failing attributes */
+	fa?: string[]
+	/** error reading the device */
+	e?: string
+}
+
+export interface ZfsPool {
+	/** name */
+	n: string
+	/** health (ONLINE, DEGRADED, FAULTED, etc) */
+	h: string
+	/** size (GB) */
+	s: number
+	/** allocated (GB) */
+	a: number
+	/** fragmentation (percent) */
+	f: number
+	/** capacity used (percent) */
+	c: number
+	/** last or current scrub or resilver */
+	sc?: string
+	/** errors found by the last scrub */
+	se?: number
+	/** read errors */
+	re?: number
+	/** write errors */
+	we?: number
+	/** checksum errors */
+	ce?: number
+	/** permanent data errors */
+	de?: boolean
+	/** devices which are not online or have errors */
+	d?: { n: string; s: string; r?: number; w?: number; c?: number }[]
+}
+
+export interface PowerStatus {
+	/** ac adapter online, undefined if the system has none */
+	ac?: boolean
+	/** batteries */
+	b?: Battery[]
+	/** ups devices */
+	u?: Ups[]
+}
+
+export interface Battery {
+	/** name */
+	n: string
+	/** capacity (percent) */
+	c: number
+	/** status (Charging, Discharging, Full, Not charging) */
+	s: string
+	/** estimated runtime while discharging (seconds) */
+	r?: number
+}
+
+export interface Ups {
+	/** name */
+	n: string
+	/** manufacturer and model */
+	m?: string
+	/** nut status flags */
+	s?: string
+	/** on battery */
+	ob?: boolean
+	/** low battery */
+	lb?: boolean
+	/** load (percent) */
+	l?: number
+	/** battery charge (percent) */
+	c?: number
+	/** runtime on battery (seconds) */
+	r?: number
+	/** error querying upsd */
+	e?: string
+}
+
+export interface CertStatus {
+	/** host:port or file path */
+	n: string
+	/** subject common name */
+	sub?: string
+	/** issuer */
+	i?: string
+	/** expiry (unix seconds) */
+	x?: number
+	/** days until expiry */
+	d: number
+	/** hostname mismatch */
+	sm?: boolean
+	/** error loading certificate */
+	e?: string
+}
+
+export interface Session {
+	/** user */
+	u: string
+	/** terminal */
+	l: string
+	/** source address or hostname */
+	h?: string
+	/** login time (unix seconds) */
+	t: number
+	/** process id */
+	pid?: number
+}
+
+export interface Listener {
+	/** protocol (tcp or udp) */
+	p: string
+	/** address */
+	a: string
+	/** port */
+	pt: number
+	/** process name */
+	c?: string
+	/** process id */
+	pid?: number
+	/** first seen (unix ms), 0 if present at the first report */
+	s?: number
+}
+
+export interface UpdateStatus {
+	/** package manager */
+	pm: string
+	/** pending updates */
+	p: number
+	/** security updates */
+	s: number
+	/** names of packages with security updates */
+	sp?: string[]
+	/** oldest pending security update first seen (unix seconds) */
+	ss?: number
+	/** first seen of each package with a security update (unix seconds) */
+	fs?: Record<string, number>
+	/** reboot required */
+	r?: boolean
+	/** last check (unix seconds) */
+	c: number
+	/** error from last check */
+	e?: string
+}
+
+export interface Inventory {
+	/** os name */
+	os?: string
+	/** os id */
+	osid?: string
+	/** os version */
+	osv?: string
+	/** system vendor */
+	sv?: string
+	/** product model */
+	pm?: string
+	/** serial number */
+	sn?: string
+	/** bios vendor */
+	bv?: string
+	/** bios version */
+	bver?: string
+	/** bios date */
+	bd?: string
+	/** total memory (gb) */
+	m?: number
+	dimms?: { n: string; s: number }[]
+	nics?: { n: string; mac?: string; drv?: string; m?: string }[]
+	disks?: { n: string; m?: string; sn?: string; s: number; rot?: boolean }[]
+}
+
+export interface AgentStats {
+	/** agent resident memory (mb) */
+	m: number
+	/** number of goroutines */
+	g: number
+	/** time to collect the payload (ms) */
+	ct: number
+	/** collector status */
+	c: CollectorStatus[]
+}
+
+export interface CollectorStatus {
+	/** name */
+	n: string
+	/** duration (ms) */
+	d: number
+	/** error message */
+	e?: string
+	/** timed out */
+	to?: boolean
+	/** failing since (unix ms) */
+	s?: number
+}
+
 export interface SystemInfo {
 	/** hostname */
 	h: string
@@ -51,6 +330,8 @@ export interface SystemInfo {
 	dt?: number
 	/** operating system */
 	os?: Os
+	/** error from the last run of each failing probe */
+	pe?: Record<string, string>
 }
 
 export interface SystemStats {
@@ -58,6 +339,8 @@ export interface SystemStats {
 	cpu: number
 	/** peak cpu */
 	cpum?: number
+	/** 95th percentile cpu */
+	cpu95?: number
 	/** total memory (gb) */
 	m: number
 	/** memory used (gb) */
@@ -86,6 +369,10 @@ export interface SystemStats {
 	drm?: number
 	/** max disk write (mb) */
 	dwm?: number
+	/** 95th percentile disk read (mb) */
+	dr95?: number
+	/** 95th percentile disk write (mb) */
+	dw95?: number
 	/** network sent (mb) */
 	ns: number
 	/** network received (mb) */
@@ -94,12 +381,71 @@ export interface SystemStats {
 	nsm?: number
 	/** max network received (mb) */
 	nrm?: number
+	/** 95th percentile network sent (mb) */
+	ns95?: number
+	/** 95th percentile network received (mb) */
+	nr95?: number
 	/** temperatures */
 	t?: Record<string, number>
 	/** extra filesystems */
 	efs?: Record<string, ExtraFsStats>
 	/** GPU data */
 	g?: Record<string, GPUData>
+	/** custom metrics */
+	cm?: Record<string, number>
+	/** probe results */
+	pr?: Record<string, ProbeStats>
+	/** tcp connections per state */
+	tcp?: Record<string, number>
+	/** tcp retransmitted segments per second */
+	trs?: number
+	/** fan speeds (rpm) */
+	fan?: Record<string, number>
+	/** voltages (v) */
+	vol?: Record<string, number>
+	/** currents (a) */
+	cur?: Record<string, number>
+	/** power (w) */
+	pwr?: Record<string, number>
+	/** voltages outside the sensor chip's limits */
+	va?: number
+	/** cpu frequencies keyed by cpu */
+	cf?: Record<string, CpuFreq>
+	/** thermal throttle events per minute */
+	thr?: number
+	/** watts by rapl domain, e.g. package-0 */
+	rp?: Record<string, number>
+	/** kwh used during the record's period (rapl and gpu power) */
+	kwh?: number
+	/** zfs arc efficiency */
+	arc?: {
+		/** arc hit ratio (percent) */
+		h?: number
+		/** l2arc hit ratio (percent) */
+		l2h?: number
+		/** l2arc size (GB) */
+		l2?: number
+	}
+	/** percent of link speed used by network interface */
+	nu?: Record<string, number>
+}
+
+export interface CpuFreq {
+	/** current (mhz) */
+	c: number
+	/** minimum (mhz) */
+	n: number
+	/** maximum allowed by hardware, policy and firmware limits (mhz) */
+	x: number
+	/** hardware maximum (mhz) */
+	h: number
+}
+
+export interface ProbeStats {
+	/** latency (ms) */
+	l: number
+	/** success (%) */
+	s: number
 }
 
 export interface GPUData {
@@ -160,6 +506,8 @@ export interface AlertRecord extends RecordModel {
 	system: string
 	name: string
 	triggered: boolean
+	/** custom metric name or pattern (Custom alerts) */
+	metric?: string
 	sysname?: string
 	// user: string
 }
@@ -182,6 +530,14 @@ export type UserSettings = {
 	chartTime: ChartTimes
 	emails?: string[]
 	webhooks?: string[]
+	/** known login source networks for login alerts */
+	loginNetworks?: string[]
+	/** users whose logins always trigger login alerts */
+	loginUsers?: string[]
+	/** unit temperatures are displayed in */
+	temperatureUnit?: "celsius" | "fahrenheit"
+	/** cost per kwh used to show energy costs */
+	energyCost?: number
 }
 
 type ChartDataContainer = {
@@ -205,6 +561,14 @@ interface AlertInfo {
 	icon: any
 	desc: () => string
 	max?: number
+	/** Initial threshold value (default 80) */
+	start?: number
+	/** Triggers when the value falls below the threshold rather than above it */
+	invert?: boolean
+	/** Label for the threshold value when it is not an average */
+	valueDesc?: () => string
 	/** Single value description (when there's only one value, like status) */
 	singleDesc?: () => string
+	/** Applies to the custom metrics matching a name or pattern set on the alert */
+	metric?: boolean
 }